@@ -14,14 +14,22 @@ limitations under the License.
 package analyze
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/agent"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai/interactive"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/server"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -42,6 +50,21 @@ var (
 	customAnalysis  bool
 	customHeaders   []string
 	withStats       bool
+	minSeverity     string
+	fromSnapshot    string
+	baseline        string
+	watch           bool
+	aiBudget        int
+	aiGroup         bool
+	dedupe          bool
+	stream          bool
+	structured      bool
+	explainContext  []string
+	contextTokens   int
+	agentMode       bool
+	agentMaxSteps   int
+	targetVersion   string
+	watchResync     time.Duration
 )
 
 // AnalyzeCmd represents the problems command
@@ -66,6 +89,7 @@ var AnalyzeCmd = &cobra.Command{
 			interactiveMode,
 			customHeaders,
 			withStats,
+			fromSnapshot,
 		)
 
 		verbose := viper.GetBool("verbose")
@@ -81,15 +105,82 @@ var AnalyzeCmd = &cobra.Command{
 		}
 		defer config.Close()
 
-		if customAnalysis {
-			config.RunCustomAnalysis()
-			if verbose {
-				fmt.Println("Debug: All custom analyzers completed.")
+		if minSeverity != "" {
+			severity, err := common.ParseSeverity(minSeverity)
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
 			}
+			config.MinSeverity = severity
 		}
-		config.RunAnalysis()
-		if verbose {
-			fmt.Println("Debug: All core analyzers completed.")
+		config.CustomAnalysis = customAnalysis
+		config.Baseline = baseline
+		config.Dedupe = dedupe
+		config.AIBudget = aiBudget
+		config.GroupExplanations = aiGroup
+		config.Structured = structured
+		if config.ExplainContext, err = analysis.ParseExplainContext(explainContext); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		config.ContextTokens = contextTokens
+		if targetVersion != "" {
+			if _, err := analyzer.ParseKubernetesVersion(targetVersion); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			config.TargetVersion = targetVersion
+		}
+		if agentMode {
+			if !explain || structured {
+				color.Red("Error: --agent requires --explain and cannot be combined with --structured")
+				os.Exit(1)
+			}
+			if !ai.SupportsTools(config.AIClient) {
+				color.Red("Error: --agent requires a backend that supports tool calling: openai, azureopenai, litellm or anthropic")
+				os.Exit(1)
+			}
+			config.Agent = &agent.Agent{Tools: server.AgentTools(config.Client), MaxSteps: agentMaxSteps}
+		}
+		if anonymize && viper.GetString(redact.ConfigKey+".key") == "" {
+			// Pseudonyms are only stable across runs with a saved key.
+			key, err := redact.GenerateKey()
+			if err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			// Notices go to stderr to keep JSON, SARIF and JUnit output valid.
+			if err := redact.SaveKey(key); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v; pseudonyms are only stable within this run\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "Generated a redaction key and saved it as %s.key in %s\n", redact.ConfigKey, viper.ConfigFileUsed())
+			}
+		}
+		if stream && output == "text" {
+			config.Stream = os.Stdout
+		}
+
+		if watch {
+			if explain || interactiveMode {
+				color.Red("Error: --watch cannot be combined with --explain or --interactive")
+				os.Exit(1)
+			}
+			if baseline != "" || dedupe || customAnalysis {
+				color.Red("Error: --watch cannot be combined with --baseline, --dedupe or --custom-analysis")
+				os.Exit(1)
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+			if err := config.Watch(ctx, os.Stdout, output, watchResync); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			return
+		}
+
+		if err := config.Analyze(); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
 		}
 
 		if explain {
@@ -125,7 +216,13 @@ var AnalyzeCmd = &cobra.Command{
 			}
 			sigs := make(chan os.Signal, 1)
 			signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-			interactiveClient := interactive.NewInteractionRunner(config, output_data)
+			contextWindow := output_data
+			if config.Stream != nil {
+				// The streamed results are not part of the printed output.
+				contextWindow, _ = config.PrintOutput("json")
+			}
+			interactiveClient := interactive.NewInteractionRunner(config, contextWindow)
+			interactiveClient.Anonymize = anonymize
 
 			go interactiveClient.StartInteraction()
 			for {
@@ -152,7 +249,7 @@ func init() {
 	// no cache flag
 	AnalyzeCmd.Flags().BoolVarP(&nocache, "no-cache", "c", false, "Do not use cached data")
 	// anonymize flag
-	AnalyzeCmd.Flags().BoolVarP(&anonymize, "anonymize", "a", false, "Anonymize data before sending it to the AI backend. This flag masks sensitive data, such as Kubernetes object names and labels, by replacing it with a key. However, please note that this flag does not currently apply to events.")
+	AnalyzeCmd.Flags().BoolVarP(&anonymize, "anonymize", "a", false, "Anonymize data before sending it to the AI backend. Object names, namespaces, IP addresses, hostnames, emails, tokens and the patterns configured under redaction.patterns are replaced by stable pseudonyms and restored in the response.")
 	// array of strings flag
 	AnalyzeCmd.Flags().StringSliceVarP(&filters, "filter", "f", []string{}, "Filter for these analyzers (e.g. Pod, PersistentVolumeClaim, Service, ReplicaSet)")
 	// explain flag
@@ -160,11 +257,11 @@ func init() {
 	// add flag for backend
 	AnalyzeCmd.Flags().StringVarP(&backend, "backend", "b", "", "Backend AI provider")
 	// output as json
-	AnalyzeCmd.Flags().StringVarP(&output, "output", "o", "text", "Output format (text, json)")
+	AnalyzeCmd.Flags().StringVarP(&output, "output", "o", "text", "Output format (text, json, sarif, junit)")
 	// add language options for output
 	AnalyzeCmd.Flags().StringVarP(&language, "language", "l", "english", "Languages to use for AI (e.g. 'English', 'Spanish', 'French', 'German', 'Italian', 'Portuguese', 'Dutch', 'Russian', 'Chinese', 'Japanese', 'Korean')")
 	// add max concurrency
-	AnalyzeCmd.Flags().IntVarP(&maxConcurrency, "max-concurrency", "m", 10, "Maximum number of concurrent requests to the Kubernetes API server")
+	AnalyzeCmd.Flags().IntVarP(&maxConcurrency, "max-concurrency", "m", 10, "Maximum number of concurrent requests to the Kubernetes API server and the AI backend")
 	// kubernetes doc flag
 	AnalyzeCmd.Flags().BoolVarP(&withDoc, "with-doc", "d", false, "Give me the official documentation of the involved field")
 	// interactive mode flag
@@ -177,4 +274,30 @@ func init() {
 	AnalyzeCmd.Flags().StringVarP(&labelSelector, "selector", "L", "", "Label selector (label query) to filter on, supports '=', '==', and '!='. (e.g. -L key1=value1,key2=value2). Matching objects must satisfy all of the specified label constraints.")
 	// print stats
 	AnalyzeCmd.Flags().BoolVarP(&withStats, "with-stat", "s", false, "Print analysis stats. This option disables errors display.")
+	// minimum severity flag
+	AnalyzeCmd.Flags().StringVar(&minSeverity, "min-severity", "", "Only report failures at or above this severity (critical, high, medium, low, info)")
+	// snapshot flag
+	AnalyzeCmd.Flags().StringVar(&fromSnapshot, "from-snapshot", "", "Analyze a directory or tarball of YAML/JSON manifests instead of a live cluster (e.g. a kubectl get -o yaml dump or a must-gather)")
+	// baseline flag
+	AnalyzeCmd.Flags().StringVar(&baseline, "baseline", "", "Path to a previous analysis written with --output=json; only findings that are not in it are reported")
+	// AI budget flags
+	AnalyzeCmd.Flags().IntVar(&aiBudget, "ai-budget", 0, "Approximate maximum number of tokens to spend on explanations per run; remaining results are marked as unexplained (0 means unlimited)")
+	AnalyzeCmd.Flags().BoolVar(&aiGroup, "ai-group", false, "Explain results of the same kind with similar failures with a single prompt")
+	// dedupe flag
+	AnalyzeCmd.Flags().BoolVar(&dedupe, "dedupe", false, "Collapse identical failures of objects with the same parent, e.g. the replicas of a Deployment, into one result")
+	// stream flag
+	AnalyzeCmd.Flags().BoolVar(&stream, "stream", false, "With --explain and text output, print explanations as they are generated, one result at a time")
+	// agent flags
+	AnalyzeCmd.Flags().BoolVar(&agentMode, "agent", false, "With --explain, let the AI backend investigate each result with read-only cluster tools before answering; the transcript is part of the JSON output")
+	AnalyzeCmd.Flags().IntVar(&agentMaxSteps, "agent-max-steps", agent.DefaultMaxSteps, "Maximum number of requests of an --agent investigation")
+	// explain context flags
+	AnalyzeCmd.Flags().StringSliceVar(&explainContext, "explain-context", []string{}, "With --explain, attach context about each object to the prompt: events, spec, logs, owner")
+	AnalyzeCmd.Flags().IntVar(&contextTokens, "explain-context-tokens", analysis.DefaultContextTokens, "Approximate token cap of each --explain-context section")
+	// structured explanations flag
+	AnalyzeCmd.Flags().BoolVar(&structured, "structured", false, "With --explain, ask the AI backend for a JSON explanation with a cause, confidence, steps, kubectl commands and documentation links, exposed as explanation in the JSON output")
+	// deprecation flag
+	AnalyzeCmd.Flags().StringVar(&targetVersion, "target-version", "", "Kubernetes version the Deprecation analyzer checks upgrade readiness for, e.g. 1.32 (defaults to the release after the server)")
+	// watch flags
+	AnalyzeCmd.Flags().BoolVarP(&watch, "watch", "w", false, "Keep running and report findings as they appear or are resolved (text or json output only)")
+	AnalyzeCmd.Flags().DurationVar(&watchResync, "watch-resync", 10*time.Minute, "In watch mode, how often to re-run analyzers whose resources cannot be watched (0 disables)")
 }
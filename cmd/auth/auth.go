@@ -36,6 +36,10 @@ var (
 	organizationId  string
 	azureAPIType    string
 	azureAPIVersion string
+	// retry policy
+	maxRetries            int
+	requestsPerMinute     int
+	maxConcurrentRequests int
 )
 
 var configAI ai.AIConfiguration
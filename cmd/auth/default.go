@@ -24,6 +24,7 @@ import (
 
 var (
 	providerName string
+	fallbacks    []string
 )
 
 var defaultCmd = &cobra.Command{
@@ -36,12 +37,21 @@ var defaultCmd = &cobra.Command{
 			color.Red("Error: %v", err)
 			os.Exit(1)
 		}
+		if cmd.Flags().Changed("fallbacks") {
+			setFallbacks()
+			if providerName == "" {
+				return
+			}
+		}
 		if providerName == "" {
 			if configAI.DefaultProvider != "" {
 				color.Yellow("Your default provider is %s", configAI.DefaultProvider)
 			} else {
 				color.Yellow("Your default provider is openai")
 			}
+			if len(configAI.Fallbacks) > 0 {
+				color.Yellow("Your fallback providers are %s", strings.Join(configAI.Fallbacks, ", "))
+			}
 			os.Exit(0)
 		}
 		// lowercase the provider name
@@ -73,7 +83,44 @@ var defaultCmd = &cobra.Command{
 	},
 }
 
+// setFallbacks validates and stores the providers tried, in order, when the
+// default one fails. An empty list removes the fallbacks.
+func setFallbacks() {
+	names := make([]string, 0, len(fallbacks))
+	for _, name := range fallbacks {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		providerExists := false
+		for _, provider := range configAI.Providers {
+			if provider.Name == name {
+				providerExists = true
+			}
+		}
+		if !providerExists {
+			color.Red("Error: Provider %s does not exist", name)
+			os.Exit(1)
+		}
+		names = append(names, name)
+	}
+	configAI.Fallbacks = names
+
+	viper.Set("ai", configAI)
+	if err := viper.WriteConfig(); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+	if len(names) == 0 {
+		color.Green("Fallback providers removed")
+		return
+	}
+	color.Green("Fallback providers set to %s", strings.Join(names, ", "))
+}
+
 func init() {
 	// provider name flag
 	defaultCmd.Flags().StringVarP(&providerName, "provider", "p", "", "The name of the provider to set as default")
+	// fallback providers flag
+	defaultCmd.Flags().StringSliceVar(&fallbacks, "fallbacks", []string{}, "Providers to try, in order, when the default provider fails (e.g. --fallbacks=azureopenai,ollama); pass an empty value to remove them")
 }
@@ -16,6 +16,7 @@ package auth
 import (
 	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/spf13/pflag"
 )
 
 func newAIProviderFromAuthFlags(name string) ai.AIProvider {
@@ -37,6 +38,10 @@ func newAIProviderFromAuthFlags(name string) ai.AIProvider {
 		OrganizationId:  organizationId,
 		AzureAPIType:    azureAPIType,
 		AzureAPIVersion: azureAPIVersion,
+
+		MaxRetries:            maxRetries,
+		RequestsPerMinute:     requestsPerMinute,
+		MaxConcurrentRequests: maxConcurrentRequests,
 	}
 }
 
@@ -74,3 +79,41 @@ func applyAIProviderUpdates(provider *ai.AIProvider, name string) {
 	}
 	provider.Temperature = temperature
 }
+
+// applyRetryPolicyUpdates updates the retry policy fields whose flags were
+// set, since zero is a valid value for all of them.
+func applyRetryPolicyUpdates(provider *ai.AIProvider, flags *pflag.FlagSet) {
+	if flags.Changed("max-retries") {
+		provider.MaxRetries = maxRetries
+		color.Blue("Max retries updated successfully")
+	}
+	if flags.Changed("requests-per-minute") {
+		provider.RequestsPerMinute = requestsPerMinute
+		color.Blue("Requests per minute updated successfully")
+	}
+	if flags.Changed("max-concurrent-requests") {
+		provider.MaxConcurrentRequests = maxConcurrentRequests
+		color.Blue("Max concurrent requests updated successfully")
+	}
+}
+
+// addRetryPolicyFlags registers the retry policy flags shared by add and update.
+func addRetryPolicyFlags(flags *pflag.FlagSet) {
+	flags.IntVar(&maxRetries, "max-retries", 0, "Number of times a request that failed with a rate limit, server or network error is retried with exponential backoff (0 disables retries)")
+	flags.IntVar(&requestsPerMinute, "requests-per-minute", 0, "Maximum number of requests per minute sent to the provider (0 means unlimited)")
+	flags.IntVar(&maxConcurrentRequests, "max-concurrent-requests", 0, "Maximum number of requests in flight to the provider (0 means unlimited)")
+}
+
+// removeFallback drops name from the fallback providers of config and
+// reports whether it was one of them.
+func removeFallback(config *ai.AIConfiguration, name string) bool {
+	fallbacks := make([]string, 0, len(config.Fallbacks))
+	for _, fallback := range config.Fallbacks {
+		if fallback != name {
+			fallbacks = append(fallbacks, fallback)
+		}
+	}
+	removed := len(fallbacks) != len(config.Fallbacks)
+	config.Fallbacks = fallbacks
+	return removed
+}
@@ -194,6 +194,34 @@ func TestAddCommandModelDefaultPerBackend(t *testing.T) {
 	}
 }
 
+func TestRemoveCommandDropsFallback(t *testing.T) {
+	configureTestViper(t)
+	resetAuthFlagState(t)
+
+	viper.Set("ai", ai.AIConfiguration{
+		Providers:       []ai.AIProvider{{Name: "openai"}, {Name: "ollama"}, {Name: "amazonbedrock"}},
+		DefaultProvider: "openai",
+		Fallbacks:       []string{"ollama", "amazonbedrock"},
+	})
+	if err := viper.WriteConfig(); err != nil {
+		t.Fatalf("failed to write initial config: %v", err)
+	}
+
+	setFlag(t, removeCmd, "backends", "ollama")
+	removeCmd.Run(removeCmd, nil)
+
+	var cfg ai.AIConfiguration
+	if err := viper.UnmarshalKey("ai", &cfg); err != nil {
+		t.Fatalf("failed to unmarshal ai config: %v", err)
+	}
+	if len(cfg.Providers) != 2 {
+		t.Fatalf("expected two providers, got %d", len(cfg.Providers))
+	}
+	if !reflect.DeepEqual(cfg.Fallbacks, []string{"amazonbedrock"}) {
+		t.Fatalf("expected the removed provider to be dropped from the fallbacks, got %#v", cfg.Fallbacks)
+	}
+}
+
 func resetAuthFlagState(t *testing.T) {
 	t.Helper()
 
@@ -214,6 +242,9 @@ func resetAuthFlagState(t *testing.T) {
 	organizationId = ""
 	azureAPIType = ""
 	azureAPIVersion = ""
+	maxRetries = 0
+	requestsPerMinute = 0
+	maxConcurrentRequests = 0
 }
 
 func configureTestViper(t *testing.T) {
@@ -53,6 +53,10 @@ var removeCmd = &cobra.Command{
 						configAI.DefaultProvider = "openai"
 					}
 					color.Green("%s deleted from the AI backend provider list", b)
+					// A fallback without a provider would fail every explanation.
+					if removeFallback(&configAI, b) {
+						color.Yellow("%s removed from the fallback providers", b)
+					}
 					break
 				}
 			}
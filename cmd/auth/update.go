@@ -75,6 +75,7 @@ var updateCmd = &cobra.Command{
 			if backend == provider.Name {
 				foundBackend = true
 				applyAIProviderUpdates(&configAI.Providers[i], backend)
+				applyRetryPolicyUpdates(&configAI.Providers[i], cmd.Flags())
 				color.Green("%s updated in the AI backend provider list", backend)
 			}
 		}
@@ -110,4 +111,6 @@ func init() {
 	updateCmd.Flags().StringVarP(&azureAPIType, "azureAPIType", "a", "", fmt.Sprintf("AzureOpenAI API Type name. Valid values: %s, %s or %s (only for azureopenai backend)", openai.APITypeAzure, openai.APITypeAzureAD, openai.APITypeCloudflareAzure))
 	// add flag for azure open ai API version
 	updateCmd.Flags().StringVarP(&azureAPIVersion, "azureAPIVersion", "", "", "AzureOpenAI API version, e.g. 2024-02-15-preview")
+	// add flags for the retry policy
+	addRetryPolicyFlags(updateCmd.Flags())
 }
@@ -17,6 +17,7 @@ package cache
 import (
 	"os"
 	"reflect"
+	"strconv"
 
 	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
@@ -55,7 +56,7 @@ var listCmd = &cobra.Command{
 		table.Header(headers)
 
 		for _, v := range names {
-			if err := table.Append([]string{v.Name, v.UpdatedAt.String()}); err != nil {
+			if err := table.Append([]string{v.Name, v.UpdatedAt.String(), strconv.FormatInt(v.Size, 10)}); err != nil {
 				color.Red("Error: %v", err)
 				os.Exit(1)
 			}
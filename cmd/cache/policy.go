@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var (
+	ttl        time.Duration
+	maxEntries int
+	maxSize    string
+)
+
+// policyCmd represents the policy command
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Show or set the eviction policy of the cache",
+	Long: `This command shows the eviction policy of the cache, or changes the limits given as flags.
+	Entries older than --ttl are dropped, and the least recently used ones while the cache holds more
+	than --max-entries or --max-size. A value of 0 removes the limit. Only the file cache enforces the policy.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheInfo, err := cache.ParseCacheConfiguration()
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		policy := cacheInfo.Policy
+		flags := cmd.Flags()
+		if flags.Changed("ttl") {
+			policy.TTL = ttl
+		}
+		if flags.Changed("max-entries") {
+			policy.MaxEntries = maxEntries
+		}
+		if flags.Changed("max-size") {
+			size, err := resource.ParseQuantity(maxSize)
+			if err != nil {
+				color.Red("Error: invalid --max-size %q: %v", maxSize, err)
+				os.Exit(1)
+			}
+			policy.MaxSize = size.Value()
+		}
+		if policy.TTL < 0 || policy.MaxEntries < 0 || policy.MaxSize < 0 {
+			color.Red("Error: the limits of the policy cannot be negative")
+			os.Exit(1)
+		}
+
+		if policy != cacheInfo.Policy {
+			if err := cache.SetPolicy(policy); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+			color.Green("Cache policy updated")
+		}
+		fmt.Printf("TTL: %s\n", limit(policy.TTL != 0, policy.TTL.String()))
+		fmt.Printf("Max entries: %s\n", limit(policy.MaxEntries != 0, fmt.Sprint(policy.MaxEntries)))
+		fmt.Printf("Max size: %s\n", limit(policy.MaxSize != 0, formatSize(policy.MaxSize)))
+	},
+}
+
+func limit(set bool, value string) string {
+	if !set {
+		return "none"
+	}
+	return value
+}
+
+func init() {
+	CacheCmd.AddCommand(policyCmd)
+	policyCmd.Flags().DurationVar(&ttl, "ttl", 0, "Drop entries older than this duration, e.g. 24h")
+	policyCmd.Flags().IntVar(&maxEntries, "max-entries", 0, "Keep at most this many entries")
+	policyCmd.Flags().StringVar(&maxSize, "max-size", "0", "Keep at most this many bytes, e.g. 100Mi")
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics about the cache",
+	Long: `This command shows the number of entries and the size of the cache, its hit ratio
+	and how old its entries are. Hits and misses are only counted by the file cache.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := cache.GetCacheConfiguration()
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		stats, err := cache.GetStats(c, time.Now())
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Cache: %s\n", c.GetName())
+		fmt.Printf("Entries: %d\n", stats.Entries)
+		fmt.Printf("Size: %s\n", formatSize(stats.Size))
+		if stats.HitsRecorded {
+			fmt.Printf("Hit ratio: %.1f%% (%d hits, %d misses)\n", 100*stats.HitRatio(), stats.Hits, stats.Misses)
+		} else {
+			fmt.Println("Hit ratio: not recorded by this cache")
+		}
+
+		table := tablewriter.NewWriter(os.Stdout)
+		table.Header([]string{"Age", "Entries", "Size"})
+		for _, bucket := range stats.Ages {
+			if err := table.Append([]string{bucket.Label, strconv.Itoa(bucket.Entries), formatSize(bucket.Size)}); err != nil {
+				color.Red("Error: %v", err)
+				os.Exit(1)
+			}
+		}
+		if err := table.Render(); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func formatSize(size int64) string {
+	return resource.NewQuantity(size, resource.BinarySI).String()
+}
+
+func init() {
+	CacheCmd.AddCommand(statsCmd)
+}
@@ -31,7 +31,11 @@ var addCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		inputFilters := strings.Split(args[0], ",")
-		coreFilters, additionalFilters, integrationFilters := analyzer.ListFilters()
+		coreFilters, additionalFilters, integrationFilters, err := analyzer.ListFilters()
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
 
 		availableFilters := append(append(coreFilters, additionalFilters...), integrationFilters...)
 		// Verify filter exist
@@ -15,6 +15,7 @@ package filters
 
 import (
 	"fmt"
+	"os"
 	"slices"
 
 	"github.com/fatih/color"
@@ -31,7 +32,11 @@ var listCmd = &cobra.Command{
 	Long:  `The list command displays a list of available filters that can be used to analyze Kubernetes resources.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		activeFilters := viper.GetStringSlice("active_filters")
-		coreFilters, additionalFilters, integrationFilters := analyzer.ListFilters()
+		coreFilters, additionalFilters, integrationFilters, err := analyzer.ListFilters()
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
 		integration := integration.NewIntegration()
 		availableFilters := append(append(coreFilters, additionalFilters...), integrationFilters...)
 
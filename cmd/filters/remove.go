@@ -34,7 +34,11 @@ var removeCmd = &cobra.Command{
 
 		// Get defined active_filters
 		activeFilters := viper.GetStringSlice("active_filters")
-		coreFilters, _, _ := analyzer.ListFilters()
+		coreFilters, _, _, err := analyzer.ListFilters()
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
 
 		if len(activeFilters) == 0 {
 			activeFilters = coreFilters
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignore
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
+	"github.com/spf13/cobra"
+)
+
+var (
+	kind      string
+	namespace string
+	name      string
+	text      string
+	until     string
+	reason    string
+)
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a rule that silences matching findings",
+	Long: `The add command adds a rule that silences the findings it matches.
+	Empty fields match anything, but at least one of --kind, --namespace, --name or --text is required.
+
+	Examples:
+	  k8sgpt ignore add --kind ConfigMap --namespace kube-system --text "is not used"
+	  k8sgpt ignore add --kind Pod --name "foo/*" --until 2026-12-01`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rule := ignore.Rule{
+			Kind:      kind,
+			Namespace: namespace,
+			Name:      name,
+			Text:      text,
+			Until:     until,
+			Reason:    reason,
+		}
+		if err := rule.Validate(); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+
+		rules := loadRules()
+		for _, existing := range rules {
+			if existing.ID == rule.ID {
+				color.Red("Error: rule %s already exists. Please use k8sgpt ignore list.", rule.ID)
+				os.Exit(1)
+			}
+		}
+
+		saveRules(append(rules, rule))
+		color.Green("Ignore rule %s added", rule.ID)
+	},
+}
+
+func init() {
+	addCmd.Flags().StringVarP(&kind, "kind", "k", "", "Analyzer or result kind to ignore (e.g. Pod, ConfigMap, Security)")
+	addCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace of the objects to ignore")
+	addCmd.Flags().StringVar(&name, "name", "", "Name of the objects to ignore, supports globs and namespace/name (e.g. web-*, foo/*)")
+	addCmd.Flags().StringVarP(&text, "text", "t", "", "Only ignore failures whose text contains this string")
+	addCmd.Flags().StringVarP(&until, "until", "u", "", "Last day (YYYY-MM-DD, UTC) the rule applies, or RFC 3339 time at which it expires")
+	addCmd.Flags().StringVarP(&reason, "reason", "r", "", "Why the findings are ignored")
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignore
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var IgnoreCmd = &cobra.Command{
+	Use:   "ignore",
+	Short: "Manage rules that silence known findings",
+	Long: `The ignore command manages rules that silence known findings of the analyze command.
+	Rules can expire, after which the findings are reported again together with a warning.
+	Single objects can also be excluded with the k8sgpt.ai/ignore annotation, e.g. k8sgpt.ai/ignore: Pod,Log.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			_ = cmd.Help()
+			return
+		}
+	},
+}
+
+func init() {
+	IgnoreCmd.AddCommand(addCmd)
+	IgnoreCmd.AddCommand(listCmd)
+	IgnoreCmd.AddCommand(removeCmd)
+}
+
+func loadRules() []ignore.Rule {
+	var rules []ignore.Rule
+	if err := viper.UnmarshalKey(ignore.ConfigKey, &rules); err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+	return rules
+}
+
+func saveRules(rules []ignore.Rule) {
+	viper.Set(ignore.ConfigKey, rules)
+	if err := viper.WriteConfig(); err != nil {
+		color.Red("Error writing config file: %s", err.Error())
+		os.Exit(1)
+	}
+}
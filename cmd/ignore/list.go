@@ -0,0 +1,56 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignore
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the ignore rules",
+	Long:  `The list command displays the configured ignore rules and whether they have expired.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		rules := loadRules()
+		if len(rules) == 0 {
+			color.Yellow("No ignore rules configured. Please use k8sgpt ignore add.")
+			return
+		}
+
+		now := time.Now()
+		for _, rule := range rules {
+			status := color.GreenString("active")
+			if rule.Expired(now) {
+				status = color.RedString("expired")
+			}
+			fmt.Printf("> %s (%s)\n", color.YellowString(rule.ID), status)
+			for _, field := range []struct{ name, value string }{
+				{"kind", rule.Kind},
+				{"namespace", rule.Namespace},
+				{"name", rule.Name},
+				{"text", rule.Text},
+				{"until", rule.Until},
+				{"reason", rule.Reason},
+			} {
+				if field.value != "" {
+					fmt.Printf("  %s: %s\n", field.name, field.value)
+				}
+			}
+		}
+	},
+}
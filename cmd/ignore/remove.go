@@ -0,0 +1,46 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignore
+
+import (
+	"os"
+	"slices"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
+	"github.com/spf13/cobra"
+)
+
+var removeCmd = &cobra.Command{
+	Use:   "remove [id(s)]",
+	Short: "Remove one or more ignore rules",
+	Long:  `The remove command removes the ignore rules with the given IDs, as shown by k8sgpt ignore list.`,
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		rules := loadRules()
+		for _, id := range args {
+			index := slices.IndexFunc(rules, func(rule ignore.Rule) bool { return rule.ID == id })
+			if index == -1 {
+				color.Red("Error: rule %s does not exist. Please use k8sgpt ignore list.", id)
+				os.Exit(1)
+			}
+			rules = slices.Delete(rules, index, index+1)
+		}
+
+		saveRules(rules)
+		for _, id := range args {
+			color.Green("Ignore rule %s removed", id)
+		}
+	},
+}
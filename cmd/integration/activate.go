@@ -31,7 +31,11 @@ var activateCmd = &cobra.Command{
 	Args:  cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
 		integrationName := args[0]
-		coreFilters, _, _ := analyzer.ListFilters()
+		coreFilters, _, _, err := analyzer.ListFilters()
+		if err != nil {
+			color.Red("Error: %v", err)
+			return
+		}
 
 		// Update filters
 		activeFilters := viper.GetStringSlice("active_filters")
@@ -41,7 +45,7 @@ var activateCmd = &cobra.Command{
 
 		integration := integration.NewIntegration()
 		// Check if the integation exists
-		err := integration.Activate(integrationName, namespace, activeFilters, skipInstall)
+		err = integration.Activate(integrationName, namespace, activeFilters, skipInstall)
 		if err != nil {
 			color.Red("Error: %v", err)
 			return
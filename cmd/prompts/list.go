@@ -0,0 +1,36 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompts
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the prompt templates",
+	Long:  `The list command displays the user-defined prompt templates and the built-in ones they do not override.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, t := range loadTemplates().List() {
+			name := t.Kind
+			if t.Integration != "" {
+				name = fmt.Sprintf("%s (%s)", t.Kind, t.Integration)
+			}
+			fmt.Printf("> %s: %s\n", color.YellowString(name), t.Source)
+		}
+	},
+}
@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompts
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/prompts"
+	"github.com/spf13/cobra"
+)
+
+var PromptsCmd = &cobra.Command{
+	Use:   "prompts",
+	Short: "Inspect the prompt templates used to explain results",
+	Long: `The prompts command lists and previews the prompt templates sent to the AI backend.
+	Templates are configured under prompts.templates in the config file or as <Kind>.tmpl files,
+	or <integration>/<Kind>.tmpl files, in the directory set by prompts.directory.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			_ = cmd.Help()
+			return
+		}
+	},
+}
+
+func init() {
+	PromptsCmd.AddCommand(listCmd)
+	PromptsCmd.AddCommand(showCmd)
+	PromptsCmd.AddCommand(testCmd)
+}
+
+func loadTemplates() *prompts.Registry {
+	registry, err := prompts.LoadConfig()
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+	return registry
+}
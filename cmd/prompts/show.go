@@ -0,0 +1,51 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompts
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/integration"
+	"github.com/spf13/cobra"
+)
+
+var integrationName string
+
+var showCmd = &cobra.Command{
+	Use:   "show [kind]",
+	Short: "Show the prompt template used for a kind",
+	Long: `The show command prints the prompt template used to explain the results of a kind,
+	or the default template when no kind is given.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		registry := loadTemplates()
+		t := registry.Default()
+		if len(args) == 1 {
+			if integrationName == "" {
+				// Use the integration whose analyzer reports the kind, if any.
+				integrationName, _ = integration.NewIntegration().AnalyzerByIntegration(args[0])
+			}
+			if found, ok := registry.Lookup(args[0], integrationName); ok {
+				t = found
+			}
+		}
+		color.Yellow("Kind: %s, source: %s", t.Kind, t.Source)
+		fmt.Println(t.Template)
+	},
+}
+
+func init() {
+	showCmd.Flags().StringVar(&integrationName, "integration", "", "Integration reporting the kind, e.g. prometheus (defaults to the integration whose analyzer reports the kind)")
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompts
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+var (
+	filter         string
+	namespace      string
+	objectName     string
+	language       string
+	anonymize      bool
+	structured     bool
+	explainContext []string
+	contextTokens  int
+	fromSnapshot   string
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test <kind>",
+	Short: "Preview the prompt for a result of the cluster",
+	Long: `The test command runs the analyzer of a kind and prints the prompt that would explain
+	its first result, or the result of the object given with --name. Nothing is sent to the AI backend.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		kind := args[0]
+		if filter == "" {
+			filter = kind
+		}
+		config, err := analysis.NewAnalysis(
+			"",
+			language,
+			[]string{filter},
+			namespace,
+			"",
+			true,
+			false,
+			10,
+			false,
+			false,
+			[]string{},
+			false,
+			fromSnapshot,
+		)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		defer config.Close()
+		config.Structured = structured
+		if config.ExplainContext, err = analysis.ParseExplainContext(explainContext); err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		config.ContextTokens = contextTokens
+		config.RunAnalysis()
+		for _, analysisErr := range config.Errors {
+			color.Yellow(analysisErr)
+		}
+
+		result, ok := findResult(config.Results, kind, objectName)
+		if !ok {
+			color.Red("Error: no %s result found", kind)
+			os.Exit(1)
+		}
+		prompt, err := config.RenderPrompt(result, anonymize)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		color.Yellow("Prompt for %s %s:", result.Kind, result.Name)
+		fmt.Println(prompt)
+	},
+}
+
+// findResult returns the first result of kind, or the one about the object
+// called name, given as name or namespace/name.
+func findResult(results []common.Result, kind, name string) (common.Result, bool) {
+	for _, result := range results {
+		if result.Kind != kind {
+			continue
+		}
+		if name == "" || result.Name == name || strings.HasSuffix(result.Name, "/"+name) {
+			return result, true
+		}
+	}
+	return common.Result{}, false
+}
+
+func init() {
+	testCmd.Flags().StringVarP(&filter, "filter", "f", "", "Analyzer to run (defaults to the kind)")
+	testCmd.Flags().StringVarP(&namespace, "namespace", "n", "", "Namespace to analyze")
+	testCmd.Flags().StringVar(&objectName, "name", "", "Name of the object whose result is used, as name or namespace/name")
+	testCmd.Flags().StringVarP(&language, "language", "l", "english", "Language of the prompt")
+	testCmd.Flags().BoolVarP(&anonymize, "anonymize", "a", false, "Anonymize the result as analyze --anonymize does")
+	testCmd.Flags().BoolVar(&structured, "structured", false, "Preview the prompt of analyze --structured")
+	testCmd.Flags().StringSliceVar(&explainContext, "explain-context", []string{}, "Attach context about the object as analyze --explain-context does: events, spec, logs, owner")
+	testCmd.Flags().IntVar(&contextTokens, "explain-context-tokens", analysis.DefaultContextTokens, "Approximate token cap of each --explain-context section")
+	testCmd.Flags().StringVar(&fromSnapshot, "from-snapshot", "", "Analyze a directory or tarball of manifests instead of a live cluster")
+}
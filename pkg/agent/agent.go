@@ -0,0 +1,127 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package agent investigates failures with a model that calls read-only
+// cluster tools before it answers.
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+)
+
+const (
+	// DefaultMaxSteps is the default number of requests of an investigation.
+	DefaultMaxSteps = 8
+	// maxToolOutput is the number of characters of a tool result passed to
+	// the model.
+	maxToolOutput = 16000
+)
+
+const (
+	instructions = `You are investigating a failure in a Kubernetes cluster. You can call read-only tools to look at the cluster before answering. Call them as long as they help you find the cause, then answer in the format requested below.`
+	answerNow    = `You have used up your tool calls. Answer now, in the format requested at the start, with what you have found.`
+)
+
+// Tool is a tool the model can call during an investigation.
+type Tool struct {
+	ai.Tool
+	// Call runs the tool with the JSON arguments of the model and returns
+	// its result.
+	Call func(ctx context.Context, arguments string) (string, error)
+}
+
+// Agent lets a model investigate a failure with Tools.
+type Agent struct {
+	Tools []Tool
+	// MaxSteps is the number of requests sent to the model before it has to
+	// answer; 0 uses DefaultMaxSteps.
+	MaxSteps int
+}
+
+// Investigate has client answer prompt after calling the tools it needs,
+// and returns the answer and the transcript of the conversation. With a
+// redactor, the tool results are redacted and the arguments of the calls
+// restored, so the model only sees the pseudonyms of the prompt; the
+// transcript holds the messages as sent.
+func (a *Agent) Investigate(ctx context.Context, client ai.IToolCallingAI, prompt string, redactor *redact.Redactor) (string, []ai.ChatMessage, error) {
+	tools := make([]ai.Tool, 0, len(a.Tools))
+	byName := make(map[string]Tool, len(a.Tools))
+	for _, tool := range a.Tools {
+		tools = append(tools, tool.Tool)
+		byName[tool.Name] = tool
+	}
+
+	transcript := []ai.ChatMessage{{Role: ai.RoleUser, Content: instructions + "\n\n" + prompt}}
+	for step := 1; ; step++ {
+		last := step >= a.maxSteps()
+		if last {
+			transcript = append(transcript, ai.ChatMessage{Role: ai.RoleUser, Content: answerNow})
+		}
+		reply, err := client.GetToolCompletion(ctx, transcript, tools)
+		if err != nil {
+			return "", transcript, err
+		}
+		transcript = append(transcript, reply)
+		if len(reply.ToolCalls) == 0 {
+			return reply.Content, transcript, nil
+		}
+		if last {
+			if reply.Content != "" {
+				return reply.Content, transcript, nil
+			}
+			return "", transcript, fmt.Errorf("no answer after %d steps", step)
+		}
+		for _, call := range reply.ToolCalls {
+			transcript = append(transcript, ai.ChatMessage{
+				Role:       ai.RoleTool,
+				ToolCallID: call.ID,
+				Content:    a.call(ctx, byName, call, redactor),
+			})
+		}
+	}
+}
+
+func (a *Agent) maxSteps() int {
+	if a.MaxSteps > 0 {
+		return a.MaxSteps
+	}
+	return DefaultMaxSteps
+}
+
+// call runs a tool call and returns its result, or the error for the model
+// to correct its call.
+func (a *Agent) call(ctx context.Context, tools map[string]Tool, call ai.ToolCall, redactor *redact.Redactor) string {
+	tool, ok := tools[call.Name]
+	if !ok {
+		return fmt.Sprintf("Error: unknown tool %q", call.Name)
+	}
+	arguments := call.Arguments
+	if redactor != nil {
+		arguments = redactor.Restore(arguments)
+	}
+	output, err := tool.Call(ctx, arguments)
+	if err != nil {
+		output = "Error: " + err.Error()
+	}
+	if redactor != nil {
+		output = redactor.Redact(output)
+	}
+	if len(output) > maxToolOutput {
+		output = output[:maxToolOutput] + "\n... (truncated)"
+	}
+	return output
+}
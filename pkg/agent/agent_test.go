@@ -0,0 +1,123 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedClient replies with its replies in order and records the
+// conversations it received.
+type scriptedClient struct {
+	replies       []ai.ChatMessage
+	conversations [][]ai.ChatMessage
+}
+
+func (c *scriptedClient) GetToolCompletion(ctx context.Context, messages []ai.ChatMessage, tools []ai.Tool) (ai.ChatMessage, error) {
+	c.conversations = append(c.conversations, append([]ai.ChatMessage(nil), messages...))
+	if len(c.replies) == 0 {
+		return ai.ChatMessage{}, errors.New("no more replies")
+	}
+	reply := c.replies[0]
+	c.replies = c.replies[1:]
+	return reply, nil
+}
+
+func callLogs(id, arguments string) ai.ChatMessage {
+	return ai.ChatMessage{Role: ai.RoleAssistant, ToolCalls: []ai.ToolCall{{ID: id, Name: "get-logs", Arguments: arguments}}}
+}
+
+func logsTool(calls *[]string) Tool {
+	return Tool{
+		Tool: ai.Tool{Name: "get-logs"},
+		Call: func(ctx context.Context, arguments string) (string, error) {
+			*calls = append(*calls, arguments)
+			if strings.Contains(arguments, "missing") {
+				return "", errors.New("pod not found")
+			}
+			return "panic: cannot reach 10.0.0.12", nil
+		},
+	}
+}
+
+func TestInvestigate(t *testing.T) {
+	var calls []string
+	client := &scriptedClient{replies: []ai.ChatMessage{
+		callLogs("1", `{"podName":"missing"}`),
+		{Role: ai.RoleAssistant, ToolCalls: []ai.ToolCall{
+			{ID: "2", Name: "get-logs", Arguments: `{"podName":"web"}`},
+			{ID: "3", Name: "delete-pod", Arguments: `{}`},
+		}},
+		{Role: ai.RoleAssistant, Content: "Error: the database is unreachable."},
+	}}
+	agent := &Agent{Tools: []Tool{logsTool(&calls)}}
+
+	answer, transcript, err := agent.Investigate(context.Background(), client, "Explain: web crashes", nil)
+	require.NoError(t, err)
+	require.Equal(t, "Error: the database is unreachable.", answer)
+	require.Equal(t, []string{`{"podName":"missing"}`, `{"podName":"web"}`}, calls)
+
+	require.Len(t, transcript, 7)
+	require.True(t, strings.HasPrefix(transcript[0].Content, instructions))
+	require.True(t, strings.HasSuffix(transcript[0].Content, "Explain: web crashes"))
+	require.Equal(t, ai.ChatMessage{Role: ai.RoleTool, ToolCallID: "1", Content: "Error: pod not found"}, transcript[2])
+	require.Equal(t, ai.ChatMessage{Role: ai.RoleTool, ToolCallID: "2", Content: "panic: cannot reach 10.0.0.12"}, transcript[4])
+	require.Equal(t, ai.ChatMessage{Role: ai.RoleTool, ToolCallID: "3", Content: `Error: unknown tool "delete-pod"`}, transcript[5])
+	require.Equal(t, transcript[:6], client.conversations[2])
+}
+
+func TestInvestigateMaxSteps(t *testing.T) {
+	var calls []string
+	client := &scriptedClient{replies: []ai.ChatMessage{
+		callLogs("1", `{"podName":"web"}`),
+		callLogs("2", `{"podName":"web"}`),
+	}}
+	agent := &Agent{Tools: []Tool{logsTool(&calls)}, MaxSteps: 2}
+
+	_, transcript, err := agent.Investigate(context.Background(), client, "Explain: web crashes", nil)
+	require.EqualError(t, err, "no answer after 2 steps")
+	require.Len(t, calls, 1)
+	// The model is asked to answer before its last step.
+	require.Equal(t, ai.ChatMessage{Role: ai.RoleUser, Content: answerNow}, client.conversations[1][3])
+	require.Len(t, transcript, 5)
+}
+
+func TestInvestigateRedaction(t *testing.T) {
+	redactor, err := redact.New(redact.Config{Key: "test-key"})
+	require.NoError(t, err)
+	redactor.AddNames("web")
+	pseudonym := redactor.Redact("web")
+	require.NotEqual(t, "web", pseudonym)
+
+	var calls []string
+	client := &scriptedClient{replies: []ai.ChatMessage{
+		callLogs("1", `{"podName":"`+pseudonym+`"}`),
+		{Role: ai.RoleAssistant, Content: "Error: " + pseudonym + " cannot reach the database."},
+	}}
+	agent := &Agent{Tools: []Tool{logsTool(&calls)}}
+
+	answer, transcript, err := agent.Investigate(context.Background(), client, "Explain: "+pseudonym+" crashes", redactor)
+	require.NoError(t, err)
+	// The tools get the real names and the model only the pseudonyms.
+	require.Equal(t, []string{`{"podName":"web"}`}, calls)
+	require.NotContains(t, transcript[2].Content, "10.0.0.12")
+	require.Equal(t, "Error: "+pseudonym+" cannot reach the database.", answer)
+}
@@ -93,6 +93,52 @@ func (c *AnthropicClient) Configure(config IAIConfig) error {
 }
 
 func (c *AnthropicClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	var textBlocks []string
+	message, err := c.client.Messages.New(ctx, c.messageParams(prompt))
+	if err != nil {
+		return "", err
+	}
+	for _, content := range message.Content {
+		if content.Type == "text" {
+			text := content.AsText().Text
+			if text != "" {
+				textBlocks = append(textBlocks, text)
+			}
+		}
+	}
+	if len(textBlocks) == 0 {
+		return "", errors.New("anthropic response did not include any text content")
+	}
+	return strings.Join(textBlocks, "\n"), nil
+}
+
+func (c *AnthropicClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	stream := c.client.Messages.NewStreaming(ctx, c.messageParams(prompt))
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+		for stream.Next() {
+			event := stream.Current()
+			if event.Type != "content_block_delta" {
+				continue
+			}
+			delta := event.AsContentBlockDelta().Delta
+			if delta.Type != "text_delta" || delta.Text == "" {
+				continue
+			}
+			if !send(ctx, chunks, Chunk{Text: delta.Text}) {
+				return
+			}
+		}
+		if err := stream.Err(); err != nil {
+			send(ctx, chunks, Chunk{Err: err})
+		}
+	}()
+	return chunks, nil
+}
+
+func (c *AnthropicClient) messageParams(prompt string) anthropic.MessageNewParams {
 	params := anthropic.MessageNewParams{
 		Model:     c.model,
 		MaxTokens: int64(c.maxTokens),
@@ -110,24 +156,7 @@ func (c *AnthropicClient) GetCompletion(ctx context.Context, prompt string) (str
 	if len(c.stopSequences) > 0 {
 		params.StopSequences = c.stopSequences
 	}
-
-	var textBlocks []string
-	message, err := c.client.Messages.New(ctx, params)
-	if err != nil {
-		return "", err
-	}
-	for _, content := range message.Content {
-		if content.Type == "text" {
-			text := content.AsText().Text
-			if text != "" {
-				textBlocks = append(textBlocks, text)
-			}
-		}
-	}
-	if len(textBlocks) == 0 {
-		return "", errors.New("anthropic response did not include any text content")
-	}
-	return strings.Join(textBlocks, "\n"), nil
+	return params
 }
 
 func (c *AnthropicClient) GetName() string {
@@ -104,7 +104,19 @@ func (c *AzureAIClient) Configure(config IAIConfig) error {
 
 func (c *AzureAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
 	// Create a completion request
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	resp, err := c.client.CreateChatCompletion(ctx, c.chatRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (c *AzureAIClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	return streamChatCompletion(ctx, c.client, c.chatRequest(prompt))
+}
+
+func (c *AzureAIClient) chatRequest(prompt string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
 		Model: c.model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -113,11 +125,7 @@ func (c *AzureAIClient) GetCompletion(ctx context.Context, prompt string) (strin
 			},
 		},
 		Temperature: c.temperature,
-	})
-	if err != nil {
-		return "", err
 	}
-	return resp.Choices[0].Message.Content, nil
 }
 
 func (c *AzureAIClient) GetName() string {
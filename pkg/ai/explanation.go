@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// StructuredPrompt is the PromptMap key of the prompt asking for an
+// Explanation as JSON, and StructuredRetryPrompt the key of the prompt sent
+// when the first reply could not be parsed.
+const (
+	StructuredPrompt      = "structured"
+	StructuredRetryPrompt = "structuredRetry"
+)
+
+// ExplanationSchema is the JSON schema an explanation must satisfy. It is
+// part of the structured prompt.
+const ExplanationSchema = `{
+  "type": "object",
+  "properties": {
+    "cause": {"type": "string", "minLength": 1},
+    "confidence": {"type": "number", "minimum": 0, "maximum": 1},
+    "steps": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+    "commands": {"type": "array", "items": {"type": "string", "pattern": "^kubectl "}},
+    "docLinks": {"type": "array", "items": {"type": "string", "pattern": "^https?://"}}
+  },
+  "required": ["cause", "confidence", "steps"],
+  "additionalProperties": false
+}`
+
+var explanationSchema = func() *gojsonschema.Schema {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewStringLoader(ExplanationSchema))
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}()
+
+// Explanation is an explanation of a failure split into typed fields.
+type Explanation struct {
+	// Cause explains the failure.
+	Cause string `json:"cause"`
+	// Confidence of the backend in the cause, from 0 to 1.
+	Confidence float64 `json:"confidence"`
+	// Steps to fix the failure, in order.
+	Steps []string `json:"steps"`
+	// Commands are kubectl commands helping to diagnose or fix the failure.
+	Commands []string `json:"commands,omitempty"`
+	// DocLinks point to documentation about the failure.
+	DocLinks []string `json:"docLinks,omitempty"`
+}
+
+// ParseExplanation extracts an Explanation from a completion. Backends often
+// wrap JSON in a markdown code fence or a sentence, so the outermost JSON
+// object of the text is parsed and validated against ExplanationSchema.
+func ParseExplanation(text string) (*Explanation, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end < start {
+		return nil, errors.New("no JSON object found")
+	}
+	object := text[start : end+1]
+
+	result, err := explanationSchema.Validate(gojsonschema.NewStringLoader(object))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if !result.Valid() {
+		problems := make([]string, 0, len(result.Errors()))
+		for _, problem := range result.Errors() {
+			problems = append(problems, problem.String())
+		}
+		return nil, fmt.Errorf("explanation does not match the schema: %s", strings.Join(problems, "; "))
+	}
+
+	var explanation Explanation
+	if err := json.Unmarshal([]byte(object), &explanation); err != nil {
+		return nil, err
+	}
+	return &explanation, nil
+}
+
+// String renders the explanation in the Error/Solution format of the text
+// prompts.
+func (e *Explanation) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Error: %s (confidence %.0f%%)\n", e.Cause, e.Confidence*100)
+	b.WriteString("Solution:\n")
+	for i, step := range e.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+	}
+	if len(e.Commands) > 0 {
+		b.WriteString("Commands:\n")
+		for _, command := range e.Commands {
+			fmt.Fprintf(&b, "  %s\n", command)
+		}
+	}
+	if len(e.DocLinks) > 0 {
+		b.WriteString("Documentation:\n")
+		for _, link := range e.DocLinks {
+			fmt.Fprintf(&b, "  %s\n", link)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const validExplanation = `{
+  "cause": "The image tag does not exist.",
+  "confidence": 0.8,
+  "steps": ["Check the image tag", "Update the Deployment"],
+  "commands": ["kubectl describe pod web"],
+  "docLinks": ["https://kubernetes.io/docs/concepts/containers/images/"]
+}`
+
+func TestParseExplanation(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr string
+	}{
+		{name: "plain", text: validExplanation},
+		{name: "code fence", text: "```json\n" + validExplanation + "\n```"},
+		{name: "surrounding text", text: "Here is the explanation: " + validExplanation + " Hope it helps."},
+		{name: "no JSON", text: "Error: image not found\nSolution: fix the tag", wantErr: "no JSON object found"},
+		{name: "invalid JSON", text: `{"cause": "x",}`, wantErr: "invalid JSON"},
+		{name: "missing cause", text: `{"confidence": 0.5, "steps": ["a"]}`, wantErr: "cause is required"},
+		{name: "confidence out of range", text: `{"cause": "x", "confidence": 80, "steps": ["a"]}`, wantErr: "confidence"},
+		{name: "no steps", text: `{"cause": "x", "confidence": 0.5, "steps": []}`, wantErr: "steps"},
+		{name: "not a kubectl command", text: `{"cause": "x", "confidence": 0.5, "steps": ["a"], "commands": ["rm -rf /"]}`, wantErr: "commands.0"},
+		{name: "unknown field", text: `{"cause": "x", "confidence": 0.5, "steps": ["a"], "severity": "high"}`, wantErr: "severity"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			explanation, err := ParseExplanation(tt.text)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, &Explanation{
+				Cause:      "The image tag does not exist.",
+				Confidence: 0.8,
+				Steps:      []string{"Check the image tag", "Update the Deployment"},
+				Commands:   []string{"kubectl describe pod web"},
+				DocLinks:   []string{"https://kubernetes.io/docs/concepts/containers/images/"},
+			}, explanation)
+		})
+	}
+}
+
+func TestExplanationString(t *testing.T) {
+	explanation, err := ParseExplanation(validExplanation)
+	require.NoError(t, err)
+	require.Equal(t, `Error: The image tag does not exist. (confidence 80%)
+Solution:
+1. Check the image tag
+2. Update the Deployment
+Commands:
+  kubectl describe pod web
+Documentation:
+  https://kubernetes.io/docs/concepts/containers/images/`, explanation.String())
+}
+
+func TestStructuredPrompt(t *testing.T) {
+	prompt := fmt.Sprintf(PromptMap[StructuredPrompt], "english", "Back-off pulling image")
+	require.Contains(t, prompt, "--- english ---")
+	require.Contains(t, prompt, "--- Back-off pulling image ---")
+	require.Contains(t, prompt, ExplanationSchema)
+	require.NotContains(t, prompt, "%!")
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/sashabaranov/go-openai"
+	"golang.org/x/time/rate"
+)
+
+// RetryPolicy limits the requests sent to a provider and retries the ones
+// that fail with a transient error. Zero values disable a setting.
+type RetryPolicy struct {
+	MaxRetries            int
+	RequestsPerMinute     int
+	MaxConcurrentRequests int
+}
+
+// RetryPolicy returns the retry and rate limit settings of the provider.
+func (p *AIProvider) RetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:            p.MaxRetries,
+		RequestsPerMinute:     p.RequestsPerMinute,
+		MaxConcurrentRequests: p.MaxConcurrentRequests,
+	}
+}
+
+// IsZero reports whether the policy neither retries nor limits requests.
+func (p RetryPolicy) IsZero() bool {
+	return p == RetryPolicy{}
+}
+
+var (
+	// retryBackoff is the delay before the first retry. It doubles with
+	// every further attempt, up to maxRetryBackoff, and is jittered.
+	retryBackoff    = time.Second
+	maxRetryBackoff = 30 * time.Second
+)
+
+// RetryingClient wraps a client with a RetryPolicy.
+type RetryingClient struct {
+	IAI
+
+	policy  RetryPolicy
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+// NewRetryingClient applies policy to the requests sent through client.
+func NewRetryingClient(client IAI, policy RetryPolicy) *RetryingClient {
+	c := &RetryingClient{IAI: client, policy: policy}
+	if policy.RequestsPerMinute > 0 {
+		c.limiter = rate.NewLimiter(rate.Every(time.Minute/time.Duration(policy.RequestsPerMinute)), 1)
+	}
+	if policy.MaxConcurrentRequests > 0 {
+		c.slots = make(chan struct{}, policy.MaxConcurrentRequests)
+	}
+	return c
+}
+
+func (c *RetryingClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	var completion string
+	err := c.do(ctx, func() error {
+		var err error
+		completion, err = c.IAI.GetCompletion(ctx, prompt)
+		return err
+	})
+	return completion, err
+}
+
+// GetCompletionStream retries until a stream has started. A request only
+// counts against MaxConcurrentRequests until its first chunk arrives.
+func (c *RetryingClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	var stream <-chan Chunk
+	err := c.do(ctx, func() error {
+		chunks, err := GetCompletionStream(ctx, c.IAI, prompt)
+		if err != nil {
+			return err
+		}
+		stream, err = peekStream(ctx, chunks)
+		return err
+	})
+	return stream, err
+}
+
+func (c *RetryingClient) do(ctx context.Context, call func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.acquire(ctx); err != nil {
+			return err
+		}
+		err := call()
+		c.release()
+		if err == nil || attempt >= c.policy.MaxRetries || !IsRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *RetryingClient) acquire(ctx context.Context) error {
+	if c.slots != nil {
+		select {
+		case c.slots <- struct{}{}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx); err != nil {
+			c.release()
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *RetryingClient) release() {
+	if c.slots != nil {
+		<-c.slots
+	}
+}
+
+func backoff(attempt int) time.Duration {
+	delay := retryBackoff << attempt
+	if delay <= 0 || delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	// Spread retries of concurrent requests over the second half of the delay.
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// statusPattern finds rate limiting and server errors in the messages of
+// backends that do not return typed errors. Status codes only count where
+// they are shaped like one, e.g. "status code: 429", "HTTP 503",
+// "status":502 or "504 Gateway Timeout", and not anywhere in the message.
+var statusPattern = regexp.MustCompile(`(?i)\b(?:status(?:[ _]?code)?|http(?:/[0-9.]+)?)["']?\s*[:=]?\s*(?:429|50[0-4])\b|\b50[0-4] (?:internal server error|not implemented|bad gateway|service unavailable|gateway timeout)\b|too many requests|rate limit|overloaded`)
+
+// IsRetryable reports whether err is transient: rate limiting, a server
+// error or a network failure.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode > 0 {
+		return retryableStatus(apiErr.HTTPStatusCode)
+	}
+	var requestErr *openai.RequestError
+	if errors.As(err, &requestErr) && requestErr.HTTPStatusCode > 0 {
+		return retryableStatus(requestErr.HTTPStatusCode)
+	}
+	var anthropicErr *anthropic.Error
+	if errors.As(err, &anthropicErr) && anthropicErr.StatusCode > 0 {
+		return retryableStatus(anthropicErr.StatusCode)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return statusPattern.MatchString(err.Error())
+}
+
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// FailoverClient sends every request to its clients in order until one of
+// them answers. Only transient errors, see IsRetryable, move on to the next
+// client; other errors, such as a rejected prompt or an invalid key, are
+// returned at once.
+type FailoverClient struct {
+	clients []IAI
+}
+
+// NewFailoverClient returns a client that falls back from the first of
+// clients to the next ones. The clients must be configured already.
+func NewFailoverClient(clients ...IAI) *FailoverClient {
+	return &FailoverClient{clients: clients}
+}
+
+func (c *FailoverClient) Configure(config IAIConfig) error {
+	return errors.New("the clients of a failover chain are configured individually")
+}
+
+// GetName returns the name of the primary client.
+func (c *FailoverClient) GetName() string {
+	return c.clients[0].GetName()
+}
+
+func (c *FailoverClient) Close() {
+	for _, client := range c.clients {
+		client.Close()
+	}
+}
+
+func (c *FailoverClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	var errs []error
+	for _, client := range c.clients {
+		completion, err := client.GetCompletion(ctx, prompt)
+		if err == nil {
+			recordProvider(ctx, client.GetName())
+			return completion, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", client.GetName(), err))
+		if ctx.Err() != nil || !IsRetryable(err) {
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+// GetCompletionStream falls back to the next client while a stream cannot
+// be started because of a transient error. Once a client has sent text, its
+// errors are returned.
+func (c *FailoverClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	var errs []error
+	for _, client := range c.clients {
+		chunks, err := GetCompletionStream(ctx, client, prompt)
+		if err == nil {
+			chunks, err = peekStream(ctx, chunks)
+		}
+		if err == nil {
+			recordProvider(ctx, client.GetName())
+			return chunks, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", client.GetName(), err))
+		if ctx.Err() != nil || !IsRetryable(err) {
+			break
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// peekStream waits for the first chunk of a stream and returns the error it
+// carries, if any. Otherwise the returned stream yields every chunk.
+func peekStream(ctx context.Context, chunks <-chan Chunk) (<-chan Chunk, error) {
+	first, ok := <-chunks
+	if ok && first.Err != nil {
+		return nil, first.Err
+	}
+	out := make(chan Chunk)
+	go func() {
+		defer close(out)
+		if !ok || !send(ctx, out, first) {
+			return
+		}
+		for chunk := range chunks {
+			if !send(ctx, out, chunk) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+type providerRecorderKey struct{}
+
+type providerRecorder struct {
+	mutex sync.Mutex
+	name  string
+}
+
+// WithProviderRecorder returns a context in which a FailoverClient notes
+// which provider answered a request, and a function returning that name. The
+// name is empty when the request did not go through a FailoverClient.
+func WithProviderRecorder(ctx context.Context) (context.Context, func() string) {
+	recorder := &providerRecorder{}
+	return context.WithValue(ctx, providerRecorderKey{}, recorder), func() string {
+		recorder.mutex.Lock()
+		defer recorder.mutex.Unlock()
+		return recorder.name
+	}
+}
+
+func recordProvider(ctx context.Context, name string) {
+	if recorder, ok := ctx.Value(providerRecorderKey{}).(*providerRecorder); ok {
+		recorder.mutex.Lock()
+		recorder.name = name
+		recorder.mutex.Unlock()
+	}
+}
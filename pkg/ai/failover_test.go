@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+// flakyAIClient fails the first failures requests with err.
+type flakyAIClient struct {
+	nopCloser
+
+	name     string
+	failures int
+	err      error
+	delay    time.Duration
+
+	mutex    sync.Mutex
+	calls    int
+	inFlight int
+	peak     int
+}
+
+func (c *flakyAIClient) Configure(_ IAIConfig) error { return nil }
+
+func (c *flakyAIClient) GetName() string { return c.name }
+
+func (c *flakyAIClient) GetCompletion(_ context.Context, prompt string) (string, error) {
+	c.mutex.Lock()
+	c.calls++
+	call := c.calls
+	c.inFlight++
+	c.peak = max(c.peak, c.inFlight)
+	c.mutex.Unlock()
+
+	time.Sleep(c.delay)
+
+	c.mutex.Lock()
+	c.inFlight--
+	c.mutex.Unlock()
+	if call <= c.failures {
+		return "", c.err
+	}
+	return c.name + ": " + prompt, nil
+}
+
+func fastRetries(t *testing.T) {
+	previous := retryBackoff
+	retryBackoff = time.Millisecond
+	t.Cleanup(func() { retryBackoff = previous })
+}
+
+func TestRetryingClient(t *testing.T) {
+	fastRetries(t)
+	rateLimited := &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Message: "rate limited"}
+	tests := []struct {
+		name       string
+		failures   int
+		err        error
+		maxRetries int
+		wantCalls  int
+		wantErr    bool
+	}{
+		{name: "succeeds after retries", failures: 2, err: rateLimited, maxRetries: 3, wantCalls: 3},
+		{name: "gives up after max retries", failures: 5, err: rateLimited, maxRetries: 2, wantCalls: 3, wantErr: true},
+		{name: "does not retry permanent errors", failures: 1, err: errors.New("invalid api key"), maxRetries: 3, wantCalls: 1, wantErr: true},
+		{name: "retries disabled", failures: 1, err: rateLimited, wantCalls: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &flakyAIClient{name: "openai", failures: tt.failures, err: tt.err}
+			client := NewRetryingClient(backend, RetryPolicy{MaxRetries: tt.maxRetries})
+
+			completion, err := client.GetCompletion(context.Background(), "prompt")
+			require.Equal(t, tt.wantCalls, backend.calls)
+			if tt.wantErr {
+				require.ErrorIs(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "openai: prompt", completion)
+		})
+	}
+}
+
+func TestRetryingClientConcurrency(t *testing.T) {
+	backend := &flakyAIClient{name: "openai", delay: 10 * time.Millisecond}
+	client := NewRetryingClient(backend, RetryPolicy{MaxConcurrentRequests: 2})
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := client.GetCompletion(context.Background(), "prompt"); err != nil {
+				failed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	require.Zero(t, failed.Load())
+	require.Equal(t, 8, backend.calls)
+	require.LessOrEqual(t, backend.peak, 2)
+}
+
+func TestRetryingClientCanceled(t *testing.T) {
+	previous := retryBackoff
+	retryBackoff = time.Hour
+	t.Cleanup(func() { retryBackoff = previous })
+
+	backend := &flakyAIClient{name: "openai", failures: 1, err: errors.New("503 Service Unavailable")}
+	client := NewRetryingClient(backend, RetryPolicy{MaxRetries: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetCompletion(ctx, "prompt")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, 1, backend.calls)
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "openai rate limit", err: &openai.APIError{HTTPStatusCode: 429}, want: true},
+		{name: "openai server error", err: &openai.APIError{HTTPStatusCode: 502}, want: true},
+		{name: "openai bad request", err: &openai.APIError{HTTPStatusCode: 400, Message: "rate limit"}, want: false},
+		{name: "openai request error", err: &openai.RequestError{HTTPStatusCode: 503}, want: true},
+		{name: "wrapped", err: fmt.Errorf("completion: %w", &openai.APIError{HTTPStatusCode: 429}), want: true},
+		{name: "message with status", err: errors.New("unexpected status 429 from backend"), want: true},
+		{name: "overloaded", err: errors.New("the model is overloaded"), want: true},
+		{name: "status code", err: errors.New("error, status code: 503, message: upstream unavailable"), want: true},
+		{name: "http status", err: errors.New("HTTP/1.1 504 from proxy"), want: true},
+		{name: "json status", err: errors.New(`{"status":429,"error":"slow down"}`), want: true},
+		{name: "status text", err: errors.New("502 Bad Gateway"), want: true},
+		{name: "number in message", err: errors.New("maximum context length is 5000 tokens, you requested 500 more"), want: false},
+		{name: "number in model name", err: errors.New("model llama-429 not found"), want: false},
+		{name: "number in request id", err: errors.New("quota exceeded for request 504-a1b2"), want: false},
+		{name: "canceled", err: context.Canceled, want: false},
+		{name: "other", err: errors.New("invalid model"), want: false},
+		{name: "nil", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, IsRetryable(tt.err))
+		})
+	}
+}
+
+func TestFailoverClient(t *testing.T) {
+	primary := &flakyAIClient{name: "openai", failures: 1, err: errors.New("429 Too Many Requests")}
+	fallback := &flakyAIClient{name: "ollama"}
+	client := NewFailoverClient(primary, fallback)
+	require.Equal(t, "openai", client.GetName())
+
+	ctx, answeredBy := WithProviderRecorder(context.Background())
+	completion, err := client.GetCompletion(ctx, "prompt")
+	require.NoError(t, err)
+	require.Equal(t, "ollama: prompt", completion)
+	require.Equal(t, "ollama", answeredBy())
+
+	// The primary provider answers again once it has recovered.
+	ctx, answeredBy = WithProviderRecorder(context.Background())
+	completion, err = client.GetCompletion(ctx, "prompt")
+	require.NoError(t, err)
+	require.Equal(t, "openai: prompt", completion)
+	require.Equal(t, "openai", answeredBy())
+}
+
+func TestFailoverClientAllFail(t *testing.T) {
+	client := NewFailoverClient(
+		&flakyAIClient{name: "openai", failures: 1, err: errors.New("rate limit exceeded")},
+		&flakyAIClient{name: "ollama", failures: 1, err: errors.New("502 Bad Gateway")},
+	)
+
+	_, err := client.GetCompletion(context.Background(), "prompt")
+	require.EqualError(t, err, "openai: rate limit exceeded\nollama: 502 Bad Gateway")
+}
+
+func TestFailoverClientNonRetryable(t *testing.T) {
+	for name, err := range map[string]error{
+		"bad request":  &openai.APIError{HTTPStatusCode: 400, Message: "invalid prompt"},
+		"unauthorized": &openai.APIError{HTTPStatusCode: 401, Message: "invalid api key"},
+		"untyped":      errors.New("model not found"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			fallback := &flakyAIClient{name: "ollama"}
+			client := NewFailoverClient(&flakyAIClient{name: "openai", failures: 1, err: err}, fallback)
+
+			_, gotErr := client.GetCompletion(context.Background(), "prompt")
+			require.ErrorIs(t, gotErr, err)
+			_, gotErr = NewFailoverClient(&flakyAIClient{name: "openai", failures: 1, err: err}, fallback).GetCompletionStream(context.Background(), "prompt")
+			require.ErrorIs(t, gotErr, err)
+			// The request never reached the fallback provider.
+			require.Zero(t, fallback.calls)
+		})
+	}
+}
+
+func TestFailoverClientStream(t *testing.T) {
+	client := NewFailoverClient(
+		&flakyAIClient{name: "openai", failures: 1, err: errors.New("503 Service Unavailable")},
+		&flakyAIClient{name: "ollama"},
+	)
+
+	ctx, answeredBy := WithProviderRecorder(context.Background())
+	chunks, err := client.GetCompletionStream(ctx, "prompt")
+	require.NoError(t, err)
+	completion, err := ReadStream(chunks, nil)
+	require.NoError(t, err)
+	require.Equal(t, "ollama: prompt", completion)
+	require.Equal(t, "ollama", answeredBy())
+}
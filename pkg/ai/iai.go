@@ -15,6 +15,7 @@ package ai
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 )
 
@@ -115,6 +116,9 @@ func NewClient(provider string) IAI {
 type AIConfiguration struct {
 	Providers       []AIProvider `mapstructure:"providers"`
 	DefaultProvider string       `mapstructure:"defaultprovider"`
+	// Fallbacks are the providers tried in order when the selected one
+	// fails to answer.
+	Fallbacks []string `mapstructure:"fallbacks" yaml:"fallbacks,omitempty"`
 }
 
 type AIProvider struct {
@@ -138,6 +142,17 @@ type AIProvider struct {
 	AzureAPIType    string        `mapstructure:"azureapitype" yaml:"azureapitype,omitempty"`
 	AzureAPIVersion string        `mapstructure:"azureapiversion" yaml:"azureapiversion,omitempty"`
 	CustomHeaders   []http.Header `mapstructure:"customHeaders"`
+	// Retries of transient errors and request limits, see RetryPolicy.
+	MaxRetries            int `mapstructure:"maxretries" yaml:"maxretries,omitempty"`
+	RequestsPerMinute     int `mapstructure:"requestsperminute" yaml:"requestsperminute,omitempty"`
+	MaxConcurrentRequests int `mapstructure:"maxconcurrentrequests" yaml:"maxconcurrentrequests,omitempty"`
+}
+
+// CacheParameters describes the model and the parameters that change the
+// completions of the provider. Explanations are cached per value.
+func (p *AIProvider) CacheParameters() string {
+	return fmt.Sprintf("model=%s,engine=%s,temperature=%g,topp=%g,topk=%d,maxtokens=%d,stop=%q",
+		p.Model, p.Engine, p.Temperature, p.TopP, p.TopK, p.MaxTokens, p.StopSequences)
 }
 
 func (p *AIProvider) GetBaseURL() string {
@@ -221,3 +236,10 @@ func NeedPassword(backend string) bool {
 	}
 	return true
 }
+
+// EstimateTokens approximates the token count of text with the common rule
+// of thumb of four characters per token, as providers do not all report
+// usage.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -22,3 +22,9 @@ func TestAIProviderGetAzureAPIVersion(t *testing.T) {
 		t.Fatalf("expected Azure API version to be returned, got %q", got)
 	}
 }
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens("hello world"); got != 3 {
+		t.Fatalf("expected 3 tokens, got %d", got)
+	}
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interactive
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+)
+
+// commands lists the slash commands for /help.
+var commands = []struct {
+	usage       string
+	description string
+}{
+	{"/reanalyze", "Run the analysis again and replace the context with its results"},
+	{"/filter [Kind,...]", "Run the analysis again with other filters, or the default ones"},
+	{"/logs namespace/pod[/container]", "Add the last log lines of a pod to the conversation"},
+	{"/result N", "Add result N of the analysis to the conversation"},
+	{"/save [name]", "Save the session, by default under the current time"},
+	{"/load name", "Continue a saved session"},
+	{"/clear", "Forget the conversation"},
+	{"/help", "List the commands"},
+	{"/exit", "Leave the session"},
+}
+
+// command runs a slash command and returns what to print.
+func (a *InteractionRunner) command(line string) (string, error) {
+	fields := strings.Fields(line)
+	name, args := fields[0], fields[1:]
+	switch name {
+	case "/reanalyze":
+		return a.reanalyze()
+	case "/filter":
+		var filters []string
+		for _, arg := range args {
+			for _, filter := range strings.Split(arg, ",") {
+				if filter != "" {
+					filters = append(filters, filter)
+				}
+			}
+		}
+		a.config.Filters = filters
+		return a.reanalyze()
+	case "/logs":
+		return a.logs(args)
+	case "/result":
+		return a.result(args)
+	case "/save":
+		name := time.Now().Format("20060102-150405")
+		if len(args) > 0 {
+			name = args[0]
+		}
+		path, err := SessionPath(name)
+		if err != nil {
+			return "", err
+		}
+		if err := a.session.Save(path); err != nil {
+			return "", err
+		}
+		return "Session saved to " + path, nil
+	case "/load":
+		if len(args) == 0 {
+			return "", errors.New("usage: /load name")
+		}
+		path, err := SessionPath(args[0])
+		if err != nil {
+			return "", err
+		}
+		session, err := LoadSession(path)
+		if err != nil {
+			return "", err
+		}
+		// The session may have been saved without --anonymize.
+		if session.Context, err = a.redact(session.Context); err != nil {
+			return "", err
+		}
+		for i := range session.History {
+			if session.History[i].Content, err = a.redact(session.History[i].Content); err != nil {
+				return "", err
+			}
+		}
+		a.session = session
+		a.contextRedacted = a.Anonymize
+		return fmt.Sprintf("Session loaded from %s with %d messages", path, len(session.History)), nil
+	case "/clear":
+		a.session.History = nil
+		return "Conversation cleared", nil
+	case "/help":
+		var b strings.Builder
+		for _, command := range commands {
+			fmt.Fprintf(&b, "%-34s %s\n", command.usage, command.description)
+		}
+		return strings.TrimSuffix(b.String(), "\n"), nil
+	}
+	return "", fmt.Errorf("unknown command %s, type /help for the list of commands", name)
+}
+
+// reanalyze runs the analysis again, with the same custom analyzers,
+// baseline and deduplication as the first one, and makes its results the
+// context.
+func (a *InteractionRunner) reanalyze() (string, error) {
+	a.config.Results, a.config.Errors, a.config.Resolved = nil, nil, nil
+	if err := a.config.Analyze(); err != nil {
+		return "", err
+	}
+	if a.redactor != nil {
+		a.config.AddResultNames(a.redactor)
+	}
+	output, err := a.config.PrintOutput("json")
+	if err != nil {
+		return "", err
+	}
+	context, err := a.redact(string(output))
+	if err != nil {
+		return "", err
+	}
+	a.session.Context = context
+	a.contextRedacted = a.Anonymize
+	a.session.add(ai.RoleUser, "I ran the analysis again, the context holds its current results.")
+	summary := fmt.Sprintf("Analysis found %d problems", len(a.config.Results))
+	if len(a.config.Filters) > 0 {
+		summary += " with filters " + strings.Join(a.config.Filters, ", ")
+	}
+	return summary, nil
+}
+
+func (a *InteractionRunner) logs(args []string) (string, error) {
+	parts := []string{}
+	if len(args) > 0 {
+		parts = strings.SplitN(args[0], "/", 3)
+	}
+	if len(parts) < 2 {
+		return "", errors.New("usage: /logs namespace/pod[/container]")
+	}
+	container := ""
+	if len(parts) == 3 {
+		container = parts[2]
+	}
+	logs := a.config.PodLogs(parts[0], parts[1], container)
+	if logs == "" {
+		return "", fmt.Errorf("no logs found for %s", args[0])
+	}
+	redacted, err := a.redact(logs)
+	if err != nil {
+		return "", err
+	}
+	a.session.add(ai.RoleUser, fmt.Sprintf("Logs of pod %s:\n%s", args[0], redacted))
+	return logs, nil
+}
+
+func (a *InteractionRunner) result(args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errors.New("usage: /result N")
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 || n >= len(a.config.Results) {
+		return "", fmt.Errorf("no result %s, the analysis has %d results numbered from 0", args[0], len(a.config.Results))
+	}
+	output, err := json.MarshalIndent(a.config.Results[n], "", "  ")
+	if err != nil {
+		return "", err
+	}
+	redacted, err := a.redact(string(output))
+	if err != nil {
+		return "", err
+	}
+	a.session.add(ai.RoleUser, fmt.Sprintf("Result %d of the analysis:\n%s", n, redacted))
+	return string(output), nil
+}
@@ -5,7 +5,9 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
 	"github.com/pterm/pterm"
 )
 
@@ -21,22 +23,32 @@ const (
 )
 
 type InteractionRunner struct {
-	config        *analysis.Analysis
-	State         chan INTERACTIVE_STATE
-	contextWindow []byte
+	config  *analysis.Analysis
+	State   chan INTERACTIVE_STATE
+	session *Session
+	// HistoryTokens caps the prompt of a question, see DefaultHistoryTokens.
+	HistoryTokens int
+	// Anonymize redacts the context, questions, results and logs of the
+	// conversation like --anonymize redacts failures, and restores the
+	// pseudonyms in the answers.
+	Anonymize bool
+	redactor  *redact.Redactor
+	// contextRedacted is set once the initial context has been redacted.
+	contextRedacted bool
 }
 
 func NewInteractionRunner(config *analysis.Analysis, contextWindow []byte) *InteractionRunner {
 	return &InteractionRunner{
 		config:        config,
-		contextWindow: contextWindow,
+		session:       &Session{Context: string(contextWindow)},
 		State:         make(chan INTERACTIVE_STATE),
+		HistoryTokens: DefaultHistoryTokens,
 	}
 }
 
 func (a *InteractionRunner) StartInteraction() {
 	a.State <- E_RUNNING
-	pterm.Println("Interactive mode enabled [type exit to close.]")
+	pterm.Println("Interactive mode enabled [type exit to close, /help for commands.]")
 	for {
 
 		query := pterm.DefaultInteractiveTextInput.WithMultiLine(false)
@@ -44,24 +56,85 @@ func (a *InteractionRunner) StartInteraction() {
 		if err != nil {
 			fmt.Println(err)
 		}
+		queryString = strings.TrimSpace(queryString)
 		if queryString == "" {
 			continue
 		}
-		if strings.Contains(queryString, "exit") {
+		if queryString == "exit" || queryString == "/exit" {
 			a.State <- E_EXITED
 			continue
 		}
 		pterm.Println()
-		contextWindow := fmt.Sprintf("%s %s %s", prompt, string(a.contextWindow),
-			queryString)
+		if strings.HasPrefix(queryString, "/") {
+			output, err := a.command(queryString)
+			if err != nil {
+				color.Red("Error: %v", err)
+				continue
+			}
+			pterm.Println(output)
+			continue
+		}
 
-		response, err := a.config.AIClient.GetCompletion(a.config.Context,
-			contextWindow)
+		err = a.ask(queryString, func(text string) {
+			pterm.Print(text)
+		})
 		if err != nil {
 			color.Red("Error: %v", err)
 			a.State <- E_EXITED
 			continue
 		}
-		pterm.Println(response)
+		pterm.Println()
+	}
+}
+
+// redact replaces the sensitive values of text with the pseudonyms of the
+// session when Anonymize is set.
+func (a *InteractionRunner) redact(text string) (string, error) {
+	if !a.Anonymize {
+		return text, nil
+	}
+	if a.redactor == nil {
+		redactor, err := a.config.NewRedactor()
+		if err != nil {
+			return "", err
+		}
+		a.redactor = redactor
+	}
+	return a.redactor.Redact(text), nil
+}
+
+// ask sends question after the history of the session, passes the answer
+// to onText as it is generated and adds both to the history.
+func (a *InteractionRunner) ask(question string, onText func(string)) error {
+	// The context is given unredacted to NewInteractionRunner, before
+	// Anonymize is set.
+	if a.Anonymize && !a.contextRedacted {
+		context, err := a.redact(a.session.Context)
+		if err != nil {
+			return err
+		}
+		a.session.Context = context
+		a.contextRedacted = true
+	}
+	question, err := a.redact(question)
+	if err != nil {
+		return err
+	}
+	prompt := a.session.Prompt(question, a.HistoryTokens)
+	chunks, err := ai.GetCompletionStream(a.config.Context, a.config.AIClient, prompt)
+	if err != nil {
+		return err
+	}
+	if onText != nil && a.redactor != nil {
+		restorer := analysis.NewStreamRestorer(a.redactor, onText)
+		defer restorer.Flush()
+		onText = restorer.Write
+	}
+	answer, err := ai.ReadStream(chunks, onText)
+	if err != nil {
+		return err
 	}
+	a.session.add(ai.RoleUser, question)
+	a.session.add(ai.RoleAssistant, answer)
+	return nil
 }
@@ -0,0 +1,261 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interactive
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/adrg/xdg"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// echoAIClient answers with the prompt it received.
+type echoAIClient struct {
+	ai.NoOpAIClient
+	prompts []string
+}
+
+func (c *echoAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	c.prompts = append(c.prompts, prompt)
+	return "answer " + string(rune('A'+len(c.prompts)-1)), nil
+}
+
+func newTestRunner(client *echoAIClient) *InteractionRunner {
+	config := &analysis.Analysis{
+		Context:        context.Background(),
+		AIClient:       client,
+		MaxConcurrency: 1,
+		Namespace:      "default",
+		Client: &kubernetes.Client{Client: fake.NewSimpleClientset(&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+			Status: v1.PodStatus{
+				Phase:      v1.PodPending,
+				Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Reason: "Unschedulable", Message: "0/1 nodes are available"}},
+			},
+		})},
+		Results: []common.Result{{Kind: "Pod", Name: "default/web", Error: []common.Failure{{Text: "web is pending"}}}},
+	}
+	return NewInteractionRunner(config, []byte("0: Pod default/web()"))
+}
+
+func TestSessionPrompt(t *testing.T) {
+	session := &Session{Context: "0: Pod default/web()"}
+	session.add(ai.RoleUser, "why?")
+	session.add(ai.RoleAssistant, strings.Repeat("a", 400))
+	session.add(ai.RoleUser, "and now?")
+	session.add(ai.RoleAssistant, "it is fixed")
+
+	prompt := session.Prompt("thanks", DefaultHistoryTokens)
+	require.Equal(t, prompt, "Given the following context:  0: Pod default/web()\n\nConversation so far:\nUser: why?\nAssistant: "+
+		strings.Repeat("a", 400)+"\nUser: and now?\nAssistant: it is fixed\n\nUser: thanks")
+
+	// The oldest turns are left out once the prompt is too long.
+	prompt = session.Prompt("thanks", 50)
+	require.NotContains(t, prompt, "why?")
+	require.Contains(t, prompt, "Conversation so far:\nUser: and now?\nAssistant: it is fixed")
+	require.True(t, strings.HasPrefix(prompt, "Given the following context:  0: Pod default/web()"))
+
+	prompt = session.Prompt("thanks", 1)
+	require.NotContains(t, prompt, "Conversation so far")
+}
+
+func TestSessionSaveLoad(t *testing.T) {
+	session := &Session{Context: "context"}
+	session.add(ai.RoleUser, "why?")
+	session.add(ai.RoleAssistant, "because")
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	require.NoError(t, session.Save(path))
+	loaded, err := LoadSession(path)
+	require.NoError(t, err)
+	require.Equal(t, session, loaded)
+
+	resolved, err := SessionPath(path)
+	require.NoError(t, err)
+	require.Equal(t, path, resolved)
+	// Cleanups run last first, so xdg reloads the restored environment.
+	t.Cleanup(xdg.Reload)
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	xdg.Reload()
+	resolved, err = SessionPath("incident")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dataHome, "k8sgpt", "sessions", "incident.json"), resolved)
+}
+
+func TestAskKeepsHistory(t *testing.T) {
+	client := &echoAIClient{}
+	runner := newTestRunner(client)
+
+	var streamed strings.Builder
+	require.NoError(t, runner.ask("why is web pending?", func(text string) { streamed.WriteString(text) }))
+	require.Equal(t, "answer A", streamed.String())
+	require.NoError(t, runner.ask("how do I fix it?", nil))
+
+	require.Len(t, client.prompts, 2)
+	require.Contains(t, client.prompts[1], "User: why is web pending?\nAssistant: answer A\n\nUser: how do I fix it?")
+	require.Len(t, runner.session.History, 4)
+}
+
+func TestLogsAnonymized(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("redaction.key", "test-key")
+
+	runner := newTestRunner(&echoAIClient{})
+	runner.Anonymize = true
+	// The fake clientset answers every log request with "fake logs".
+	runner.config.Results[0].ParentObject = "Deployment/fake"
+
+	output, err := runner.command("/logs default/web")
+	require.NoError(t, err)
+	require.Equal(t, "fake logs", output)
+	message := runner.session.History[len(runner.session.History)-1].Content
+	require.NotContains(t, message, "fake logs")
+	require.Contains(t, message, " logs")
+}
+
+func TestSessionAnonymized(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("redaction.key", "test-key")
+
+	client := &echoAIClient{}
+	runner := newTestRunner(client)
+	runner.Anonymize = true
+	runner.config.Filters = []string{"Pod"}
+
+	output, err := runner.command("/result 0")
+	require.NoError(t, err)
+	require.Contains(t, output, "default/web")
+	require.NoError(t, runner.ask("why is web pending?", nil))
+	_, err = runner.command("/reanalyze")
+	require.NoError(t, err)
+	require.NoError(t, runner.ask("and now?", nil))
+
+	// Neither the session nor the prompts hold the object names.
+	texts := append([]string{runner.session.Context}, client.prompts...)
+	for _, message := range runner.session.History {
+		texts = append(texts, message.Content)
+	}
+	for _, text := range texts {
+		require.NotContains(t, text, "web")
+		require.NotContains(t, text, "default")
+	}
+}
+
+func TestLoadAnonymized(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("redaction.key", "test-key")
+
+	// A session saved without --anonymize.
+	path := filepath.Join(t.TempDir(), "incident.json")
+	saved := &Session{
+		Context: "0: Pod default/web()",
+		History: []ai.ChatMessage{{Role: ai.RoleUser, Content: "why is default/web pending?"}},
+	}
+	require.NoError(t, saved.Save(path))
+
+	client := &echoAIClient{}
+	runner := newTestRunner(client)
+	runner.Anonymize = true
+	_, err := runner.command("/load " + path)
+	require.NoError(t, err)
+	require.NoError(t, runner.ask("and now?", nil))
+
+	require.Len(t, client.prompts, 1)
+	require.NotContains(t, client.prompts[0], "web")
+	require.NotContains(t, client.prompts[0], "default")
+}
+
+func TestReanalyzeAppliesBaseline(t *testing.T) {
+	runner := newTestRunner(&echoAIClient{})
+	runner.config.Filters = []string{"Pod"}
+
+	output, err := runner.command("/reanalyze")
+	require.NoError(t, err)
+	require.Equal(t, "Analysis found 1 problems with filters Pod", output)
+
+	// The findings of the first run are the baseline of the next ones.
+	data, err := runner.config.PrintOutput("json")
+	require.NoError(t, err)
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, os.WriteFile(baseline, data, 0o600))
+	runner.config.Baseline = baseline
+
+	output, err = runner.command("/reanalyze")
+	require.NoError(t, err)
+	require.Equal(t, "Analysis found 0 problems with filters Pod", output)
+	require.Empty(t, runner.config.Resolved)
+}
+
+func TestCommands(t *testing.T) {
+	client := &echoAIClient{}
+	runner := newTestRunner(client)
+
+	output, err := runner.command("/result 0")
+	require.NoError(t, err)
+	require.Contains(t, output, `"name": "default/web"`)
+	_, err = runner.command("/result 3")
+	require.EqualError(t, err, "no result 3, the analysis has 1 results numbered from 0")
+
+	// The fake clientset answers every log request with "fake logs".
+	output, err = runner.command("/logs default/web")
+	require.NoError(t, err)
+	require.Equal(t, "fake logs", output)
+	_, err = runner.command("/logs web")
+	require.EqualError(t, err, "usage: /logs namespace/pod[/container]")
+
+	output, err = runner.command("/filter Pod")
+	require.NoError(t, err)
+	require.Equal(t, "Analysis found 1 problems with filters Pod", output)
+	require.Contains(t, runner.session.Context, `"kind": "Pod"`)
+
+	require.NoError(t, runner.ask("what now?", nil))
+	prompt := client.prompts[0]
+	require.Contains(t, prompt, "Result 0 of the analysis:")
+	require.Contains(t, prompt, "Logs of pod default/web:\nfake logs")
+	require.Contains(t, prompt, "I ran the analysis again")
+
+	output, err = runner.command("/clear")
+	require.NoError(t, err)
+	require.Equal(t, "Conversation cleared", output)
+	require.Empty(t, runner.session.History)
+
+	path := filepath.Join(t.TempDir(), "incident.json")
+	_, err = runner.command("/save " + path)
+	require.NoError(t, err)
+	runner.session = &Session{}
+	output, err = runner.command("/load " + path)
+	require.NoError(t, err)
+	require.Equal(t, "Session loaded from "+path+" with 0 messages", output)
+	require.Contains(t, runner.session.Context, `"kind": "Pod"`)
+
+	_, err = runner.command("/restart")
+	require.EqualError(t, err, "unknown command /restart, type /help for the list of commands")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package interactive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+)
+
+// DefaultHistoryTokens is the approximate token cap of the prompt of a
+// question, made of the context window, the history and the question.
+const DefaultHistoryTokens = 8000
+
+// Session is the context window and the conversation of an interactive
+// session, which /save and /load persist.
+type Session struct {
+	Context string           `json:"context"`
+	History []ai.ChatMessage `json:"history,omitempty"`
+}
+
+// Prompt returns the prompt asking question after the history, leaving out
+// the oldest turns that do not fit in maxTokens. The context window is
+// always sent.
+func (s *Session) Prompt(question string, maxTokens int) string {
+	head := fmt.Sprintf("%s %s", prompt, s.Context)
+	tail := formatTurn(ai.ChatMessage{Role: ai.RoleUser, Content: question})
+	budget := maxTokens - ai.EstimateTokens(head) - ai.EstimateTokens(tail)
+
+	var turns []string
+	for i := len(s.History) - 1; i >= 0; i-- {
+		turn := formatTurn(s.History[i])
+		if budget -= ai.EstimateTokens(turn); budget < 0 {
+			break
+		}
+		turns = append([]string{turn}, turns...)
+	}
+
+	var b strings.Builder
+	b.WriteString(head)
+	if len(turns) > 0 {
+		b.WriteString("\n\nConversation so far:\n")
+		b.WriteString(strings.Join(turns, "\n"))
+	}
+	b.WriteString("\n\n")
+	b.WriteString(tail)
+	return b.String()
+}
+
+// add appends a turn to the history.
+func (s *Session) add(role, content string) {
+	s.History = append(s.History, ai.ChatMessage{Role: role, Content: content})
+}
+
+func formatTurn(turn ai.ChatMessage) string {
+	if turn.Role == ai.RoleAssistant {
+		return "Assistant: " + turn.Content
+	}
+	return "User: " + turn.Content
+}
+
+// Save writes the session to path. Sessions hold cluster data and are only
+// readable by the user.
+func (s *Session) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// LoadSession reads a session written by Save.
+func LoadSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("reading session %s: %w", path, err)
+	}
+	return &session, nil
+}
+
+// SessionPath returns the file of the session called name in the k8sgpt
+// data directory, or name itself when it is a path.
+func SessionPath(name string) (string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) || filepath.Ext(name) == ".json" {
+		return name, nil
+	}
+	return xdg.DataFile(filepath.Join("k8sgpt", "sessions", name+".json"))
+}
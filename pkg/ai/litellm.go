@@ -80,7 +80,22 @@ func (c *LiteLLMClient) Configure(config IAIConfig) error {
 }
 
 func (c *LiteLLMClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	resp, err := c.client.CreateChatCompletion(ctx, c.chatRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", errors.New("no completion choices returned from LiteLLM")
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (c *LiteLLMClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	return streamChatCompletion(ctx, c.client, c.chatRequest(prompt))
+}
+
+func (c *LiteLLMClient) chatRequest(prompt string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
 		Model: c.model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -93,14 +108,7 @@ func (c *LiteLLMClient) GetCompletion(ctx context.Context, prompt string) (strin
 		PresencePenalty:  presencePenalty,
 		FrequencyPenalty: frequencyPenalty,
 		TopP:             c.topP,
-	})
-	if err != nil {
-		return "", err
 	}
-	if len(resp.Choices) == 0 {
-		return "", errors.New("no completion choices returned from LiteLLM")
-	}
-	return resp.Choices[0].Message.Content, nil
 }
 
 func (c *LiteLLMClient) GetName() string {
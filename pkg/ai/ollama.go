@@ -77,15 +77,8 @@ func (c *OllamaClient) Configure(config IAIConfig) error {
 	return nil
 }
 func (c *OllamaClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
-	req := &ollama.GenerateRequest{
-		Model:  c.model,
-		Prompt: prompt,
-		Stream: new(bool),
-		Options: map[string]interface{}{
-			"temperature": c.temperature,
-			"top_p":       c.topP,
-		},
-	}
+	req := c.generateRequest(prompt)
+	req.Stream = new(bool)
 	completion := ""
 	respFunc := func(resp ollama.GenerateResponse) error {
 		completion = resp.Response
@@ -97,6 +90,38 @@ func (c *OllamaClient) GetCompletion(ctx context.Context, prompt string) (string
 	}
 	return completion, nil
 }
+
+func (c *OllamaClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		err := c.client.Generate(ctx, c.generateRequest(prompt), func(resp ollama.GenerateResponse) error {
+			if resp.Response == "" {
+				return nil
+			}
+			if !send(ctx, chunks, Chunk{Text: resp.Response}) {
+				return ctx.Err()
+			}
+			return nil
+		})
+		if err != nil {
+			send(ctx, chunks, Chunk{Err: err})
+		}
+	}()
+	return chunks, nil
+}
+
+func (c *OllamaClient) generateRequest(prompt string) *ollama.GenerateRequest {
+	return &ollama.GenerateRequest{
+		Model:  c.model,
+		Prompt: prompt,
+		Options: map[string]interface{}{
+			"temperature": c.temperature,
+			"top_p":       c.topP,
+		},
+	}
+}
+
 func (a *OllamaClient) GetName() string {
 	return ollamaClientName
 }
@@ -86,7 +86,19 @@ func (c *OpenAIClient) Configure(config IAIConfig) error {
 
 func (c *OpenAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
 	// Create a completion request
-	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+	resp, err := c.client.CreateChatCompletion(ctx, c.chatRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (c *OpenAIClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error) {
+	return streamChatCompletion(ctx, c.client, c.chatRequest(prompt))
+}
+
+func (c *OpenAIClient) chatRequest(prompt string) openai.ChatCompletionRequest {
+	return openai.ChatCompletionRequest{
 		Model: c.model,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -94,16 +106,12 @@ func (c *OpenAIClient) GetCompletion(ctx context.Context, prompt string) (string
 				Content: prompt,
 			},
 		},
-		Temperature:      c.temperature,
+		Temperature:         c.temperature,
 		MaxCompletionTokens: maxToken,
-		PresencePenalty:  presencePenalty,
-		FrequencyPenalty: frequencyPenalty,
-		TopP:             c.topP,
-	})
-	if err != nil {
-		return "", err
+		PresencePenalty:     presencePenalty,
+		FrequencyPenalty:    frequencyPenalty,
+		TopP:                c.topP,
 	}
-	return resp.Choices[0].Message.Content, nil
 }
 
 func (c *OpenAIClient) GetName() string {
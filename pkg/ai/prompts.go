@@ -57,6 +57,17 @@ const (
 	Solution: {kubectl command}
 	`
 	raw_promt = `{"language": "%s","message": "%s","prompt": "%s"}`
+
+	structured_prompt = `Explain the following Kubernetes error message delimited by triple dashes written in --- %s --- language; --- %s ---.
+	Reply with a single JSON object and nothing else. It must match this JSON schema:
+	` + ExplanationSchema + `
+	cause explains the error, confidence is how sure you are of the cause from 0 to 1,
+	steps is the step by step solution, commands are kubectl commands that help to diagnose
+	or fix the error and docLinks are links to the official documentation of the error.
+	`
+
+	structured_retry_prompt = structured_prompt + `Your previous reply was not valid JSON matching the schema. Reply with the JSON object only.
+	`
 )
 
 var PromptMap = map[string]string{
@@ -66,4 +77,6 @@ var PromptMap = map[string]string{
 	"PrometheusConfigRelabelReport": prom_relabel_prompt,
 	"PolicyReport":                  kyverno_prompt,
 	"ClusterPolicyReport":           kyverno_prompt,
+	StructuredPrompt:                structured_prompt,
+	StructuredRetryPrompt:           structured_retry_prompt,
 }
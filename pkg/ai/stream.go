@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Chunk is a piece of a streamed completion. A chunk carrying an error is
+// the last one of its stream.
+type Chunk struct {
+	Text string
+	Err  error
+}
+
+// IStreamingAI is implemented by clients whose backend can stream
+// completions as they are generated.
+type IStreamingAI interface {
+	// GetCompletionStream generates text based on prompt and sends it in
+	// chunks. The channel is closed when the completion is done.
+	GetCompletionStream(ctx context.Context, prompt string) (<-chan Chunk, error)
+}
+
+// GetCompletionStream streams the completion of prompt when the client
+// supports it and otherwise sends the blocking completion as a single chunk.
+func GetCompletionStream(ctx context.Context, client IAI, prompt string) (<-chan Chunk, error) {
+	if streaming, ok := client.(IStreamingAI); ok {
+		return streaming.GetCompletionStream(ctx, prompt)
+	}
+	completion, err := client.GetCompletion(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan Chunk, 1)
+	chunks <- Chunk{Text: completion}
+	close(chunks)
+	return chunks, nil
+}
+
+// ReadStream passes every chunk of a stream to onText and returns the whole
+// completion.
+func ReadStream(chunks <-chan Chunk, onText func(string)) (string, error) {
+	var completion strings.Builder
+	for chunk := range chunks {
+		if chunk.Err != nil {
+			return completion.String(), chunk.Err
+		}
+		completion.WriteString(chunk.Text)
+		if onText != nil {
+			onText(chunk.Text)
+		}
+	}
+	return completion.String(), nil
+}
+
+// send delivers a chunk unless the consumer has gone away.
+func send(ctx context.Context, chunks chan<- Chunk, chunk Chunk) bool {
+	select {
+	case chunks <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// streamChatCompletion streams a chat completion from an OpenAI-compatible
+// API.
+func streamChatCompletion(ctx context.Context, client *openai.Client, request openai.ChatCompletionRequest) (<-chan Chunk, error) {
+	request.Stream = true
+	stream, err := client.CreateChatCompletionStream(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan Chunk)
+	go func() {
+		defer close(chunks)
+		defer stream.Close()
+		for {
+			response, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				send(ctx, chunks, Chunk{Err: err})
+				return
+			}
+			if len(response.Choices) == 0 || response.Choices[0].Delta.Content == "" {
+				continue
+			}
+			if !send(ctx, chunks, Chunk{Text: response.Choices[0].Delta.Content}) {
+				return
+			}
+		}
+	}()
+	return chunks, nil
+}
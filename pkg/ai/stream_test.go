@@ -0,0 +1,144 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// readAll collects a stream and the chunks it was made of.
+func readAll(t *testing.T, client IAI, prompt string) (string, []string) {
+	chunks, err := GetCompletionStream(context.Background(), client, prompt)
+	require.NoError(t, err)
+	var pieces []string
+	completion, err := ReadStream(chunks, func(text string) { pieces = append(pieces, text) })
+	require.NoError(t, err)
+	return completion, pieces
+}
+
+func TestGetCompletionStreamFallback(t *testing.T) {
+	completion, pieces := readAll(t, &NoOpAIClient{}, "prompt")
+	require.Equal(t, "I am a noop response to the prompt prompt", completion)
+	require.Len(t, pieces, 1)
+}
+
+func TestReadStreamError(t *testing.T) {
+	chunks := make(chan Chunk, 2)
+	chunks <- Chunk{Text: "partial"}
+	chunks <- Chunk{Err: errors.New("connection reset")}
+	close(chunks)
+
+	completion, err := ReadStream(chunks, nil)
+	require.EqualError(t, err, "connection reset")
+	require.Equal(t, "partial", completion)
+}
+
+func sseServer(t *testing.T, events []string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			_, _ = fmt.Fprint(w, event)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAICompatibleStreaming(t *testing.T) {
+	delta := func(text string) string {
+		return fmt.Sprintf("data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"content\":%q}}]}\n\n", text)
+	}
+	server := sseServer(t, []string{delta("Error: "), delta("pod crashed. "), delta("Solution: restart it."), "data: [DONE]\n\n"})
+
+	clients := map[string]IAI{
+		"openai":  &OpenAIClient{},
+		"localai": &LocalAIClient{},
+		"litellm": &LiteLLMClient{},
+	}
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, client.Configure(&litellmMockConfig{baseURL: server.URL + "/v1", model: "gpt-4o"}))
+			require.Implements(t, (*IStreamingAI)(nil), client)
+
+			completion, pieces := readAll(t, client, "prompt")
+			require.Equal(t, "Error: pod crashed. Solution: restart it.", completion)
+			require.Equal(t, []string{"Error: ", "pod crashed. ", "Solution: restart it."}, pieces)
+		})
+	}
+}
+
+func TestOllamaStreaming(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, text := range []string{"Error: ", "pod crashed."} {
+			_, _ = fmt.Fprintf(w, "{\"model\":\"llama3\",\"response\":%q,\"done\":false}\n", text)
+		}
+		_, _ = fmt.Fprint(w, "{\"model\":\"llama3\",\"response\":\"\",\"done\":true}\n")
+	}))
+	defer server.Close()
+
+	client := &OllamaClient{}
+	require.NoError(t, client.Configure(&litellmMockConfig{baseURL: server.URL}))
+	completion, pieces := readAll(t, client, "prompt")
+	require.Equal(t, "Error: pod crashed.", completion)
+	require.Equal(t, []string{"Error: ", "pod crashed."}, pieces)
+}
+
+func TestAnthropicStreaming(t *testing.T) {
+	event := func(name, data string) string {
+		return fmt.Sprintf("event: %s\ndata: %s\n\n", name, data)
+	}
+	textDelta := func(text string) string {
+		return event("content_block_delta", fmt.Sprintf("{\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":%q}}", text))
+	}
+	server := sseServer(t, []string{
+		event("message_start", "{\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"type\":\"message\",\"role\":\"assistant\",\"content\":[],\"model\":\"claude\",\"usage\":{\"input_tokens\":1,\"output_tokens\":1}}}"),
+		event("content_block_start", "{\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}"),
+		textDelta("Error: "),
+		textDelta("pod crashed."),
+		event("content_block_stop", "{\"type\":\"content_block_stop\",\"index\":0}"),
+		event("message_stop", "{\"type\":\"message_stop\"}"),
+	})
+
+	client := &AnthropicClient{}
+	require.NoError(t, client.Configure(&litellmMockConfig{baseURL: server.URL, password: "key"}))
+	completion, pieces := readAll(t, client, "prompt")
+	require.Equal(t, "Error: pod crashed.", completion)
+	require.Equal(t, []string{"Error: ", "pod crashed."}, pieces)
+}
+
+func TestStreamingError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"message":"rate limited"}}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &OpenAIClient{}
+	require.NoError(t, client.Configure(&litellmMockConfig{baseURL: server.URL + "/v1"}))
+	chunks, err := client.GetCompletionStream(context.Background(), "prompt")
+	if err == nil {
+		_, err = ReadStream(chunks, nil)
+	}
+	require.Error(t, err)
+	require.True(t, strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "rate limited"))
+}
@@ -0,0 +1,265 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/sashabaranov/go-openai"
+)
+
+// The roles of the messages of a conversation with tools.
+const (
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// ErrToolsUnsupported is returned when a backend cannot call tools.
+var ErrToolsUnsupported = errors.New("the AI backend does not support tool calling")
+
+// Tool is a function the model can call.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parameters is the JSON schema of the arguments.
+	Parameters map[string]any `json:"parameters"`
+}
+
+// ToolCall is a call of a tool requested by the model.
+type ToolCall struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Arguments is a JSON object.
+	Arguments string `json:"arguments"`
+}
+
+// ChatMessage is a message of a conversation with tools. Assistant messages
+// carry the tool calls of the model, and tool messages the result of the
+// call with ToolCallID.
+type ChatMessage struct {
+	Role       string     `json:"role"`
+	Content    string     `json:"content,omitempty"`
+	ToolCalls  []ToolCall `json:"toolCalls,omitempty"`
+	ToolCallID string     `json:"toolCallId,omitempty"`
+}
+
+// IToolCallingAI is implemented by clients whose backend can call tools.
+type IToolCallingAI interface {
+	// GetToolCompletion returns the next assistant message of a
+	// conversation, which either calls some of tools or answers.
+	GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error)
+}
+
+// SupportsTools reports whether requests sent through client can call
+// tools.
+func SupportsTools(client IAI) bool {
+	switch c := client.(type) {
+	case *RetryingClient:
+		return SupportsTools(c.IAI)
+	case *FailoverClient:
+		for _, client := range c.clients {
+			if SupportsTools(client) {
+				return true
+			}
+		}
+		return false
+	}
+	_, ok := client.(IToolCallingAI)
+	return ok
+}
+
+// openAIToolCompletion sends a conversation with tools to an OpenAI
+// compatible API, using request for the model and its parameters.
+func openAIToolCompletion(ctx context.Context, client *openai.Client, request openai.ChatCompletionRequest, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	request.Messages = nil
+	for _, message := range messages {
+		chatMessage := openai.ChatCompletionMessage{
+			Role:       message.Role,
+			Content:    message.Content,
+			ToolCallID: message.ToolCallID,
+		}
+		for _, call := range message.ToolCalls {
+			chatMessage.ToolCalls = append(chatMessage.ToolCalls, openai.ToolCall{
+				ID:       call.ID,
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: call.Name, Arguments: call.Arguments},
+			})
+		}
+		request.Messages = append(request.Messages, chatMessage)
+	}
+	for _, tool := range tools {
+		request.Tools = append(request.Tools, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	resp, err := client.CreateChatCompletion(ctx, request)
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	if len(resp.Choices) == 0 {
+		return ChatMessage{}, errors.New("no completion choices returned")
+	}
+	reply := ChatMessage{Role: RoleAssistant, Content: resp.Choices[0].Message.Content}
+	for _, call := range resp.Choices[0].Message.ToolCalls {
+		reply.ToolCalls = append(reply.ToolCalls, ToolCall{
+			ID:        call.ID,
+			Name:      call.Function.Name,
+			Arguments: call.Function.Arguments,
+		})
+	}
+	return reply, nil
+}
+
+func (c *OpenAIClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	return openAIToolCompletion(ctx, c.client, c.chatRequest(""), messages, tools)
+}
+
+func (c *AzureAIClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	return openAIToolCompletion(ctx, c.client, c.chatRequest(""), messages, tools)
+}
+
+func (c *LiteLLMClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	return openAIToolCompletion(ctx, c.client, c.chatRequest(""), messages, tools)
+}
+
+// GetToolCompletion sends the conversation as Anthropic messages, in which
+// tool results are content blocks of user messages.
+func (c *AnthropicClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	params := c.messageParams("")
+	params.Messages = nil
+	for _, message := range messages {
+		switch message.Role {
+		case RoleAssistant:
+			var blocks []anthropic.ContentBlockParamUnion
+			if message.Content != "" {
+				blocks = append(blocks, anthropic.NewTextBlock(message.Content))
+			}
+			for _, call := range message.ToolCalls {
+				blocks = append(blocks, anthropic.NewToolUseBlock(call.ID, json.RawMessage(call.Arguments), call.Name))
+			}
+			params.Messages = append(params.Messages, anthropic.NewAssistantMessage(blocks...))
+		default:
+			block := anthropic.NewTextBlock(message.Content)
+			if message.Role == RoleTool {
+				block = anthropic.NewToolResultBlock(message.ToolCallID, message.Content, false)
+			}
+			// Tool results and the text following them go into a single
+			// user message.
+			if last := len(params.Messages) - 1; last >= 0 && params.Messages[last].Role == anthropic.MessageParamRoleUser {
+				params.Messages[last].Content = append(params.Messages[last].Content, block)
+				continue
+			}
+			params.Messages = append(params.Messages, anthropic.NewUserMessage(block))
+		}
+	}
+	for _, tool := range tools {
+		schema := anthropic.ToolInputSchemaParam{
+			Properties: tool.Parameters["properties"],
+			Required:   requiredParameters(tool.Parameters),
+		}
+		param := anthropic.ToolUnionParamOfTool(schema, tool.Name)
+		param.OfTool.Description = anthropic.String(tool.Description)
+		params.Tools = append(params.Tools, param)
+	}
+
+	response, err := c.client.Messages.New(ctx, params)
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	reply := ChatMessage{Role: RoleAssistant}
+	var text []string
+	for _, content := range response.Content {
+		switch content.Type {
+		case "text":
+			if block := content.AsText(); block.Text != "" {
+				text = append(text, block.Text)
+			}
+		case "tool_use":
+			block := content.AsToolUse()
+			reply.ToolCalls = append(reply.ToolCalls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		}
+	}
+	reply.Content = strings.Join(text, "\n")
+	return reply, nil
+}
+
+// GetToolCompletion applies the retry policy to a conversation with tools.
+func (c *RetryingClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	client, ok := c.IAI.(IToolCallingAI)
+	if !ok {
+		return ChatMessage{}, ErrToolsUnsupported
+	}
+	var reply ChatMessage
+	err := c.do(ctx, func() error {
+		var err error
+		reply, err = client.GetToolCompletion(ctx, messages, tools)
+		return err
+	})
+	return reply, err
+}
+
+// GetToolCompletion falls back to the next client that supports tools on
+// transient errors.
+func (c *FailoverClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	var errs []error
+	for _, client := range c.clients {
+		toolClient, ok := client.(IToolCallingAI)
+		if !ok || !SupportsTools(client) {
+			continue
+		}
+		reply, err := toolClient.GetToolCompletion(ctx, messages, tools)
+		if err == nil {
+			recordProvider(ctx, client.GetName())
+			return reply, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", client.GetName(), err))
+		if ctx.Err() != nil || !IsRetryable(err) {
+			break
+		}
+	}
+	if len(errs) == 0 {
+		return ChatMessage{}, ErrToolsUnsupported
+	}
+	return ChatMessage{}, errors.Join(errs...)
+}
+
+// requiredParameters returns the required properties of a JSON schema,
+// which are []any once the schema went through encoding/json.
+func requiredParameters(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		names := make([]string, 0, len(required))
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				names = append(names, name)
+			}
+		}
+		return names
+	}
+	return nil
+}
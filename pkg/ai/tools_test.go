@@ -0,0 +1,184 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testTools = []Tool{{
+		Name:        "get-logs",
+		Description: "Get logs from a pod container",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"podName": map[string]any{"type": "string"}},
+			"required":   []any{"podName"},
+		},
+	}}
+	testConversation = []ChatMessage{
+		{Role: RoleUser, Content: "Why does web crash?"},
+		{Role: RoleAssistant, ToolCalls: []ToolCall{{ID: "call-1", Name: "get-logs", Arguments: `{"podName":"web"}`}}},
+		{Role: RoleTool, ToolCallID: "call-1", Content: "panic: missing DATABASE_URL"},
+		{Role: RoleUser, Content: "Answer now."},
+	}
+)
+
+func TestOpenAIToolCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Role       string `json:"role"`
+				Content    string `json:"content"`
+				ToolCallID string `json:"tool_call_id"`
+				ToolCalls  []struct {
+					ID       string `json:"id"`
+					Function struct {
+						Name      string `json:"name"`
+						Arguments string `json:"arguments"`
+					} `json:"function"`
+				} `json:"tool_calls"`
+			} `json:"messages"`
+			Tools []struct {
+				Type     string `json:"type"`
+				Function struct {
+					Name       string         `json:"name"`
+					Parameters map[string]any `json:"parameters"`
+				} `json:"function"`
+			} `json:"tools"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body.Messages, 4)
+		require.Equal(t, "get-logs", body.Messages[1].ToolCalls[0].Function.Name)
+		require.Equal(t, "tool", body.Messages[2].Role)
+		require.Equal(t, "call-1", body.Messages[2].ToolCallID)
+		require.Len(t, body.Tools, 1)
+		require.Equal(t, "function", body.Tools[0].Type)
+		require.Equal(t, []any{"podName"}, body.Tools[0].Function.Parameters["required"])
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"choices": []map[string]any{{"message": map[string]any{
+				"role": "assistant",
+				"tool_calls": []map[string]any{{
+					"id":       "call-2",
+					"type":     "function",
+					"function": map[string]string{"name": "get-logs", "arguments": `{"podName":"web","previous":true}`},
+				}},
+			}}},
+		})
+	}))
+	defer server.Close()
+
+	c := &LiteLLMClient{}
+	require.NoError(t, c.Configure(&litellmMockConfig{baseURL: server.URL, model: "gpt-4o"}))
+	reply, err := c.GetToolCompletion(context.Background(), testConversation, testTools)
+	require.NoError(t, err)
+	require.Equal(t, ChatMessage{
+		Role:      RoleAssistant,
+		ToolCalls: []ToolCall{{ID: "call-2", Name: "get-logs", Arguments: `{"podName":"web","previous":true}`}},
+	}, reply)
+}
+
+func TestAnthropicToolCompletion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content []struct {
+					Type      string          `json:"type"`
+					Text      string          `json:"text"`
+					ID        string          `json:"id"`
+					Input     json.RawMessage `json:"input"`
+					ToolUseID string          `json:"tool_use_id"`
+				} `json:"content"`
+			} `json:"messages"`
+			Tools []struct {
+				Name        string `json:"name"`
+				InputSchema struct {
+					Required []string `json:"required"`
+				} `json:"input_schema"`
+			} `json:"tools"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		// The tool result and the following text share a user message.
+		require.Len(t, body.Messages, 3)
+		require.Equal(t, "tool_use", body.Messages[1].Content[0].Type)
+		require.JSONEq(t, `{"podName":"web"}`, string(body.Messages[1].Content[0].Input))
+		require.Equal(t, "user", body.Messages[2].Role)
+		require.Len(t, body.Messages[2].Content, 2)
+		require.Equal(t, "tool_result", body.Messages[2].Content[0].Type)
+		require.Equal(t, "call-1", body.Messages[2].Content[0].ToolUseID)
+		require.Equal(t, "Answer now.", body.Messages[2].Content[1].Text)
+		require.Len(t, body.Tools, 1)
+		require.Equal(t, []string{"podName"}, body.Tools[0].InputSchema.Required)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"content":[{"type":"text","text":"DATABASE_URL is not set."}]}`))
+	}))
+	defer server.Close()
+
+	c := &AnthropicClient{}
+	require.NoError(t, c.Configure(&anthropicMockConfig{baseURL: server.URL, password: "test-token"}))
+	reply, err := c.GetToolCompletion(context.Background(), testConversation, testTools)
+	require.NoError(t, err)
+	require.Equal(t, ChatMessage{Role: RoleAssistant, Content: "DATABASE_URL is not set."}, reply)
+}
+
+func TestSupportsTools(t *testing.T) {
+	require.True(t, SupportsTools(&OpenAIClient{}))
+	require.False(t, SupportsTools(&NoOpAIClient{}))
+	require.False(t, SupportsTools(NewRetryingClient(&NoOpAIClient{}, RetryPolicy{MaxRetries: 1})))
+	require.True(t, SupportsTools(NewRetryingClient(&AnthropicClient{}, RetryPolicy{MaxRetries: 1})))
+	require.True(t, SupportsTools(NewFailoverClient(&NoOpAIClient{}, &LiteLLMClient{})))
+	require.False(t, SupportsTools(NewFailoverClient(&NoOpAIClient{}, &CohereClient{})))
+}
+
+// toolAIClient answers conversations with tools after failing the first
+// failures of them with err.
+type toolAIClient struct {
+	flakyAIClient
+}
+
+func (c *toolAIClient) GetToolCompletion(ctx context.Context, messages []ChatMessage, tools []Tool) (ChatMessage, error) {
+	content, err := c.GetCompletion(ctx, messages[0].Content)
+	if err != nil {
+		return ChatMessage{}, err
+	}
+	return ChatMessage{Role: RoleAssistant, Content: content}, nil
+}
+
+func TestFailoverClientToolCompletion(t *testing.T) {
+	rateLimited := &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests, Message: "rate limited"}
+	fallback := &toolAIClient{flakyAIClient{name: "anthropic"}}
+	client := NewFailoverClient(&toolAIClient{flakyAIClient{name: "openai", failures: 1, err: rateLimited}}, fallback)
+	reply, err := client.GetToolCompletion(context.Background(), testConversation, testTools)
+	require.NoError(t, err)
+	require.Equal(t, "anthropic: Why does web crash?", reply.Content)
+
+	// A rejected request is returned without trying the next provider.
+	rejected := &openai.APIError{HTTPStatusCode: http.StatusUnauthorized, Message: "invalid api key"}
+	fallback = &toolAIClient{flakyAIClient{name: "anthropic"}}
+	client = NewFailoverClient(&toolAIClient{flakyAIClient{name: "openai", failures: 1, err: rejected}}, fallback)
+	_, err = client.GetToolCompletion(context.Background(), testConversation, testTools)
+	require.ErrorIs(t, err, rejected)
+	require.Zero(t, fallback.calls)
+}
@@ -15,23 +15,30 @@ package analysis
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fatih/color"
 	openapi_v2 "github.com/google/gnostic/openapiv2"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/agent"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/custom"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/prompts"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	"github.com/schollz/progressbar/v3"
 	"github.com/spf13/viper"
@@ -55,6 +62,25 @@ type Analysis struct {
 	WithDoc            bool
 	WithStats          bool
 	Stats              []common.AnalysisStats
+	MinSeverity        common.Severity   // Failures below this severity are dropped; empty keeps everything
+	Resolved           []common.Result   // Baseline findings no longer reported, see ApplyBaseline
+	IgnoreRules        []ignore.Rule     // Failures matching these rules are dropped, see k8sgpt ignore
+	AIBudget           int               // Approximate token limit for explanations; 0 is unlimited
+	GroupExplanations  bool              // Explain results with similar failures with a single prompt
+	Stream             io.Writer         // With text output, results are written here as they are explained
+	Structured         bool              // Ask for explanations as JSON and parse them into Result.Explanation
+	Prompts            *prompts.Registry // User prompt templates by result kind, see k8sgpt prompts
+	ExplainContext     []string          // Context attached to prompts, see ContextSections
+	ContextTokens      int               // Approximate token cap of each context section; 0 uses DefaultContextTokens
+	Agent              *agent.Agent      // Investigates each result with read-only cluster tools, see --agent
+	TargetVersion      string            // Kubernetes version the Deprecation analyzer checks upgrade readiness for
+	CustomAnalysis     bool              // Also run the custom analyzers, see Analyze
+	Baseline           string            // Path of an analysis whose findings are not reported, see ApplyBaseline
+	Dedupe             bool              // Collapse results of the same parent, see DeduplicateResults
+	aiBudget           *tokenBudget
+	rules              []*analyzer.RuleAnalyzer // Declarative rules loaded by NewAnalysis
+	cacheParameters    string                   // Model and parameters of the provider, part of the cache keys
+	streamed           bool
 }
 
 type (
@@ -73,8 +99,11 @@ type JsonOutput struct {
 	Status   AnalysisStatus  `json:"status"`
 	Problems int             `json:"problems"`
 	Results  []common.Result `json:"results"`
+	Resolved []common.Result `json:"resolved,omitempty"`
 }
 
+// NewAnalysis returns an analysis of the cluster of the configured context,
+// or of the manifests in snapshot, a directory or tarball, when it is set.
 func NewAnalysis(
 	backend string,
 	language string,
@@ -88,12 +117,20 @@ func NewAnalysis(
 	interactiveMode bool,
 	httpHeaders []string,
 	withStats bool,
+	snapshot string,
 ) (*Analysis, error) {
 	// Get kubernetes client from viper.
 	kubecontext := viper.GetString("kubecontext")
 	kubeconfig := viper.GetString("kubeconfig")
 	verbose := viper.GetBool("verbose")
-	client, err := kubernetes.NewClient(kubecontext, kubeconfig)
+	var client *kubernetes.Client
+	var err error
+	if snapshot != "" {
+		// Serve the analyzers from a snapshot instead of a live cluster.
+		client, err = kubernetes.NewSnapshotClient(snapshot)
+	} else {
+		client, err = kubernetes.NewClient(kubecontext, kubeconfig)
+	}
 	if verbose {
 		fmt.Println("Debug: Checking kubernetes client initialization.")
 	}
@@ -123,6 +160,22 @@ func NewAnalysis(
 		}
 	}
 
+	var ignoreRules []ignore.Rule
+	if err := viper.UnmarshalKey(ignore.ConfigKey, &ignoreRules); err != nil {
+		return nil, fmt.Errorf("loading ignore rules: %w", err)
+	}
+
+	promptTemplates, err := prompts.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Invalid rules are reported here rather than when the analyzers run.
+	rules, err := analyzer.LoadRuleAnalyzers()
+	if err != nil {
+		return nil, err
+	}
+
 	a := &Analysis{
 		Context:        context.Background(),
 		Filters:        filters,
@@ -135,6 +188,9 @@ func NewAnalysis(
 		MaxConcurrency: maxConcurrency,
 		WithDoc:        withDoc,
 		WithStats:      withStats,
+		IgnoreRules:    ignoreRules,
+		Prompts:        promptTemplates,
+		rules:          rules,
 	}
 	if verbose {
 		fmt.Print("Debug: Analysis configuration loaded, ")
@@ -185,15 +241,8 @@ func NewAnalysis(
 		}
 	}
 
-	var aiProvider ai.AIProvider
-	for _, provider := range configAI.Providers {
-		if backend == provider.Name {
-			aiProvider = provider
-			break
-		}
-	}
-
-	if aiProvider.Name == "" {
+	aiProvider, ok := findAIProvider(configAI.Providers, backend)
+	if !ok {
 		return nil, fmt.Errorf("AI provider %s not specified in configuration. Please run k8sgpt auth", backend)
 	}
 
@@ -202,6 +251,60 @@ func NewAnalysis(
 		fmt.Printf("baseUrl=%s, model=%s.\n", aiProvider.BaseURL, aiProvider.Model)
 	}
 
+	if verbose {
+		fmt.Println("Debug: Checking AI client initialization.")
+	}
+	aiClient, err := newAIClient(aiProvider, httpHeaders)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fall back to the other configured providers, in order, when the
+	// selected one fails.
+	if len(configAI.Fallbacks) > 0 {
+		chain := []ai.IAI{aiClient}
+		for _, name := range configAI.Fallbacks {
+			if name == aiProvider.Name {
+				continue
+			}
+			fallback, ok := findAIProvider(configAI.Providers, name)
+			if !ok {
+				return nil, fmt.Errorf("fallback AI provider %s not specified in configuration. Please run k8sgpt auth", name)
+			}
+			fallbackClient, err := newAIClient(fallback, httpHeaders)
+			if err != nil {
+				return nil, fmt.Errorf("configuring fallback AI provider %s: %w", name, err)
+			}
+			chain = append(chain, fallbackClient)
+		}
+		if len(chain) > 1 {
+			aiClient = ai.NewFailoverClient(chain...)
+			if verbose {
+				fmt.Printf("Debug: Falling back to AI providers %v.\n", configAI.Fallbacks)
+			}
+		}
+	}
+	if verbose {
+		fmt.Println("Debug: AI client initialized.")
+	}
+	a.AIClient = aiClient
+	a.AnalysisAIProvider = aiProvider.Name
+	a.cacheParameters = aiProvider.CacheParameters()
+	return a, nil
+}
+
+func findAIProvider(providers []ai.AIProvider, name string) (ai.AIProvider, bool) {
+	for _, provider := range providers {
+		if provider.Name == name {
+			return provider, true
+		}
+	}
+	return ai.AIProvider{}, false
+}
+
+// newAIClient configures the client of an AI provider, applying its retry
+// policy.
+func newAIClient(aiProvider ai.AIProvider, httpHeaders []string) (ai.IAI, error) {
 	aiClient := ai.NewClient(aiProvider.Name)
 
 	var headerStrings []string
@@ -222,18 +325,13 @@ func NewAnalysis(
 	customHeaders := util.NewHeaders(headerStrings)
 
 	aiProvider.CustomHeaders = customHeaders
-	if verbose {
-		fmt.Println("Debug: Checking AI client initialization.")
-	}
 	if err := aiClient.Configure(&aiProvider); err != nil {
 		return nil, err
 	}
-	if verbose {
-		fmt.Println("Debug: AI client initialized.")
+	if policy := aiProvider.RetryPolicy(); !policy.IsZero() {
+		return ai.NewRetryingClient(aiClient, policy), nil
 	}
-	a.AIClient = aiClient
-	a.AnalysisAIProvider = aiProvider.Name
-	return a, nil
+	return aiClient, nil
 }
 
 func (a *Analysis) CustomAnalyzersAreAvailable() bool {
@@ -244,6 +342,36 @@ func (a *Analysis) CustomAnalyzersAreAvailable() bool {
 	return len(customAnalyzers) > 0
 }
 
+// Analyze runs the analyzers and post-processes their results like k8sgpt
+// analyze does before explaining them: the custom analyzers run first when
+// enabled, then the built-in ones, which drop ignored failures and those
+// below MinSeverity, and finally the baseline is applied and duplicates are
+// collapsed.
+func (a *Analysis) Analyze() error {
+	verbose := viper.GetBool("verbose")
+	if a.CustomAnalysis {
+		a.RunCustomAnalysis()
+		if verbose {
+			fmt.Println("Debug: All custom analyzers completed.")
+		}
+	}
+	a.RunAnalysis()
+	if verbose {
+		fmt.Println("Debug: All core analyzers completed.")
+	}
+
+	if a.Baseline != "" {
+		// Only report and explain findings that are not part of the baseline.
+		if err := a.ApplyBaseline(a.Baseline); err != nil {
+			return err
+		}
+	}
+	if a.Dedupe {
+		a.DeduplicateResults()
+	}
+	return nil
+}
+
 func (a *Analysis) RunCustomAnalysis() {
 	// Validate namespace if specified, consistent with built-in filter behavior
 	if a.Namespace != "" && a.Client != nil {
@@ -260,16 +388,7 @@ func (a *Analysis) RunCustomAnalysis() {
 		return
 	}
 
-	// Set a reasonable maximum for concurrency to prevent excessive memory allocation
-	const maxAllowedConcurrency = 100
-	concurrency := a.MaxConcurrency
-	if concurrency <= 0 {
-		concurrency = 10 // Default value if not set
-	} else if concurrency > maxAllowedConcurrency {
-		concurrency = maxAllowedConcurrency // Cap at a reasonable maximum
-	}
-
-	semaphore := make(chan struct{}, concurrency)
+	semaphore := make(chan struct{}, a.concurrency())
 	var wg sync.WaitGroup
 	var mutex sync.Mutex
 	verbose := viper.GetBool("verbose")
@@ -337,7 +456,7 @@ func (a *Analysis) RunCustomAnalysis() {
 				}
 			} else {
 				mutex.Lock()
-				a.Results = append(a.Results, result)
+				a.Results = append(a.Results, withDefaultSeverity([]common.Result{result}, common.SeverityMedium)...)
 				mutex.Unlock()
 				if verbose {
 					fmt.Printf("Debug: %s completed without errors.\n", cAnalyzer.Name)
@@ -349,12 +468,28 @@ func (a *Analysis) RunCustomAnalysis() {
 	wg.Wait()
 }
 
+// concurrency returns the number of analyzers or AI requests run at once.
+func (a *Analysis) concurrency() int {
+	// Set a reasonable maximum for concurrency to prevent excessive memory allocation
+	const maxAllowedConcurrency = 100
+	concurrency := a.MaxConcurrency
+	if concurrency <= 0 {
+		concurrency = 10 // Default value if not set
+	} else if concurrency > maxAllowedConcurrency {
+		concurrency = maxAllowedConcurrency // Cap at a reasonable maximum
+	}
+	return concurrency
+}
+
 func (a *Analysis) RunAnalysis() {
+	defer func() {
+		a.applyIgnoreRules()
+		a.applySeverity()
+	}()
+
 	activeFilters := viper.GetStringSlice("active_filters")
 	verbose := viper.GetBool("verbose")
 
-	coreAnalyzerMap, analyzerMap := analyzer.GetAnalyzerMap()
-
 	// we get the openapi schema from the server only if required by the flag "with-doc"
 	openapiSchema := &openapi_v2.Document{}
 	if a.WithDoc {
@@ -379,64 +514,76 @@ func (a *Analysis) RunAnalysis() {
 		LabelSelector: a.LabelSelector,
 		AIClient:      a.AIClient,
 		OpenapiSchema: openapiSchema,
+		Lister:        common.NewLister(a.Client),
+		Owners:        util.NewOwnerResolver(a.Client),
+		TargetVersion: a.TargetVersion,
 	}
 
-	// Set a reasonable maximum for concurrency to prevent excessive memory allocation
-	const maxAllowedConcurrency = 100
-	concurrency := a.MaxConcurrency
-	if concurrency <= 0 {
-		concurrency = 10 // Default value if not set
-	} else if concurrency > maxAllowedConcurrency {
-		concurrency = maxAllowedConcurrency // Cap at a reasonable maximum
-	}
-
-	semaphore := make(chan struct{}, concurrency)
+	semaphore := make(chan struct{}, a.concurrency())
 	var wg sync.WaitGroup
 	var mutex sync.Mutex
-	// if there are no filters selected and no active_filters then run coreAnalyzer
-	if len(a.Filters) == 0 && len(activeFilters) == 0 {
-		if verbose {
+	if verbose {
+		switch {
+		case len(a.Filters) == 0 && len(activeFilters) == 0:
 			fmt.Println("Debug: No filters selected and no active filters found, run all core analyzers.")
+		case len(a.Filters) != 0:
+			fmt.Printf("Debug: Filter flags %v specified, run selected core analyzers.\n", a.Filters)
+		default:
+			fmt.Printf("Debug: Found active filters %v, run selected core analyzers.\n", activeFilters)
 		}
-		for name, analyzer := range coreAnalyzerMap {
-			wg.Add(1)
-			semaphore <- struct{}{}
-			go a.executeAnalyzer(analyzer, name, analyzerConfig, semaphore, &wg, &mutex)
-
-		}
-		wg.Wait()
+	}
+	analyzers, err := a.selectAnalyzers(activeFilters)
+	if err != nil {
+		a.Errors = append(a.Errors, err.Error())
 		return
 	}
+	for name, analyzer := range analyzers {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go a.executeAnalyzer(analyzer, name, analyzerConfig, semaphore, &wg, &mutex)
+	}
+	wg.Wait()
+}
+
+// selectAnalyzers returns the analyzers to run: the ones named by the
+// filters flag, else the active filters, else all core analyzers.
+func (a *Analysis) selectAnalyzers(activeFilters []string) (map[string]common.IAnalyzer, error) {
+	var coreAnalyzerMap, analyzerMap map[string]common.IAnalyzer
+	var err error
+	if a.rules != nil {
+		coreAnalyzerMap, analyzerMap, err = analyzer.GetAnalyzerMapWithRules(a.rules)
+	} else {
+		coreAnalyzerMap, analyzerMap, err = analyzer.GetAnalyzerMap()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// if there are no filters selected and no active_filters then run coreAnalyzer
+	if len(a.Filters) == 0 && len(activeFilters) == 0 {
+		return coreAnalyzerMap, nil
+	}
+
+	selected := make(map[string]common.IAnalyzer)
 	// if the filters flag is specified
 	if len(a.Filters) != 0 {
-		if verbose {
-			fmt.Printf("Debug: Filter flags %v specified, run selected core analyzers.\n", a.Filters)
-		}
 		for _, filter := range a.Filters {
 			if analyzer, ok := analyzerMap[filter]; ok {
-				semaphore <- struct{}{}
-				wg.Add(1)
-				go a.executeAnalyzer(analyzer, filter, analyzerConfig, semaphore, &wg, &mutex)
+				selected[filter] = analyzer
 			} else {
 				a.Errors = append(a.Errors, fmt.Sprintf("\"%s\" filter does not exist. Please run k8sgpt filters list.", filter))
 			}
 		}
-		wg.Wait()
-		return
+		return selected, nil
 	}
 
 	// use active_filters
-	if len(activeFilters) > 0 && verbose {
-		fmt.Printf("Debug: Found active filters %v, run selected core analyzers.\n", activeFilters)
-	}
 	for _, filter := range activeFilters {
 		if analyzer, ok := analyzerMap[filter]; ok {
-			semaphore <- struct{}{}
-			wg.Add(1)
-			go a.executeAnalyzer(analyzer, filter, analyzerConfig, semaphore, &wg, &mutex)
+			selected[filter] = analyzer
 		}
 	}
-	wg.Wait()
+	return selected, nil
 }
 
 func (a *Analysis) executeAnalyzer(analyzer common.IAnalyzer, filter string, analyzerConfig common.Analyzer, semaphore chan struct{}, wg *sync.WaitGroup, mutex *sync.Mutex) {
@@ -455,10 +602,13 @@ func (a *Analysis) executeAnalyzer(analyzer common.IAnalyzer, filter string, ana
 	if verbose {
 		fmt.Printf("Debug: %s launched.\n", reflect.TypeOf(analyzer).Name())
 	}
+	// An analyzer may return the results it could collect along with an
+	// error, both are kept.
 	results, err := analyzer.Analyze(analyzerConfig)
 	if err != nil {
 		fmt.Println(err)
 	}
+	results = a.dropAnnotatedResults(analyzerConfig.GetLister(), filter, results)
 	// Measure the time taken
 	if a.WithStats {
 		elapsedTime = time.Since(startTime)
@@ -476,6 +626,7 @@ func (a *Analysis) executeAnalyzer(analyzer common.IAnalyzer, filter string, ana
 			a.Stats = append(a.Stats, stat)
 		}
 		a.Errors = append(a.Errors, fmt.Sprintf("[%s] %s", filter, err))
+		a.Results = append(a.Results, withDefaultSeverity(results, analyzerSeverity(filter))...)
 		if verbose {
 			fmt.Printf("Debug: %s completed with errors.\n", reflect.TypeOf(analyzer).Name())
 		}
@@ -483,7 +634,7 @@ func (a *Analysis) executeAnalyzer(analyzer common.IAnalyzer, filter string, ana
 		if a.WithStats {
 			a.Stats = append(a.Stats, stat)
 		}
-		a.Results = append(a.Results, results...)
+		a.Results = append(a.Results, withDefaultSeverity(results, analyzerSeverity(filter))...)
 		if verbose {
 			fmt.Printf("Debug: %s completed without errors.\n", reflect.TypeOf(analyzer).Name())
 		}
@@ -501,88 +652,339 @@ func (a *Analysis) GetAIResults(output string, anonymize bool) error {
 		fmt.Println("Debug: Generating AI analysis.")
 	}
 
+	a.aiBudget = newTokenBudget(a.AIBudget)
+	defer func() { a.aiBudget = nil }()
+
+	var redactor *redact.Redactor
+	if anonymize {
+		var err error
+		if redactor, err = a.NewRedactor(); err != nil {
+			return err
+		}
+	}
+
+	var unexplained int
+	var err error
+	if a.Stream != nil && output == "text" {
+		unexplained, err = a.streamAIResults(redactor)
+	} else {
+		unexplained, err = a.collectAIResults(output, redactor)
+	}
+	if err != nil {
+		// Check for exhaustion.
+		if strings.Contains(err.Error(), "status code: 429") {
+			return fmt.Errorf("exhausted API quota for AI provider %s: %v", a.AIClient.GetName(), err)
+		}
+		return fmt.Errorf("failed while calling AI provider %s: %v", a.AIClient.GetName(), err)
+	}
+	if unexplained > 0 {
+		a.Errors = append(a.Errors, fmt.Sprintf("[AI] token budget of %d exhausted, %d results were not explained", a.AIBudget, unexplained))
+	}
+	a.reportFallbacks()
+	a.reportUnparsed()
+	return nil
+}
+
+// reportUnparsed warns about the structured explanations that were kept as
+// text because the reply did not match the schema.
+func (a *Analysis) reportUnparsed() {
+	unparsed := 0
+	for _, result := range a.Results {
+		if _, structured := a.promptTemplate(result.Kind); structured && result.Explanation == nil && result.Details != "" {
+			unparsed++
+		}
+	}
+	if unparsed > 0 {
+		a.Errors = append(a.Errors, fmt.Sprintf("[AI] %d explanations did not match the JSON schema and were kept as text", unparsed))
+	}
+}
+
+// reportFallbacks warns about the results explained by a fallback provider.
+func (a *Analysis) reportFallbacks() {
+	fallbacks := map[string]int{}
+	for _, result := range a.Results {
+		if result.Provider != "" && result.Provider != a.AIClient.GetName() {
+			fallbacks[result.Provider]++
+		}
+	}
+	names := make([]string, 0, len(fallbacks))
+	for name := range fallbacks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a.Errors = append(a.Errors, fmt.Sprintf("[AI] %d results were explained by fallback provider %s", fallbacks[name], name))
+	}
+}
+
+// collectAIResults explains the results concurrently and returns the number
+// of results left unexplained by the token budget.
+func (a *Analysis) collectAIResults(output string, redactor *redact.Redactor) (int, error) {
+	verbose := viper.GetBool("verbose")
 	var bar *progressbar.ProgressBar
 	if output != "json" {
 		bar = progressbar.Default(int64(len(a.Results)))
 	}
 
-	for index, analysis := range a.Results {
-		var texts []string
+	semaphore := make(chan struct{}, a.concurrency())
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var firstErr error
+	unexplained := 0
 
-		if bar != nil && verbose {
-			bar.Describe(fmt.Sprintf("Analyzing %s", analysis.Kind))
+	for _, group := range a.explanationGroups(a.GroupExplanations) {
+		mutex.Lock()
+		failed := firstErr != nil
+		mutex.Unlock()
+		if failed {
+			break
 		}
 
-		for _, failure := range analysis.Error {
-			if anonymize {
-				for _, s := range failure.Sensitive {
-					failure.Text = util.ReplaceIfMatch(failure.Text, s.Unmasked, s.Masked)
-				}
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(group []int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			if bar != nil && verbose {
+				bar.Describe(fmt.Sprintf("Analyzing %s", a.Results[group[0]].Kind))
 			}
-			texts = append(texts, failure.Text)
-		}
 
-		promptTemplate := ai.PromptMap["default"]
-		// If the resource `Kind` comes from an "integration plugin",
-		// maybe a customized prompt template will be involved.
-		if prompt, ok := ai.PromptMap[analysis.Kind]; ok {
-			promptTemplate = prompt
-		}
-		result, err := a.getAIResultForSanitizedFailures(texts, promptTemplate)
-		if err != nil {
-			// FIXME: can we avoid checking if output is json multiple times?
-			//   maybe implement the progress bar better?
-			if output != "json" {
-				_ = bar.Exit()
+			result, err := a.explainResult(a.groupResult(group), redactor, nil)
+			if errors.Is(err, errAIBudgetExhausted) {
+				mutex.Lock()
+				for _, index := range group {
+					a.Results[index].Unexplained = true
+				}
+				unexplained += len(group)
+				mutex.Unlock()
+				if bar != nil {
+					_ = bar.Add(len(group))
+				}
+				return
+			}
+			if err != nil {
+				mutex.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mutex.Unlock()
+				return
 			}
 
-			// Check for exhaustion.
-			if strings.Contains(err.Error(), "status code: 429") {
-				return fmt.Errorf("exhausted API quota for AI provider %s: %v", a.AIClient.GetName(), err)
+			mutex.Lock()
+			for _, index := range group {
+				result.apply(&a.Results[index])
 			}
-			return fmt.Errorf("failed while calling AI provider %s: %v", a.AIClient.GetName(), err)
+			mutex.Unlock()
+			if bar != nil {
+				_ = bar.Add(len(group))
+			}
+		}(group)
+	}
+	wg.Wait()
+
+	// FIXME: can we avoid checking if output is json multiple times?
+	//   maybe implement the progress bar better?
+	if firstErr != nil && bar != nil {
+		_ = bar.Exit()
+	}
+	return unexplained, firstErr
+}
+
+// streamAIResults explains the results one after the other and writes them
+// to the Stream in the text output format, rendering explanations as they
+// arrive. PrintOutput then only adds the warnings.
+func (a *Analysis) streamAIResults(redactor *redact.Redactor) (int, error) {
+	var header strings.Builder
+	a.writeProviderHeader(&header)
+	header.WriteString("\n")
+	_, _ = io.WriteString(a.Stream, header.String())
+	a.streamed = true
+
+	// Results are written in order; the failures of a group are explained
+	// together with the first result and the others reuse its explanation.
+	leaders := make([]int, len(a.Results))
+	groups := map[int][]int{}
+	for _, group := range a.explanationGroups(a.GroupExplanations) {
+		groups[group[0]] = group
+		for _, index := range group {
+			leaders[index] = group[0]
 		}
+	}
 
-		if anonymize {
-			for _, failure := range analysis.Error {
-				for _, s := range failure.Sensitive {
-					result = strings.ReplaceAll(result, s.Masked, s.Unmasked)
-				}
+	unexplained := 0
+	for index := range a.Results {
+		leader := leaders[index]
+		if leader != index {
+			a.Results[index].Details = a.Results[leader].Details
+			a.Results[index].Explanation = a.Results[leader].Explanation
+			a.Results[index].Provider = a.Results[leader].Provider
+			a.Results[index].Unexplained = a.Results[leader].Unexplained
+			if a.Results[index].Unexplained {
+				unexplained++
 			}
+			var text strings.Builder
+			writeTextResult(&text, index, a.Results[index])
+			text.WriteString(color.GreenString(a.Results[index].Details + "\n"))
+			_, _ = io.WriteString(a.Stream, text.String())
+			continue
 		}
 
-		analysis.Details = result
-		if output != "json" {
-			_ = bar.Add(1)
+		var text strings.Builder
+		writeTextResult(&text, index, a.Results[index])
+		_, _ = io.WriteString(a.Stream, text.String())
+
+		restorer := NewStreamRestorer(redactor, func(text string) {
+			_, _ = io.WriteString(a.Stream, color.GreenString(text))
+		})
+		result, err := a.explainResult(a.groupResult(groups[index]), redactor, restorer.Write)
+		if errors.Is(err, errAIBudgetExhausted) {
+			a.Results[index].Unexplained = true
+			unexplained++
+			_, _ = io.WriteString(a.Stream, color.YellowString("Not explained: AI token budget exhausted\n"))
+		} else if err != nil {
+			return unexplained, err
 		}
-		a.Results[index] = analysis
+		restorer.Flush()
+		result.apply(&a.Results[index])
+		_, _ = io.WriteString(a.Stream, "\n")
 	}
-	return nil
+	return unexplained, nil
 }
 
-func (a *Analysis) getAIResultForSanitizedFailures(texts []string, promptTmpl string) (string, error) {
+// explained is the explanation of a result and the provider that gave it,
+// which is empty for cached explanations.
+type explained struct {
+	details     string
+	explanation *ai.Explanation
+	provider    string
+	transcript  []ai.ChatMessage
+}
+
+func (e explained) apply(result *common.Result) {
+	result.Details = e.details
+	result.Explanation = e.explanation
+	result.Provider = e.provider
+	result.Transcript = e.transcript
+}
+
+// explainResult asks the AI backend to explain the failures of a result,
+// redacting them first when a redactor is given. Explanations are passed to
+// onText as they arrive when it is set.
+func (a *Analysis) explainResult(analysis common.Result, redactor *redact.Redactor, onText func(string)) (explained, error) {
+	texts := failureTexts(analysis, redactor)
+	data := a.promptData(analysis, texts, redactor)
+	promptTemplate, structured := a.promptTemplate(analysis.Kind)
+	if structured {
+		return a.explainStructured(texts, data.Context, redactor, onText)
+	}
+
+	prompt, err := promptTemplate.Render(data)
+	if err != nil {
+		return explained{}, err
+	}
+	if a.Agent != nil {
+		return a.investigate(prompt, redactor, onText)
+	}
 	inputKey := strings.Join(texts, " ")
-	// Check for cached data.
-	// TODO(bwplotka): This might depend on model too (or even other client configuration pieces), fix it in later PRs.
-	cacheKey := util.GetCacheKey(a.AIClient.GetName(), a.Language, inputKey)
+	cacheKey := a.cacheKey(promptTemplate.Template, inputKey)
+	if !promptTemplate.IsBuiltIn() || data.Context != "" {
+		// The prompt holds more than the failures.
+		cacheKey = a.cacheKey(promptTemplate.Template, prompt)
+	}
+	result, provider, err := a.complete(inputKey, cacheKey, prompt, onText)
+	if err != nil {
+		return explained{}, err
+	}
+	if redactor != nil {
+		result = redactor.Restore(result)
+	}
+	return explained{details: result, provider: provider}, nil
+}
+
+// explainStructured asks for an ai.Explanation of texts, with the context
+// of the object. When the reply cannot be parsed it asks once more, then
+// keeps the reply as text.
+func (a *Analysis) explainStructured(texts []string, objectContext string, redactor *redact.Redactor, onText func(string)) (explained, error) {
+	var result explained
+	inputKey := strings.Join(texts, " ")
+	for _, key := range []string{ai.StructuredPrompt, ai.StructuredRetryPrompt} {
+		promptTmpl := ai.PromptMap[key]
+		prompt := prompts.AppendContext(fmt.Sprintf(strings.TrimSpace(promptTmpl), a.Language, inputKey), objectContext)
+		cacheKey := a.cacheKey(promptTmpl, inputKey)
+		if objectContext != "" {
+			cacheKey = a.cacheKey(promptTmpl, prompt)
+		}
+		response, provider, err := a.complete(inputKey, cacheKey, prompt, nil)
+		if err != nil {
+			if result.details != "" {
+				// Keep the first reply when the retry fails.
+				break
+			}
+			return explained{}, err
+		}
+		if redactor != nil {
+			response = redactor.Restore(response)
+		}
+		result = explained{details: response, provider: provider}
+		if explanation, err := ai.ParseExplanation(response); err == nil {
+			result.details = explanation.String()
+			result.explanation = explanation
+			break
+		}
+		// Do not serve the invalid reply from the cache again.
+		if !a.Cache.IsCacheDisabled() && a.Cache.Exists(cacheKey) {
+			_ = a.Cache.Remove(cacheKey)
+		}
+	}
+	if onText != nil {
+		onText(result.details)
+	}
+	return result, nil
+}
+
+// cacheKey returns the key the explanation of input with promptTmpl is
+// cached under. Besides the backend and the language, the key depends on
+// the model and the parameters of the provider and on the template, so
+// changing any of them does not serve stale explanations.
+func (a *Analysis) cacheKey(promptTmpl, input string) string {
+	tmplHash := sha256.Sum256([]byte(promptTmpl))
+	return util.GetCacheKey(a.AIClient.GetName(), a.Language, fmt.Sprintf("%s-%x-%s", a.cacheParameters, tmplHash[:8], input))
+}
+
+// cachedExplanation is an explanation in the AI cache.
+type cachedExplanation struct {
+	Provider string `json:"provider"`
+	Response string `json:"response"`
+}
 
+// complete returns the cached explanation under cacheKey, or asks the AI
+// backend to complete prompt, an explanation of the failures in inputKey.
+func (a *Analysis) complete(inputKey, cacheKey, prompt string, onText func(string)) (string, string, error) {
+	// Check for cached data.
 	if !a.Cache.IsCacheDisabled() && a.Cache.Exists(cacheKey) {
 		response, err := a.Cache.Load(cacheKey)
 		if err != nil {
-			return "", err
+			return "", "", err
 		}
 
 		if response != "" {
+			var cached cachedExplanation
 			output, err := base64.StdEncoding.DecodeString(response)
 			if err == nil {
-				return string(output), nil
+				err = json.Unmarshal(output, &cached)
+			}
+			if err == nil {
+				if onText != nil {
+					onText(cached.Response)
+				}
+				return cached.Response, cached.Provider, nil
 			}
 			color.Red("error decoding cached data; ignoring cache item: %v", err)
 		}
 	}
 
-	// Process template.
-	prompt := fmt.Sprintf(strings.TrimSpace(promptTmpl), a.Language, inputKey)
 	if a.AIClient.GetName() == ai.CustomRestClientName {
 		// Use proper JSON marshaling to handle special characters in error messages
 		// This fixes issues with quotes, newlines, and other special chars in inputKey
@@ -597,19 +999,51 @@ func (a *Analysis) getAIResultForSanitizedFailures(texts []string, promptTmpl st
 		}
 		promptBytes, err := json.Marshal(customRestPrompt)
 		if err != nil {
-			return "", fmt.Errorf("failed to marshal customrest prompt: %w", err)
+			return "", "", fmt.Errorf("failed to marshal customrest prompt: %w", err)
 		}
 		prompt = string(promptBytes)
 	}
-	response, err := a.AIClient.GetCompletion(a.Context, prompt)
+	if !a.aiBudget.reserve(ai.EstimateTokens(prompt)) {
+		return "", "", errAIBudgetExhausted
+	}
+
+	ctx := a.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, answeredBy := ai.WithProviderRecorder(ctx)
+	var response string
+	var err error
+	if onText != nil {
+		var chunks <-chan ai.Chunk
+		if chunks, err = ai.GetCompletionStream(ctx, a.AIClient, prompt); err == nil {
+			response, err = ai.ReadStream(chunks, onText)
+		}
+	} else {
+		response, err = a.AIClient.GetCompletion(ctx, prompt)
+	}
 	if err != nil {
-		return "", err
+		return "", "", err
+	}
+	provider := answeredBy()
+	if provider == "" {
+		provider = a.AIClient.GetName()
 	}
+	a.aiBudget.add(ai.EstimateTokens(response))
 
-	if err = a.Cache.Store(cacheKey, base64.StdEncoding.EncodeToString([]byte(response))); err != nil {
+	// The key is built from the primary provider, so the replies of a
+	// fallback provider are not cached.
+	if provider != a.AIClient.GetName() {
+		return response, provider, nil
+	}
+	data, err := json.Marshal(cachedExplanation{Provider: provider, Response: response})
+	if err != nil {
+		return "", "", err
+	}
+	if err = a.Cache.Store(cacheKey, base64.StdEncoding.EncodeToString(data)); err != nil {
 		color.Red("error storing value to cache; value won't be cached: %v", err)
 	}
-	return response, nil
+	return response, provider, nil
 }
 
 func (a *Analysis) Close() {
@@ -27,6 +27,7 @@ import (
 	schemav1 "buf.build/gen/go/k8sgpt-ai/k8sgpt/protocolbuffers/go/schema/v1"
 	"google.golang.org/grpc"
 
+	"github.com/adrg/xdg"
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
@@ -370,28 +371,36 @@ func TestNewAnalysis(t *testing.T) {
 	}
 }
 
-func TestGetAIResultForSanitizedFailures(t *testing.T) {
-	enabledCache := cache.New("enabled-cache")
+func TestComplete(t *testing.T) {
+	// Keep the file cache of the test out of the user's cache.
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xdg.Reload()
+
+	enabledCache := cache.New("file")
 	disabledCache := cache.New("disabled-cache")
 	disabledCache.DisableCache()
 	aiClient := &ai.NoOpAIClient{}
 
 	tests := []struct {
-		name           string
-		a              Analysis
-		texts          []string
-		promptTmpl     string
-		expectedOutput string
-		expectedErr    string
+		name             string
+		a                Analysis
+		prompt           string
+		calls            int
+		expectedOutput   string
+		expectedProvider string
 	}{
 		{
-			name: "Cache enabled",
+			name: "cache enabled",
 			a: Analysis{
 				AIClient: aiClient,
 				Cache:    enabledCache,
 			},
-			texts:          []string{"some-data"},
-			expectedOutput: "I am a noop response to the prompt %!(EXTRA string=, string=some-data)",
+			prompt:         "Explain: some-data",
+			calls:          2,
+			expectedOutput: "I am a noop response to the prompt Explain: some-data",
+			// The second call is served from the cache.
+			expectedProvider: aiClient.GetName(),
 		},
 		{
 			name: "cache disabled",
@@ -400,27 +409,47 @@ func TestGetAIResultForSanitizedFailures(t *testing.T) {
 				Cache:    disabledCache,
 				Language: "English",
 			},
-			texts:          []string{"test input"},
-			promptTmpl:     "Response in %s: %s",
-			expectedOutput: "I am a noop response to the prompt Response in English: test input",
+			prompt:           "Response in English: test input",
+			calls:            2,
+			expectedOutput:   "I am a noop response to the prompt Response in English: test input",
+			expectedProvider: aiClient.GetName(),
 		},
 	}
 
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			output, err := tt.a.getAIResultForSanitizedFailures(tt.texts, tt.promptTmpl)
-			if tt.expectedErr == "" {
+			cacheKey := tt.a.cacheKey(tt.name, tt.prompt)
+			var output, provider string
+			for i := 0; i < tt.calls; i++ {
+				var err error
+				output, provider, err = tt.a.complete(tt.prompt, cacheKey, tt.prompt, nil)
 				require.NoError(t, err)
-				require.Equal(t, tt.expectedOutput, output)
-			} else {
-				require.ErrorContains(t, err, tt.expectedErr)
-				require.Empty(t, output)
 			}
+			require.Equal(t, tt.expectedOutput, output)
+			require.Equal(t, tt.expectedProvider, provider)
 		})
 	}
 }
 
+func TestCompleteFallbackNotCached(t *testing.T) {
+	t.Cleanup(xdg.Reload)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	xdg.Reload()
+
+	a := Analysis{
+		AIClient: ai.NewFailoverClient(&unavailableAIClient{}, &ai.NoOpAIClient{}),
+		Cache:    cache.New("file"),
+	}
+	cacheKey := a.cacheKey("fallback", "some-data")
+	output, provider, err := a.complete("some-data", cacheKey, "some-data", nil)
+	require.NoError(t, err)
+	require.Equal(t, "I am a noop response to the prompt some-data", output)
+	require.Equal(t, "noopai", provider)
+	// The reply of the fallback provider is not served as the primary's.
+	require.False(t, a.Cache.Exists(cacheKey))
+}
+
 // Test: Verbose output in NewAnalysis with explain=false
 func TestVerbose_NewAnalysisWithoutExplain(t *testing.T) {
 	// Set viper config.
@@ -440,7 +469,7 @@ func TestVerbose_NewAnalysisWithoutExplain(t *testing.T) {
 		a, err := NewAnalysis(
 			"", "english", []string{"Pod"}, "default", "", true,
 			false, // explain
-			10, false, false, []string{}, false,
+			10, false, false, []string{}, false, "",
 		)
 		require.NoError(t, err)
 		a.Close()
@@ -499,7 +528,7 @@ func TestVerbose_NewAnalysisWithExplain(t *testing.T) {
 		a, err := NewAnalysis(
 			"", "english", []string{"Pod"}, "default", "", true,
 			true, // explain
-			10, false, false, []string{}, false,
+			10, false, false, []string{}, false, "",
 		)
 		require.NoError(t, err)
 		a.Close()
@@ -577,7 +606,8 @@ func TestVerbose_RunAnalysisWithoutFilter(t *testing.T) {
 	}
 
 	// Get all core analyzers from analyzer.GetAnalyzerMap()
-	coreAnalyzerMap, _ := analyzer.GetAnalyzerMap()
+	coreAnalyzerMap, _, err := analyzer.GetAnalyzerMap()
+	require.NoError(t, err)
 	for _, analyzerInstance := range coreAnalyzerMap {
 		analyzerType := getTypeName(analyzerInstance)
 		expectedLaunched := fmt.Sprintf("Debug: %s launched.", analyzerType)
@@ -640,6 +670,79 @@ func TestVerbose_RunCustomAnalysisWithCustomAnalyzer(t *testing.T) {
 	}
 }
 
+func TestSelectAnalyzersRules(t *testing.T) {
+	rule, err := analyzer.NewRuleAnalyzer(analyzer.RuleConfiguration{
+		Name:       "DeploymentLimits",
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Expression: "true",
+	})
+	require.NoError(t, err)
+
+	// The rules loaded by NewAnalysis are selected without reading the
+	// configuration again.
+	a := Analysis{Filters: []string{"DeploymentLimits"}, rules: []*analyzer.RuleAnalyzer{rule}}
+	analyzers, err := a.selectAnalyzers(nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]common.IAnalyzer{"DeploymentLimits": rule}, analyzers)
+}
+
+func TestCacheKey(t *testing.T) {
+	base := Analysis{AIClient: &ai.NoOpAIClient{}, Language: "english", cacheParameters: (&ai.AIProvider{Model: "gpt-4o"}).CacheParameters()}
+	key := base.cacheKey(ai.PromptMap["default"], "some-data")
+
+	tests := []struct {
+		name       string
+		a          Analysis
+		promptTmpl string
+		input      string
+		same       bool
+	}{
+		{
+			name:       "same request",
+			a:          base,
+			promptTmpl: ai.PromptMap["default"],
+			input:      "some-data",
+			same:       true,
+		},
+		{
+			name:       "other model",
+			a:          Analysis{AIClient: base.AIClient, Language: base.Language, cacheParameters: (&ai.AIProvider{Model: "gpt-4o-mini"}).CacheParameters()},
+			promptTmpl: ai.PromptMap["default"],
+			input:      "some-data",
+		},
+		{
+			name:       "other temperature",
+			a:          Analysis{AIClient: base.AIClient, Language: base.Language, cacheParameters: (&ai.AIProvider{Model: "gpt-4o", Temperature: 0.2}).CacheParameters()},
+			promptTmpl: ai.PromptMap["default"],
+			input:      "some-data",
+		},
+		{
+			name:       "other template",
+			a:          base,
+			promptTmpl: ai.PromptMap[ai.StructuredPrompt],
+			input:      "some-data",
+		},
+		{
+			name:       "other language",
+			a:          Analysis{AIClient: base.AIClient, Language: "german", cacheParameters: base.cacheParameters},
+			promptTmpl: ai.PromptMap["default"],
+			input:      "some-data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.a.cacheKey(tt.promptTmpl, tt.input)
+			if tt.same {
+				require.Equal(t, key, got)
+			} else {
+				require.NotEqual(t, key, got)
+			}
+		})
+	}
+}
+
 // Test: RunCustomAnalysis must not deadlock when MaxConcurrency is 0.
 // A non-positive value previously produced an unbuffered semaphore whose only
 // receiver is launched after the blocking send, hanging the command forever.
@@ -0,0 +1,83 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import "strings"
+
+// DeduplicateResults collapses results that belong to the same parent
+// object and whose failures only differ in object names, e.g. the pods of a
+// crash-looping Deployment, into the first of them. The collapsed result
+// lists every affected object, so it is explained and reported once.
+func (a *Analysis) DeduplicateResults() {
+	positions := map[string]int{}
+	results := a.Results[:0:0]
+	for _, result := range a.Results {
+		if result.ParentObject == "" {
+			results = append(results, result)
+			continue
+		}
+		key := result.ParentObject + "\x00" + similarityKey(result)
+		position, ok := positions[key]
+		if !ok {
+			positions[key] = len(results)
+			results = append(results, result)
+			continue
+		}
+
+		group := &results[position]
+		if len(group.Affected) == 0 {
+			group.Affected = []string{group.Name}
+		}
+		group.Affected = append(group.Affected, result.Name)
+		group.Count = len(group.Affected)
+		if result.Severity.Rank() > group.Severity.Rank() {
+			group.Severity = result.Severity
+		}
+	}
+	a.Results = results
+}
+
+// replaceName replaces the occurrences of name in text that are whole names:
+// not part of a longer name, nor followed by a tag or port as in web:1.0.
+func replaceName(text, name, replacement string) string {
+	if name == "" {
+		return text
+	}
+	var output strings.Builder
+	last := 0
+	for start := 0; start < len(text); {
+		index := strings.Index(text[start:], name)
+		if index < 0 {
+			break
+		}
+		begin, end := start+index, start+index+len(name)
+		start = begin + 1
+		if begin > 0 && isNameByte(text[begin-1]) {
+			continue
+		}
+		if end < len(text) && (isNameByte(text[end]) || text[end] == ':' && end+1 < len(text) && isNameByte(text[end+1])) {
+			continue
+		}
+		output.WriteString(text[last:begin])
+		output.WriteString(replacement)
+		last, start = end, end
+	}
+	output.WriteString(text[last:])
+	return output.String()
+}
+
+// isNameByte reports whether c can be part of a Kubernetes object name.
+func isNameByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '.' || c == '_'
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/stretchr/testify/require"
+)
+
+func replicaResult(name, parent string, severity common.Severity) common.Result {
+	return common.Result{
+		Kind:         "Pod",
+		Name:         "default/" + name,
+		ParentObject: parent,
+		Severity:     severity,
+		Error: []common.Failure{{
+			Text:      "the last termination reason is Error container=web pod=" + name,
+			Sensitive: []common.Sensitive{{Unmasked: name, Masked: "masked"}},
+		}},
+	}
+}
+
+func TestDeduplicateResults(t *testing.T) {
+	a := &Analysis{
+		Results: []common.Result{
+			replicaResult("web-1", "Deployment/web", common.SeverityMedium),
+			replicaResult("api-1", "Deployment/api", ""),
+			replicaResult("web-2", "Deployment/web", common.SeverityHigh),
+			replicaResult("web-3", "Deployment/web", common.SeverityMedium),
+			replicaResult("orphan", "", ""),
+			replicaResult("other", "", ""),
+		},
+	}
+	a.DeduplicateResults()
+
+	require.Len(t, a.Results, 4)
+	web := a.Results[0]
+	require.Equal(t, "default/web-1", web.Name)
+	require.Equal(t, 3, web.Count)
+	require.Equal(t, []string{"default/web-1", "default/web-2", "default/web-3"}, web.Affected)
+	require.Equal(t, common.SeverityHigh, web.Severity)
+	require.Zero(t, a.Results[1].Count)
+	require.Empty(t, a.Results[1].Affected)
+
+	output, err := a.PrintOutput("text")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Affected (3): default/web-1, default/web-2, default/web-3")
+
+	output, err = a.PrintOutput("json")
+	require.NoError(t, err)
+	var decoded JsonOutput
+	require.NoError(t, json.Unmarshal(output, &decoded))
+	require.Equal(t, 3, decoded.Results[0].Count)
+	require.Len(t, decoded.Results[0].Affected, 3)
+}
+
+func TestDeduplicateResultsDifferentFailures(t *testing.T) {
+	first := replicaResult("web-1", "Deployment/web", "")
+	second := replicaResult("web-2", "Deployment/web", "")
+	second.Error[0].Text = "Back-off pulling image web:latest"
+	a := &Analysis{Results: []common.Result{first, second}}
+	a.DeduplicateResults()
+
+	require.Len(t, a.Results, 2)
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+)
+
+// DiffOutput compares the findings of two analysis runs. Findings are keyed
+// by Kind, Name and failure text; a result whose failures only partially
+// changed is split across the sections. Results collapsed with --dedupe are
+// compared per affected object.
+type DiffOutput struct {
+	New       []common.Result `json:"new"`
+	Resolved  []common.Result `json:"resolved"`
+	Unchanged []common.Result `json:"unchanged"`
+}
+
+// LoadJsonOutput reads an analysis previously written with --output=json.
+func LoadJsonOutput(path string) (*JsonOutput, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var output JsonOutput
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("parsing analysis %s: %w", path, err)
+	}
+	return &output, nil
+}
+
+// DiffResults reports which findings of current are new compared to
+// baseline, which baseline findings are gone and which are still present.
+func DiffResults(baseline, current []common.Result) DiffOutput {
+	baseline = expandResults(baseline)
+	current = expandResults(current)
+	baselineKeys := failureKeys(baseline)
+	currentKeys := failureKeys(current)
+
+	var diff DiffOutput
+	diff.New, diff.Unchanged = splitResults(current, baselineKeys)
+	diff.Resolved, _ = splitResults(baseline, currentKeys)
+	return diff
+}
+
+// expandResults undoes DeduplicateResults: a result that lists several
+// affected objects is replaced by one result per object, with the name of the
+// first object in the failure texts substituted by the object's own name.
+func expandResults(results []common.Result) []common.Result {
+	expanded := make([]common.Result, 0, len(results))
+	for _, result := range results {
+		if len(result.Affected) == 0 {
+			expanded = append(expanded, result)
+			continue
+		}
+		for _, name := range result.Affected {
+			r := result
+			r.Name = name
+			r.Affected = nil
+			r.Count = 0
+			r.Error = make([]common.Failure, len(result.Error))
+			for i, failure := range result.Error {
+				failure.Text = renameObject(failure.Text, result.Name, name)
+				r.Error[i] = failure
+			}
+			expanded = append(expanded, r)
+		}
+	}
+	return expanded
+}
+
+// renameObject replaces the namespaced and the bare object name from in
+// text, where they appear as whole names.
+func renameObject(text, from, to string) string {
+	if from == to {
+		return text
+	}
+	text = replaceName(text, from, to)
+	_, fromObject, fromFound := strings.Cut(from, "/")
+	_, toObject, toFound := strings.Cut(to, "/")
+	if fromFound && toFound {
+		text = replaceName(text, fromObject, toObject)
+	}
+	return text
+}
+
+func failureKey(result common.Result, failure common.Failure) string {
+	return strings.Join([]string{result.Kind, result.Name, failure.Text}, "\x00")
+}
+
+func failureKeys(results []common.Result) map[string]bool {
+	keys := map[string]bool{}
+	for _, result := range results {
+		for _, failure := range result.Error {
+			keys[failureKey(result, failure)] = true
+		}
+	}
+	return keys
+}
+
+// splitResults partitions the failures of results by whether their key is
+// present in keys, keeping the remaining result fields intact.
+func splitResults(results []common.Result, keys map[string]bool) (missing []common.Result, present []common.Result) {
+	for _, result := range results {
+		var missingFailures, presentFailures []common.Failure
+		for _, failure := range result.Error {
+			if keys[failureKey(result, failure)] {
+				presentFailures = append(presentFailures, failure)
+			} else {
+				missingFailures = append(missingFailures, failure)
+			}
+		}
+		if len(missingFailures) > 0 {
+			r := result
+			r.Error = missingFailures
+			r.Severity = r.MaxSeverity()
+			missing = append(missing, r)
+		}
+		if len(presentFailures) > 0 {
+			r := result
+			r.Error = presentFailures
+			r.Severity = r.MaxSeverity()
+			present = append(present, r)
+		}
+	}
+	return missing, present
+}
+
+// ApplyBaseline keeps only the findings that are not part of the baseline
+// analysis and records the baseline findings that have been resolved.
+func (a *Analysis) ApplyBaseline(path string) error {
+	baseline, err := LoadJsonOutput(path)
+	if err != nil {
+		return err
+	}
+	diff := DiffResults(baseline.Results, a.Results)
+	a.Results = diff.New
+	a.Resolved = diff.Resolved
+	return nil
+}
+
+// PrintDiff renders a DiffOutput as json or text.
+func PrintDiff(diff DiffOutput, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		output, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling json: %v", err)
+		}
+		return output, nil
+	case "text":
+		var output strings.Builder
+		output.WriteString(color.RedString("New: %d\n", len(diff.New)))
+		writeTextResults(&output, diff.New)
+		output.WriteString(color.GreenString("\nResolved: %d\n", len(diff.Resolved)))
+		writeTextResults(&output, diff.Resolved)
+		output.WriteString(color.YellowString("\nUnchanged: %d\n", len(diff.Unchanged)))
+		return []byte(output.String()), nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s. Available format json,text", format)
+	}
+}
+
+func writeTextResults(output *strings.Builder, results []common.Result) {
+	for n, result := range results {
+		output.WriteString(fmt.Sprintf("%s: %s %s(%s)\n", color.CyanString("%d", n),
+			color.HiYellowString(result.Kind),
+			color.YellowString(result.Name),
+			color.CyanString(result.ParentObject)))
+		for _, err := range result.Error {
+			output.WriteString(fmt.Sprintf("- %s %s\n", color.RedString("Error:"), color.RedString(err.Text)))
+		}
+	}
+}
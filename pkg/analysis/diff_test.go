@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffResults(t *testing.T) {
+	baseline := []common.Result{
+		{Kind: "ConfigMap", Name: "default/legacy", Error: []common.Failure{{Text: "unused"}}},
+		{Kind: "Pod", Name: "default/web", Error: []common.Failure{{Text: "unready"}, {Text: "evicted"}}},
+		{Kind: "Service", Name: "default/api", Error: []common.Failure{{Text: "no endpoints"}}},
+	}
+	current := []common.Result{
+		{Kind: "ConfigMap", Name: "default/legacy", Error: []common.Failure{{Text: "unused"}}},
+		{Kind: "Pod", Name: "default/web", Error: []common.Failure{{Text: "unready"}, {Text: "crash loop"}}},
+		{Kind: "Node", Name: "node-1", Error: []common.Failure{{Text: "not ready"}}},
+	}
+
+	diff := DiffResults(baseline, current)
+
+	require.Len(t, diff.New, 2)
+	require.Equal(t, "default/web", diff.New[0].Name)
+	require.Equal(t, []common.Failure{{Text: "crash loop"}}, diff.New[0].Error)
+	require.Equal(t, "node-1", diff.New[1].Name)
+
+	require.Len(t, diff.Resolved, 2)
+	require.Equal(t, []common.Failure{{Text: "evicted"}}, diff.Resolved[0].Error)
+	require.Equal(t, "default/api", diff.Resolved[1].Name)
+
+	require.Len(t, diff.Unchanged, 2)
+	require.Equal(t, "default/legacy", diff.Unchanged[0].Name)
+	require.Equal(t, []common.Failure{{Text: "unready"}}, diff.Unchanged[1].Error)
+}
+
+func TestDiffResultsDeduplicated(t *testing.T) {
+	deduped := []common.Result{
+		{
+			Kind:         "Pod",
+			Name:         "default/web-1",
+			ParentObject: "Deployment/web",
+			Error:        []common.Failure{{Text: "the last termination reason is Error container=web pod=web-1"}},
+			Affected:     []string{"default/web-1", "default/web-2"},
+			Count:        2,
+		},
+	}
+	separate := []common.Result{
+		{Kind: "Pod", Name: "default/web-1", ParentObject: "Deployment/web", Error: []common.Failure{{Text: "the last termination reason is Error container=web pod=web-1"}}},
+		{Kind: "Pod", Name: "default/web-2", ParentObject: "Deployment/web", Error: []common.Failure{{Text: "the last termination reason is Error container=web pod=web-2"}}},
+		{Kind: "Pod", Name: "default/web-3", ParentObject: "Deployment/web", Error: []common.Failure{{Text: "the last termination reason is Error container=web pod=web-3"}}},
+	}
+
+	diff := DiffResults(deduped, separate)
+	require.Len(t, diff.New, 1)
+	require.Equal(t, "default/web-3", diff.New[0].Name)
+	require.Empty(t, diff.Resolved)
+	require.Len(t, diff.Unchanged, 2)
+
+	diff = DiffResults(separate, deduped)
+	require.Empty(t, diff.New)
+	require.Len(t, diff.Resolved, 1)
+	require.Equal(t, "default/web-3", diff.Resolved[0].Name)
+}
+
+func TestRenameObject(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"pod default/web is pending", "pod default/web-2 is pending"},
+		{"back-off restarting container=web pod=web", "back-off restarting container=web-2 pod=web-2"},
+		{"web: probe failed", "web-2: probe failed"},
+		// Longer names, images and ports that contain the name are kept.
+		{"image web:1.0 of webapp and web-1 from registry.web.io", "image web:1.0 of webapp and web-1 from registry.web.io"},
+		{"cannot reach web:8080", "cannot reach web:8080"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, renameObject(tt.text, "default/web", "default/web-2"))
+	}
+}
+
+func TestApplyBaseline(t *testing.T) {
+	previous := &Analysis{
+		Results: []common.Result{
+			{Kind: "ConfigMap", Name: "default/legacy", Error: []common.Failure{{Text: "unused"}}},
+			{Kind: "Service", Name: "default/api", Error: []common.Failure{{Text: "no endpoints"}}},
+		},
+	}
+	data, err := previous.PrintOutput("json")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "previous.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	a := &Analysis{
+		Results: []common.Result{
+			{Kind: "ConfigMap", Name: "default/legacy", Error: []common.Failure{{Text: "unused"}}},
+			{Kind: "Pod", Name: "default/web", Error: []common.Failure{{Text: "crash loop"}}},
+		},
+	}
+	require.NoError(t, a.ApplyBaseline(path))
+	require.Len(t, a.Results, 1)
+	require.Equal(t, "default/web", a.Results[0].Name)
+	require.Len(t, a.Resolved, 1)
+	require.Equal(t, "default/api", a.Resolved[0].Name)
+
+	output, err := a.PrintOutput("text")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Resolved since baseline: 1")
+
+	require.Error(t, a.ApplyBaseline(filepath.Join(t.TempDir(), "missing.json")))
+}
+
+func TestApplyBaselineDeduplicated(t *testing.T) {
+	crashLoop := func(pod string) common.Result {
+		return common.Result{
+			Kind:         "Pod",
+			Name:         "default/" + pod,
+			ParentObject: "Deployment/web",
+			Error:        []common.Failure{{Text: "the last termination reason is Error container=web pod=" + pod}},
+		}
+	}
+	previous := &Analysis{Results: []common.Result{crashLoop("web-1"), crashLoop("web-2")}}
+	previous.DeduplicateResults()
+	require.Len(t, previous.Results, 1)
+	data, err := previous.PrintOutput("json")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "previous.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	a := &Analysis{Results: []common.Result{crashLoop("web-1"), crashLoop("web-2"), crashLoop("web-3")}}
+	require.NoError(t, a.ApplyBaseline(path))
+	require.Len(t, a.Results, 1)
+	require.Equal(t, "default/web-3", a.Results[0].Name)
+	require.Empty(t, a.Resolved)
+
+	a.DeduplicateResults()
+	require.Len(t, a.Results, 1)
+	require.Empty(t, a.Results[0].Affected)
+}
@@ -0,0 +1,401 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k "k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+// The sections of context --explain-context can attach to prompts.
+const (
+	ContextEvents = "events"
+	ContextSpec   = "spec"
+	ContextLogs   = "logs"
+	ContextOwner  = "owner"
+)
+
+// ContextSections lists the valid --explain-context sections.
+var ContextSections = []string{ContextEvents, ContextSpec, ContextLogs, ContextOwner}
+
+const (
+	// DefaultContextTokens is the approximate token cap of a context section.
+	DefaultContextTokens = 400
+	contextEvents        = 5
+	contextLogLines      = int64(30)
+)
+
+// ParseExplainContext validates and deduplicates context sections.
+func ParseExplainContext(sections []string) ([]string, error) {
+	var parsed []string
+	seen := map[string]bool{}
+	for _, section := range sections {
+		section = strings.ToLower(strings.TrimSpace(section))
+		if section == "" || seen[section] {
+			continue
+		}
+		valid := false
+		for _, known := range ContextSections {
+			valid = valid || section == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown explain context %q, valid values are %s", section, strings.Join(ContextSections, ", "))
+		}
+		seen[section] = true
+		parsed = append(parsed, section)
+	}
+	return parsed, nil
+}
+
+// explainContext gathers the ExplainContext sections for result, redacted
+// when a redactor is given and capped to ContextTokens each. Sections that
+// cannot be fetched are left out.
+func (a *Analysis) explainContext(result common.Result, redactor *redact.Redactor) string {
+	namespace, name, container := splitResultName(result.Name)
+	var sections []string
+	for _, section := range a.ExplainContext {
+		var title, text string
+		keepEnd := false
+		switch section {
+		case ContextEvents:
+			title, text = "Recent events", a.eventContext(result.Kind, namespace, name)
+		case ContextSpec:
+			title, text = "Spec", a.specContext(result.Kind, namespace, name)
+		case ContextLogs:
+			title, text = "Recent logs", a.logContext(result.Kind, namespace, name, container)
+			keepEnd = true
+		case ContextOwner:
+			title, text = "Owners", strings.Join(result.OwnerChain, " -> ")
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			continue
+		}
+		if redactor != nil {
+			text = redactor.Redact(text)
+		}
+		sections = append(sections, fmt.Sprintf("%s:\n%s", title, capTokens(text, a.contextTokens(), keepEnd)))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+func (a *Analysis) contextTokens() int {
+	if a.ContextTokens > 0 {
+		return a.ContextTokens
+	}
+	return DefaultContextTokens
+}
+
+func (a *Analysis) context() context.Context {
+	if a.Context == nil {
+		return context.Background()
+	}
+	return a.Context
+}
+
+// eventContext returns the most recent events of the object, newest first.
+func (a *Analysis) eventContext(kind, namespace, name string) string {
+	if a.Client == nil || a.Client.Client == nil {
+		return ""
+	}
+	list, err := a.Client.Client.CoreV1().Events(namespace).List(a.context(), metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": kind, "involvedObject.name": name}.String(),
+	})
+	if err != nil {
+		return ""
+	}
+	var events []v1.Event
+	for _, event := range list.Items {
+		if event.InvolvedObject.Kind == kind && event.InvolvedObject.Name == name {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return eventTime(events[i]).After(eventTime(events[j]).Time)
+	})
+	var lines []string
+	for i, event := range events {
+		if i == contextEvents {
+			break
+		}
+		line := fmt.Sprintf("%s %s: %s", event.Type, event.Reason, strings.TrimSpace(event.Message))
+		if event.Count > 1 {
+			line += fmt.Sprintf(" (x%d)", event.Count)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func eventTime(event v1.Event) metav1.Time {
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp
+	}
+	if !event.EventTime.IsZero() {
+		return metav1.Time{Time: event.EventTime.Time}
+	}
+	return event.CreationTimestamp
+}
+
+// containerExcerpt holds the fields of a container that explain most
+// failures.
+type containerExcerpt struct {
+	Name           string                  `json:"name"`
+	Image          string                  `json:"image"`
+	Command        []string                `json:"command,omitempty"`
+	Args           []string                `json:"args,omitempty"`
+	Resources      v1.ResourceRequirements `json:"resources,omitempty"`
+	LivenessProbe  *v1.Probe               `json:"livenessProbe,omitempty"`
+	ReadinessProbe *v1.Probe               `json:"readinessProbe,omitempty"`
+	StartupProbe   *v1.Probe               `json:"startupProbe,omitempty"`
+}
+
+// specExcerpts return the relevant part of the spec of an object by kind:
+// the containers of pods and workloads, and the spec of a few other kinds.
+var specExcerpts = map[string]func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error){
+	"Pod": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec), nil
+	},
+	"Deployment": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec.Template.Spec), nil
+	},
+	"StatefulSet": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec.Template.Spec), nil
+	},
+	"DaemonSet": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec.Template.Spec), nil
+	},
+	"ReplicaSet": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec.Template.Spec), nil
+	},
+	"Job": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec.Template.Spec), nil
+	},
+	"CronJob": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return podSpecExcerpt(object.Spec.JobTemplate.Spec.Template.Spec), nil
+	},
+	"Service": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return object.Spec, nil
+	},
+	"PersistentVolumeClaim": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return object.Spec, nil
+	},
+	"Ingress": func(ctx context.Context, client k.Interface, namespace, name string) (interface{}, error) {
+		object, err := client.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return object.Spec, nil
+	},
+}
+
+// specContext returns the spec excerpt of the object as YAML.
+func (a *Analysis) specContext(kind, namespace, name string) string {
+	excerpt, ok := specExcerpts[kind]
+	if !ok || a.Client == nil || a.Client.Client == nil {
+		return ""
+	}
+	spec, err := excerpt(a.context(), a.Client.Client, namespace, name)
+	if err != nil {
+		return ""
+	}
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+func podSpecExcerpt(spec v1.PodSpec) map[string][]containerExcerpt {
+	excerpt := map[string][]containerExcerpt{}
+	for _, group := range []struct {
+		name       string
+		containers []v1.Container
+	}{
+		{"initContainers", spec.InitContainers},
+		{"containers", spec.Containers},
+	} {
+		for _, c := range group.containers {
+			excerpt[group.name] = append(excerpt[group.name], containerExcerpt{
+				Name:           c.Name,
+				Image:          c.Image,
+				Command:        c.Command,
+				Args:           c.Args,
+				Resources:      c.Resources,
+				LivenessProbe:  c.LivenessProbe,
+				ReadinessProbe: c.ReadinessProbe,
+				StartupProbe:   c.StartupProbe,
+			})
+		}
+	}
+	return excerpt
+}
+
+// logContext returns the last log lines of the containers of a pod, or of
+// container when the result is about a single one. The logs of the previous
+// instance are used for containers that restarted without logging since.
+func (a *Analysis) logContext(kind, namespace, name, container string) string {
+	if kind != "Pod" || a.Client == nil || a.Client.Client == nil {
+		return ""
+	}
+	pod, err := a.Client.Client.CoreV1().Pods(namespace).Get(a.context(), name, metav1.GetOptions{})
+	if err != nil {
+		return ""
+	}
+	restarted := map[string]bool{}
+	for _, status := range pod.Status.ContainerStatuses {
+		restarted[status.Name] = status.RestartCount > 0
+	}
+
+	var sections []string
+	for _, c := range pod.Spec.Containers {
+		if container != "" && c.Name != container {
+			continue
+		}
+		logs := a.containerLogs(namespace, name, c.Name, false)
+		if logs == "" && restarted[c.Name] {
+			logs = a.containerLogs(namespace, name, c.Name, true)
+		}
+		if logs == "" {
+			continue
+		}
+		if len(pod.Spec.Containers) > 1 {
+			logs = fmt.Sprintf("[%s]\n%s", c.Name, logs)
+		}
+		sections = append(sections, logs)
+	}
+	return strings.Join(sections, "\n")
+}
+
+// PodLogs returns the last log lines of the containers of a pod, or of
+// container when it is set, capped like the logs attached to prompts.
+func (a *Analysis) PodLogs(namespace, pod, container string) string {
+	return capTokens(a.logContext("Pod", namespace, pod, container), a.contextTokens(), true)
+}
+
+func (a *Analysis) containerLogs(namespace, pod, container string, previous bool) string {
+	tailLines := contextLogLines
+	stream, err := a.Client.Client.CoreV1().Pods(namespace).GetLogs(pod, &v1.PodLogOptions{
+		Container: container,
+		Previous:  previous,
+		TailLines: &tailLines,
+	}).Stream(a.context())
+	if err != nil {
+		return ""
+	}
+	defer stream.Close()
+	logs, err := io.ReadAll(stream)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(logs))
+}
+
+// splitResultName splits a result name into its namespace, object name and
+// container, as used by the Log analyzer. Cluster-scoped objects have no
+// namespace.
+func splitResultName(name string) (string, string, string) {
+	parts := strings.SplitN(name, "/", 3)
+	switch len(parts) {
+	case 1:
+		return "", parts[0], ""
+	case 2:
+		return parts[0], parts[1], ""
+	default:
+		return parts[0], parts[1], parts[2]
+	}
+}
+
+// capTokens shortens text to about tokens tokens, dropping whole lines from
+// the end, or from the start when keepEnd is set.
+func capTokens(text string, tokens int, keepEnd bool) string {
+	if ai.EstimateTokens(text) <= tokens {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	var kept []string
+	size := 0
+	for i := range lines {
+		line := lines[i]
+		if keepEnd {
+			line = lines[len(lines)-1-i]
+		}
+		if size+len(line)+1 > tokens*4 {
+			break
+		}
+		size += len(line) + 1
+		kept = append(kept, line)
+	}
+	if len(kept) == 0 {
+		// A single line is longer than the cap.
+		if keepEnd {
+			return "..." + text[len(text)-tokens*4:]
+		}
+		return text[:tokens*4] + "..."
+	}
+	if keepEnd {
+		for i, j := 0, len(kept)-1; i < j; i, j = i+1, j-1 {
+			kept[i], kept[j] = kept[j], kept[i]
+		}
+		return "...\n" + strings.Join(kept, "\n")
+	}
+	return strings.Join(kept, "\n") + "\n..."
+}
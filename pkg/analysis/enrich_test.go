@@ -0,0 +1,162 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseExplainContext(t *testing.T) {
+	sections, err := ParseExplainContext([]string{"Events", " spec", "events", "", "logs"})
+	require.NoError(t, err)
+	require.Equal(t, []string{ContextEvents, ContextSpec, ContextLogs}, sections)
+
+	_, err = ParseExplainContext([]string{"events", "metrics"})
+	require.ErrorContains(t, err, `unknown explain context "metrics"`)
+}
+
+func TestCapTokens(t *testing.T) {
+	text := "line one\nline two\nline three"
+	tests := []struct {
+		name    string
+		tokens  int
+		keepEnd bool
+		want    string
+	}{
+		{name: "under the cap", tokens: 100, want: text},
+		{name: "keep start", tokens: 5, want: "line one\nline two\n..."},
+		{name: "keep end", tokens: 5, keepEnd: true, want: "...\nline two\nline three"},
+		{name: "long line", tokens: 1, want: "line..."},
+		{name: "long line keep end", tokens: 1, keepEnd: true, want: "...hree"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, capTokens(text, tt.tokens, tt.keepEnd))
+		})
+	}
+}
+
+func TestSplitResultName(t *testing.T) {
+	tests := []struct {
+		name                         string
+		namespace, object, container string
+	}{
+		{name: "node-1", object: "node-1"},
+		{name: "default/web", namespace: "default", object: "web"},
+		{name: "default/web/app", namespace: "default", object: "web", container: "app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namespace, object, container := splitResultName(tt.name)
+			require.Equal(t, tt.namespace, namespace)
+			require.Equal(t, tt.object, object)
+			require.Equal(t, tt.container, container)
+		})
+	}
+}
+
+func contextClient() *kubernetes.Client {
+	now := time.Now()
+	event := func(name, reason string, at time.Time) *v1.Event {
+		return &v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "payments"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "api-7d9f", Namespace: "payments"},
+			Type:           "Warning",
+			Reason:         reason,
+			Message:        "pod api-7d9f: " + reason,
+			LastTimestamp:  metav1.NewTime(at),
+		}
+	}
+	return &kubernetes.Client{Client: fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-7d9f", Namespace: "payments"},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Name:  "api",
+				Image: "registry.example.com/api:1.2.3",
+				Args:  []string{"--port=8080"},
+			}}},
+		},
+		event("older", "Pulled", now.Add(-time.Hour)),
+		event("newer", "BackOff", now),
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "other", Namespace: "payments"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "other", Namespace: "payments"},
+			Reason:         "Unrelated",
+		},
+	)}
+}
+
+func TestExplainContext(t *testing.T) {
+	result := common.Result{
+		Kind:       "Pod",
+		Name:       "payments/api-7d9f",
+		OwnerChain: []string{"ReplicaSet/api-7d9f", "Deployment/api"},
+	}
+	a := &Analysis{
+		Client:         contextClient(),
+		ExplainContext: ContextSections,
+	}
+	context := a.explainContext(result, nil)
+
+	require.Contains(t, context, "Recent events:\nWarning BackOff: pod api-7d9f: BackOff\nWarning Pulled: pod api-7d9f: Pulled")
+	require.NotContains(t, context, "Unrelated")
+	require.Contains(t, context, "Spec:\ncontainers:\n- args:\n  - --port=8080\n  image: registry.example.com/api:1.2.3")
+	// The fake clientset answers every log request with "fake logs".
+	require.Contains(t, context, "Recent logs:\nfake logs")
+	require.Contains(t, context, "Owners:\nReplicaSet/api-7d9f -> Deployment/api")
+
+	a.ExplainContext = []string{ContextEvents}
+	a.ContextTokens = 10
+	require.Equal(t, "Recent events:\nWarning BackOff: pod api-7d9f: BackOff\n...", a.explainContext(result, nil))
+
+	a.ExplainContext = nil
+	require.Empty(t, a.explainContext(result, nil))
+}
+
+func TestGetAIResultsExplainContext(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("redaction.key", "test-key")
+
+	client := &echoAIClient{}
+	a := &Analysis{
+		AIClient:       client,
+		Cache:          disabledCache(),
+		Client:         contextClient(),
+		ExplainContext: []string{ContextEvents, ContextSpec},
+		Results: []common.Result{{
+			Kind:  "Pod",
+			Name:  "payments/api-7d9f",
+			Error: []common.Failure{{Text: "Back-off restarting failed container api in pod api-7d9f"}},
+		}},
+	}
+	require.NoError(t, a.GetAIResults("json", true))
+
+	require.Len(t, client.prompts, 1)
+	prompt := client.prompts[0]
+	require.Contains(t, prompt, "Use this context about the object:\nRecent events:\nWarning BackOff")
+	require.Contains(t, prompt, "--port=8080")
+	require.NotContains(t, prompt, "api-7d9f")
+	require.True(t, strings.Count(a.Results[0].Details, "api-7d9f") > 1)
+}
@@ -0,0 +1,201 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+)
+
+// errAIBudgetExhausted is returned instead of calling the AI provider once
+// the token budget of the run has been used up.
+var errAIBudgetExhausted = errors.New("AI token budget exhausted")
+
+// tokenBudget tracks the approximate number of tokens sent to and received
+// from the AI provider during a run. A nil budget is unlimited.
+type tokenBudget struct {
+	mutex sync.Mutex
+	limit int
+	used  int
+}
+
+func newTokenBudget(limit int) *tokenBudget {
+	if limit <= 0 {
+		return nil
+	}
+	return &tokenBudget{limit: limit}
+}
+
+// reserve accounts for a prompt before it is sent and reports whether the
+// budget allows sending it.
+func (b *tokenBudget) reserve(tokens int) bool {
+	if b == nil {
+		return true
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.used+tokens > b.limit {
+		return false
+	}
+	b.used += tokens
+	return true
+}
+
+// add accounts for a completion. Completions may overshoot the limit, which
+// only stops further prompts.
+func (b *tokenBudget) add(tokens int) {
+	if b == nil {
+		return
+	}
+	b.mutex.Lock()
+	b.used += tokens
+	b.mutex.Unlock()
+}
+
+// explanationGroups returns the indices of the results that share one
+// explanation. Without grouping every result is explained on its own;
+// with grouping, results of the same kind whose failures only differ in
+// object names and other sensitive values are explained once.
+func (a *Analysis) explanationGroups(group bool) [][]int {
+	groups := make([][]int, 0, len(a.Results))
+	if !group {
+		for index := range a.Results {
+			groups = append(groups, []int{index})
+		}
+		return groups
+	}
+
+	positions := map[string]int{}
+	for index, result := range a.Results {
+		key := similarityKey(result)
+		if position, ok := positions[key]; ok {
+			groups[position] = append(groups[position], index)
+			continue
+		}
+		positions[key] = len(groups)
+		groups = append(groups, []int{index})
+	}
+	return groups
+}
+
+// groupResult returns the result explained for a group: the first result of
+// the group carrying the failures of every member, so that the shared
+// explanation covers all of the affected objects.
+func (a *Analysis) groupResult(group []int) common.Result {
+	result := a.Results[group[0]]
+	if len(group) == 1 {
+		return result
+	}
+	failures := make([]common.Failure, 0, len(group)*len(result.Error))
+	for _, index := range group {
+		failures = append(failures, a.Results[index].Error...)
+	}
+	result.Error = failures
+	return result
+}
+
+// similarityKey identifies the failures of a result regardless of the names
+// of the objects involved, which are matched as whole names only.
+func similarityKey(result common.Result) string {
+	names := []string{result.Name, result.ParentObject}
+	if _, object, found := strings.Cut(result.Name, "/"); found {
+		names = append(names, object)
+	}
+
+	texts := make([]string, 0, len(result.Error))
+	for _, failure := range result.Error {
+		text := failure.Text
+		for _, sensitive := range failure.Sensitive {
+			names = append(names, sensitive.Unmasked)
+		}
+		for _, name := range names {
+			text = replaceName(text, name, "<name>")
+		}
+		texts = append(texts, text)
+	}
+	return result.Kind + "\x00" + strings.Join(texts, "\x00")
+}
+
+// NewRedactor returns the redactor used for --anonymize, aware of the names
+// of every object in the results so far.
+func (a *Analysis) NewRedactor() (*redact.Redactor, error) {
+	config, err := redact.LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	redactor, err := redact.New(config)
+	if err != nil {
+		return nil, err
+	}
+	a.AddResultNames(redactor)
+	return redactor, nil
+}
+
+// AddResultNames makes redactor aware of the names of every object in the
+// results, e.g. after the analysis ran again.
+func (a *Analysis) AddResultNames(redactor *redact.Redactor) {
+	for _, result := range a.Results {
+		// Parent objects are "Kind/name" and only the name identifies them.
+		parent := result.ParentObject[strings.LastIndex(result.ParentObject, "/")+1:]
+		redactor.AddNames(result.Name, parent)
+		redactor.AddNames(result.Affected...)
+		for _, failure := range result.Error {
+			for _, sensitive := range failure.Sensitive {
+				redactor.AddNames(sensitive.Unmasked)
+			}
+		}
+	}
+}
+
+// StreamRestorer passes streamed explanations on. With a redactor, text is
+// held back until the end of a line, as pseudonyms may be split across
+// chunks and can only be restored once complete.
+type StreamRestorer struct {
+	redactor *redact.Redactor
+	out      func(string)
+	pending  strings.Builder
+}
+
+// NewStreamRestorer returns a StreamRestorer that passes text to out,
+// restoring the pseudonyms of redactor when it is not nil.
+func NewStreamRestorer(redactor *redact.Redactor, out func(string)) *StreamRestorer {
+	return &StreamRestorer{redactor: redactor, out: out}
+}
+
+// Write passes text on, or buffers it until the end of its line.
+func (r *StreamRestorer) Write(text string) {
+	if r.redactor == nil {
+		r.out(text)
+		return
+	}
+	r.pending.WriteString(text)
+	buffered := r.pending.String()
+	if end := strings.LastIndex(buffered, "\n"); end >= 0 {
+		r.out(r.redactor.Restore(buffered[:end+1]))
+		r.pending.Reset()
+		r.pending.WriteString(buffered[end+1:])
+	}
+}
+
+// Flush passes on the text still buffered at the end of a stream.
+func (r *StreamRestorer) Flush() {
+	if r.pending.Len() > 0 {
+		r.out(r.redactor.Restore(r.pending.String()))
+		r.pending.Reset()
+	}
+}
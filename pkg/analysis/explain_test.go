@@ -0,0 +1,433 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/agent"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/prompts"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// countingAIClient records how many completions it serves and how many of
+// them ran at the same time.
+type countingAIClient struct {
+	ai.NoOpAIClient
+	calls   atomic.Int32
+	running atomic.Int32
+	mutex   sync.Mutex
+	peak    int32
+}
+
+func (c *countingAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	c.calls.Add(1)
+	running := c.running.Add(1)
+	defer c.running.Add(-1)
+	c.mutex.Lock()
+	if running > c.peak {
+		c.peak = running
+	}
+	c.mutex.Unlock()
+	time.Sleep(20 * time.Millisecond)
+	return "explanation", nil
+}
+
+func explainResults(n int) []common.Result {
+	results := make([]common.Result, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("web-%d", i)
+		results = append(results, common.Result{
+			Kind: "Pod",
+			Name: "default/" + name,
+			Error: []common.Failure{{
+				Text:      fmt.Sprintf("the last termination reason is Error container=web pod=%s", name),
+				Sensitive: []common.Sensitive{{Unmasked: name, Masked: "masked"}},
+			}},
+		})
+	}
+	return results
+}
+
+func disabledCache() cache.ICache {
+	c := cache.New("disabled-cache")
+	c.DisableCache()
+	return c
+}
+
+func TestGetAIResultsConcurrency(t *testing.T) {
+	client := &countingAIClient{}
+	a := &Analysis{
+		AIClient:       client,
+		Cache:          disabledCache(),
+		Results:        explainResults(12),
+		MaxConcurrency: 4,
+	}
+	require.NoError(t, a.GetAIResults("json", false))
+
+	require.Equal(t, int32(12), client.calls.Load())
+	require.LessOrEqual(t, client.peak, int32(4))
+	require.Greater(t, client.peak, int32(1))
+	for _, result := range a.Results {
+		require.Equal(t, "explanation", result.Details)
+	}
+}
+
+func TestGetAIResultsGrouping(t *testing.T) {
+	client := &countingAIClient{}
+	results := append(explainResults(5), common.Result{
+		Kind:  "Service",
+		Name:  "default/api",
+		Error: []common.Failure{{Text: "Service has no endpoints, expected label app=api"}},
+	})
+	a := &Analysis{
+		AIClient:          client,
+		Cache:             disabledCache(),
+		Results:           results,
+		GroupExplanations: true,
+	}
+	require.NoError(t, a.GetAIResults("json", true))
+
+	require.Equal(t, int32(2), client.calls.Load())
+	for _, result := range a.Results {
+		require.Equal(t, "explanation", result.Details)
+	}
+}
+
+func TestGroupResult(t *testing.T) {
+	a := &Analysis{Results: explainResults(3)}
+	groups := a.explanationGroups(true)
+	require.Equal(t, [][]int{{0, 1, 2}}, groups)
+
+	result := a.groupResult(groups[0])
+	require.Equal(t, "default/web-0", result.Name)
+	require.Len(t, result.Error, 3)
+	for n, failure := range result.Error {
+		require.Contains(t, failure.Text, fmt.Sprintf("pod=web-%d", n))
+	}
+	require.Len(t, a.Results[0].Error, 1)
+}
+
+func TestSimilarityKey(t *testing.T) {
+	pod := func(name, text string) common.Result {
+		return common.Result{Kind: "Pod", Name: "default/" + name, Error: []common.Failure{{Text: text}}}
+	}
+	require.Equal(t,
+		similarityKey(pod("web-1", "web-1 is pending")),
+		similarityKey(pod("web-2", "web-2 is pending")))
+	// Names inside other words, e.g. of images, are not replaced.
+	require.NotEqual(t,
+		similarityKey(pod("x", "x: image nginx not found")),
+		similarityKey(pod("y", "y: image nginy not found")))
+}
+
+func TestGetAIResultsBudget(t *testing.T) {
+	client := &countingAIClient{}
+	a := &Analysis{
+		AIClient:       client,
+		Cache:          disabledCache(),
+		Results:        explainResults(10),
+		MaxConcurrency: 1,
+		// Enough for a few prompts of the default template.
+		AIBudget: 300,
+	}
+	require.NoError(t, a.GetAIResults("json", false))
+
+	calls := int(client.calls.Load())
+	require.Greater(t, calls, 0)
+	require.Less(t, calls, 10)
+	unexplained := 0
+	for _, result := range a.Results {
+		if result.Unexplained {
+			unexplained++
+			require.Empty(t, result.Details)
+		}
+	}
+	require.Equal(t, 10-calls, unexplained)
+	require.Equal(t, []string{fmt.Sprintf("[AI] token budget of 300 exhausted, %d results were not explained", unexplained)}, a.Errors)
+
+	output, err := a.PrintOutput("text")
+	require.NoError(t, err)
+	require.Contains(t, string(output), "Not explained: AI token budget exhausted")
+}
+
+func TestTokenBudget(t *testing.T) {
+	var unlimited *tokenBudget
+	require.True(t, unlimited.reserve(1<<30))
+	require.Nil(t, newTokenBudget(0))
+
+	budget := newTokenBudget(10)
+	require.True(t, budget.reserve(6))
+	require.False(t, budget.reserve(5))
+	budget.add(3)
+	require.True(t, budget.reserve(1))
+	require.False(t, budget.reserve(1))
+}
+
+// echoAIClient answers with the prompt it received.
+type echoAIClient struct {
+	ai.NoOpAIClient
+	mutex   sync.Mutex
+	prompts []string
+}
+
+func (c *echoAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	c.mutex.Lock()
+	c.prompts = append(c.prompts, prompt)
+	c.mutex.Unlock()
+	return prompt, nil
+}
+
+func TestGetAIResultsAnonymize(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("redaction.key", "test-key")
+
+	client := &echoAIClient{}
+	a := &Analysis{
+		AIClient: client,
+		Cache:    disabledCache(),
+		Results: []common.Result{{
+			Kind:         "Pod",
+			Name:         "payments/api-7d9f",
+			ParentObject: "Deployment/api",
+			Error:        []common.Failure{{Text: "pod api-7d9f in namespace payments cannot reach 10.0.0.12"}},
+		}},
+	}
+	require.NoError(t, a.GetAIResults("json", true))
+
+	require.Len(t, client.prompts, 1)
+	for _, value := range []string{"api-7d9f", "payments", "10.0.0.12"} {
+		require.NotContains(t, client.prompts[0], value)
+	}
+	require.Contains(t, a.Results[0].Details, "pod api-7d9f in namespace payments cannot reach 10.0.0.12")
+}
+
+func TestGetAIResultsPromptTemplate(t *testing.T) {
+	registry, err := prompts.Load(prompts.Config{Templates: []prompts.Template{{
+		Kind:     "Pod",
+		Template: "{{.Kind}} {{.Name}} in {{.Language}}: {{.Failures}}",
+	}}})
+	require.NoError(t, err)
+
+	client := &echoAIClient{}
+	a := &Analysis{
+		AIClient: client,
+		Cache:    disabledCache(),
+		Language: "english",
+		Prompts:  registry,
+		Results:  append(explainResults(1), common.Result{Kind: "Service", Name: "default/web", Error: []common.Failure{{Text: "no endpoints"}}}),
+	}
+	require.NoError(t, a.GetAIResults("json", false))
+
+	require.Equal(t, "Pod default/web-0 in english: the last termination reason is Error container=web pod=web-0", a.Results[0].Details)
+	// Kinds without a template of their own use the built-in default.
+	require.Contains(t, a.Results[1].Details, "--- no endpoints ---")
+
+	prompt, err := a.RenderPrompt(a.Results[0], false)
+	require.NoError(t, err)
+	require.Equal(t, a.Results[0].Details, prompt)
+}
+
+// unavailableAIClient fails every request with a rate limiting error.
+type unavailableAIClient struct {
+	ai.NoOpAIClient
+}
+
+func (c *unavailableAIClient) GetName() string { return "openai" }
+
+func (c *unavailableAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	return "", errors.New("429 Too Many Requests")
+}
+
+func TestGetAIResultsFallbackProvider(t *testing.T) {
+	a := &Analysis{
+		AIClient: ai.NewFailoverClient(&unavailableAIClient{}, &ai.NoOpAIClient{}),
+		Cache:    disabledCache(),
+		Results:  explainResults(3),
+	}
+	require.NoError(t, a.GetAIResults("json", false))
+
+	for _, result := range a.Results {
+		require.Equal(t, "noopai", result.Provider)
+		require.NotEmpty(t, result.Details)
+	}
+	require.Equal(t, []string{"[AI] 3 results were explained by fallback provider noopai"}, a.Errors)
+}
+
+// scriptedAIClient answers with its replies in order, repeating the last one.
+type scriptedAIClient struct {
+	ai.NoOpAIClient
+	mutex   sync.Mutex
+	replies []string
+	prompts []string
+}
+
+func (c *scriptedAIClient) GetCompletion(ctx context.Context, prompt string) (string, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	reply := c.replies[min(len(c.prompts), len(c.replies)-1)]
+	c.prompts = append(c.prompts, prompt)
+	return reply, nil
+}
+
+func TestGetAIResultsStructured(t *testing.T) {
+	const valid = `{"cause": "The image does not exist.", "confidence": 0.9, "steps": ["Fix the image"], "commands": ["kubectl describe pod web-0"]}`
+	tests := []struct {
+		name            string
+		replies         []string
+		wantPrompts     int
+		wantExplanation bool
+		wantDetails     string
+		wantErrors      []string
+	}{
+		{
+			name:            "valid reply",
+			replies:         []string{"```json\n" + valid + "\n```"},
+			wantPrompts:     1,
+			wantExplanation: true,
+			wantDetails:     "Error: The image does not exist. (confidence 90%)\nSolution:\n1. Fix the image\nCommands:\n  kubectl describe pod web-0",
+		},
+		{
+			name:            "valid after retry",
+			replies:         []string{"The image does not exist.", valid},
+			wantPrompts:     2,
+			wantExplanation: true,
+			wantDetails:     "Error: The image does not exist. (confidence 90%)\nSolution:\n1. Fix the image\nCommands:\n  kubectl describe pod web-0",
+		},
+		{
+			name:        "falls back to text",
+			replies:     []string{"Error: the image does not exist"},
+			wantPrompts: 2,
+			wantDetails: "Error: the image does not exist",
+			wantErrors:  []string{"[AI] 1 explanations did not match the JSON schema and were kept as text"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &scriptedAIClient{replies: tt.replies}
+			a := &Analysis{
+				AIClient:   client,
+				Cache:      disabledCache(),
+				Results:    explainResults(1),
+				Structured: true,
+			}
+			require.NoError(t, a.GetAIResults("json", false))
+
+			require.Len(t, client.prompts, tt.wantPrompts)
+			require.Contains(t, client.prompts[0], ai.ExplanationSchema)
+			require.Equal(t, tt.wantDetails, a.Results[0].Details)
+			require.Equal(t, tt.wantExplanation, a.Results[0].Explanation != nil)
+			require.Equal(t, tt.wantErrors, a.Errors)
+		})
+	}
+}
+
+// chunkedAIClient streams the prompt it received back in small pieces.
+type chunkedAIClient struct {
+	ai.NoOpAIClient
+}
+
+func (c *chunkedAIClient) GetCompletionStream(ctx context.Context, prompt string) (<-chan ai.Chunk, error) {
+	chunks := make(chan ai.Chunk, len(prompt))
+	for start := 0; start < len(prompt); start += 3 {
+		end := min(start+3, len(prompt))
+		chunks <- ai.Chunk{Text: prompt[start:end]}
+	}
+	close(chunks)
+	return chunks, nil
+}
+
+func TestGetAIResultsStream(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+	viper.Set("redaction.key", "test-key")
+
+	var stream strings.Builder
+	a := &Analysis{
+		AIClient:           &chunkedAIClient{},
+		AnalysisAIProvider: "chunked",
+		Explain:            true,
+		Cache:              disabledCache(),
+		Results:            explainResults(3),
+		GroupExplanations:  true,
+		Stream:             &stream,
+	}
+	require.NoError(t, a.GetAIResults("text", true))
+
+	streamed := stream.String()
+	require.Contains(t, streamed, "AI Provider: chunked")
+	for n, result := range a.Results {
+		require.Contains(t, streamed, fmt.Sprintf("%d: Pod %s()", n, result.Name))
+		// The explanation of the group covers the failures of every member
+		// and is restored for every result.
+		for _, member := range a.Results {
+			require.Contains(t, result.Details, strings.TrimPrefix(member.Name, "default/"))
+		}
+	}
+	require.Equal(t, 3, strings.Count(streamed, "pod=web-0 the last termination reason is Error container=web pod=web-1"))
+	require.NotContains(t, streamed, "name-")
+
+	output, err := a.PrintOutput("text")
+	require.NoError(t, err)
+	require.NotContains(t, string(output), "AI Provider")
+	require.NotContains(t, string(output), "default/web-0")
+}
+
+// toolCallingAIClient looks at the logs of the pod before answering.
+type toolCallingAIClient struct {
+	ai.NoOpAIClient
+}
+
+func (c *toolCallingAIClient) GetToolCompletion(ctx context.Context, messages []ai.ChatMessage, tools []ai.Tool) (ai.ChatMessage, error) {
+	last := messages[len(messages)-1]
+	if last.Role == ai.RoleTool {
+		return ai.ChatMessage{Role: ai.RoleAssistant, Content: "Error: " + last.Content}, nil
+	}
+	return ai.ChatMessage{Role: ai.RoleAssistant, ToolCalls: []ai.ToolCall{{ID: "1", Name: "get-logs", Arguments: `{"podName":"web-0"}`}}}, nil
+}
+
+func TestGetAIResultsAgent(t *testing.T) {
+	logs := agent.Tool{
+		Tool: ai.Tool{Name: "get-logs"},
+		Call: func(ctx context.Context, arguments string) (string, error) {
+			return "logs of " + arguments, nil
+		},
+	}
+	a := &Analysis{
+		AIClient: &toolCallingAIClient{},
+		Cache:    disabledCache(),
+		Results:  explainResults(1),
+		Agent:    &agent.Agent{Tools: []agent.Tool{logs}},
+	}
+	require.NoError(t, a.GetAIResults("json", false))
+
+	require.Equal(t, `Error: logs of {"podName":"web-0"}`, a.Results[0].Details)
+	require.Len(t, a.Results[0].Transcript, 4)
+	require.Contains(t, a.Results[0].Transcript[0].Content, "the last termination reason is Error container=web pod=web-0")
+
+	a.AIClient = &echoAIClient{}
+	require.ErrorContains(t, a.GetAIResults("json", false), ai.ErrToolsUnsupported.Error())
+}
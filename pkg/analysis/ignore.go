@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
+)
+
+// applyIgnoreRules drops the failures silenced by the configured ignore
+// rules and reports expired or invalid rules as errors.
+func (a *Analysis) applyIgnoreRules() {
+	now := time.Now()
+	a.Errors = append(a.Errors, a.ignoreRuleErrors(now)...)
+	a.Results = ignore.Apply(a.IgnoreRules, a.Results, now)
+}
+
+func (a *Analysis) ignoreRuleErrors(now time.Time) []string {
+	var errors []string
+	for _, rule := range a.IgnoreRules {
+		if _, err := rule.Expiry(); err != nil {
+			errors = append(errors, fmt.Sprintf("[ignore] rule %s: %s", rule.ID, err))
+			continue
+		}
+		if rule.Expired(now) {
+			errors = append(errors, fmt.Sprintf("[ignore] rule %s expired on %s, remove it with k8sgpt ignore remove %s", rule.ID, rule.Until, rule.ID))
+		}
+	}
+	return errors
+}
+
+// dropAnnotatedResults removes the results whose object carries the ignore
+// annotation for the named analyzer. The annotations are read from lister,
+// so every resource is listed once per namespace instead of fetching each
+// object. Objects that cannot be listed, e.g. because they have been deleted
+// in the meantime, are kept.
+func (a *Analysis) dropAnnotatedResults(lister *common.Lister, analyzerName string, results []common.Result) []common.Result {
+	if a.Client == nil || a.Client.DynamicClient == nil {
+		return results
+	}
+
+	kept := results[:0:0]
+	for _, result := range results {
+		if !a.annotationIgnores(lister, analyzerName, result) {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}
+
+func (a *Analysis) annotationIgnores(lister *common.Lister, analyzerName string, result common.Result) bool {
+	gvr, ok := analyzer.GetResultResource(result.Kind)
+	if !ok {
+		return false
+	}
+	namespace, name := resultObject(result.Name)
+	annotations, found, err := lister.Annotations(a.Context, gvr, namespace, name)
+	if err != nil || !found {
+		return false
+	}
+	value, ok := annotations[ignore.Annotation]
+	return ok && ignore.AnnotationIgnores(value, analyzerName)
+}
+
+// resultObject splits a result name into the namespace and name of its
+// object. Names of cluster-scoped objects have no namespace, and trailing
+// segments such as the container of a Log result are dropped.
+func resultObject(resultName string) (namespace, name string) {
+	namespace, rest, found := strings.Cut(resultName, "/")
+	if !found {
+		return "", resultName
+	}
+	name, _, _ = strings.Cut(rest, "/")
+	return namespace, name
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func unschedulablePod(name string, annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{
+				{
+					Type:    v1.PodScheduled,
+					Reason:  "Unschedulable",
+					Message: "0/1 nodes are available",
+				},
+			},
+		},
+	}
+}
+
+func TestRunAnalysisIgnore(t *testing.T) {
+	objects := []runtime.Object{
+		unschedulablePod("web", nil),
+		unschedulablePod("db", nil),
+		unschedulablePod("batch", map[string]string{ignore.Annotation: "Pod"}),
+	}
+	a := &Analysis{
+		Context:   context.Background(),
+		Filters:   []string{"Pod"},
+		Namespace: "default",
+		Client: &kubernetes.Client{
+			Client:        fake.NewSimpleClientset(objects...),
+			DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme.Scheme, objects...),
+		},
+		IgnoreRules: []ignore.Rule{
+			{ID: "silenced", Kind: "Pod", Name: "db"},
+			{ID: "expired", Kind: "Pod", Name: "web", Until: "2020-01-01"},
+		},
+	}
+	a.RunAnalysis()
+
+	require.Len(t, a.Results, 1)
+	require.Equal(t, "default/web", a.Results[0].Name)
+	require.Len(t, a.Errors, 1)
+	require.True(t, strings.HasPrefix(a.Errors[0], "[ignore] rule expired expired on 2020-01-01"))
+}
+
+func TestDropAnnotatedResultsWithoutDynamicClient(t *testing.T) {
+	a := &Analysis{Context: context.Background(), Client: &kubernetes.Client{}}
+	results := []common.Result{{Kind: "Pod", Name: "default/web"}}
+	require.Equal(t, results, a.dropAnnotatedResults(common.NewLister(a.Client), "Pod", results))
+}
+
+func TestDropAnnotatedResultsListsOnce(t *testing.T) {
+	service := func(name string, annotations map[string]string) *v1.Service {
+		return &v1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations}}
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme,
+		service("web", nil),
+		service("db", map[string]string{ignore.Annotation: "Service"}),
+		unschedulablePod("batch", map[string]string{ignore.Annotation: "Log"}),
+	)
+	objects := []runtime.Object{unschedulablePod("batch", map[string]string{ignore.Annotation: "Log"})}
+	a := &Analysis{
+		Context: context.Background(),
+		Client: &kubernetes.Client{
+			Client:        fake.NewSimpleClientset(objects...),
+			DynamicClient: dynamicClient,
+		},
+	}
+	lister := common.NewLister(a.Client)
+
+	services := []common.Result{
+		{Kind: "Service", Name: "default/web"},
+		{Kind: "Service", Name: "default/db"},
+		{Kind: "Service", Name: "default/deleted"},
+	}
+	kept := a.dropAnnotatedResults(lister, "Service", services)
+	require.Equal(t, []common.Result{services[0], services[2]}, kept)
+	require.Len(t, dynamicClient.Actions(), 1)
+	require.Equal(t, "list", dynamicClient.Actions()[0].GetVerb())
+
+	logs := []common.Result{{Kind: "Pod", Name: "default/batch/main"}}
+	require.Empty(t, a.dropAnnotatedResults(lister, "Log", logs))
+	require.Len(t, dynamicClient.Actions(), 1)
+}
+
+func TestResultObject(t *testing.T) {
+	for name, want := range map[string][2]string{
+		"node-1":                {"", "node-1"},
+		"default/web":           {"default", "web"},
+		"default/web-0/sidecar": {"default", "web-0"},
+	} {
+		namespace, object := resultObject(name)
+		require.Equal(t, want, [2]string{namespace, object}, name)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+)
+
+// investigate has the Agent answer prompt with read-only cluster tools.
+// Investigations depend on the live state of the cluster and are not
+// cached.
+func (a *Analysis) investigate(prompt string, redactor *redact.Redactor, onText func(string)) (explained, error) {
+	client, ok := a.AIClient.(ai.IToolCallingAI)
+	if !ok || !ai.SupportsTools(a.AIClient) {
+		return explained{}, ai.ErrToolsUnsupported
+	}
+	ctx, answeredBy := ai.WithProviderRecorder(a.context())
+	answer, transcript, err := a.Agent.Investigate(ctx, budgetedToolClient{client, a.aiBudget}, prompt, redactor)
+	if err != nil {
+		return explained{}, err
+	}
+	if redactor != nil {
+		answer = redactor.Restore(answer)
+	}
+	if onText != nil {
+		onText(answer)
+	}
+	provider := answeredBy()
+	if provider == "" {
+		provider = a.AIClient.GetName()
+	}
+	return explained{details: answer, provider: provider, transcript: transcript}, nil
+}
+
+// budgetedToolClient counts the conversation of every step of an
+// investigation against the token budget.
+type budgetedToolClient struct {
+	ai.IToolCallingAI
+	budget *tokenBudget
+}
+
+func (c budgetedToolClient) GetToolCompletion(ctx context.Context, messages []ai.ChatMessage, tools []ai.Tool) (ai.ChatMessage, error) {
+	tokens := 0
+	for _, message := range messages {
+		tokens += ai.EstimateTokens(message.Content)
+		for _, call := range message.ToolCalls {
+			tokens += ai.EstimateTokens(call.Arguments)
+		}
+	}
+	if !c.budget.reserve(tokens) {
+		return ai.ChatMessage{}, errAIBudgetExhausted
+	}
+	reply, err := c.IToolCallingAI.GetToolCompletion(ctx, messages, tools)
+	if err != nil {
+		return reply, err
+	}
+	c.budget.add(ai.EstimateTokens(reply.Content))
+	return reply, nil
+}
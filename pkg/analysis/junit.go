@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+type JUnitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []JUnitTestSuite `xml:"testsuite"`
+}
+
+type JUnitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []JUnitTestCase `xml:"testcase"`
+	SystemErr string          `xml:"system-err,omitempty"`
+}
+
+type JUnitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *JUnitFailure `xml:"failure,omitempty"`
+}
+
+type JUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Content string `xml:",chardata"`
+}
+
+// junitOutput reports every result as a failing testcase, grouped into one
+// testsuite per kind. Analysis warnings are attached to a "k8sgpt" suite.
+func (a *Analysis) junitOutput() ([]byte, error) {
+	suites := []JUnitTestSuite{}
+	suiteIndex := map[string]int{}
+
+	for _, result := range a.Results {
+		index, ok := suiteIndex[result.Kind]
+		if !ok {
+			index = len(suites)
+			suiteIndex[result.Kind] = index
+			suites = append(suites, JUnitTestSuite{Name: result.Kind})
+		}
+
+		texts := make([]string, 0, len(result.Error))
+		for _, failure := range result.Error {
+			texts = append(texts, failure.Text)
+		}
+		message := fmt.Sprintf("%s %s has problems", result.Kind, result.Name)
+		if len(texts) > 0 {
+			message = texts[0]
+		}
+		content := strings.Join(texts, "\n")
+		if result.ParentObject != "" {
+			content = fmt.Sprintf("Parent: %s\n%s", result.ParentObject, content)
+		}
+		if result.Details != "" {
+			content = fmt.Sprintf("%s\n\n%s", content, result.Details)
+		}
+
+		suite := &suites[index]
+		suite.Tests++
+		suite.Failures++
+		suite.TestCases = append(suite.TestCases, JUnitTestCase{
+			Name:      resultKey(result),
+			ClassName: result.Kind,
+			Failure: &JUnitFailure{
+				Message: message,
+				Type:    string(result.Severity),
+				Content: content,
+			},
+		})
+	}
+
+	if len(a.Errors) != 0 {
+		suites = append(suites, JUnitTestSuite{
+			Name:      "k8sgpt",
+			SystemErr: strings.Join(a.Errors, "\n"),
+		})
+	}
+
+	output := JUnitTestSuites{Name: "k8sgpt", Suites: suites}
+	for _, suite := range suites {
+		output.Tests += suite.Tests
+		output.Failures += suite.Failures
+	}
+
+	data, err := xml.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling junit: %v", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
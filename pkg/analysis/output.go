@@ -6,11 +6,14 @@ import (
 	"strings"
 
 	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 )
 
 var outputFormats = map[string]func(*Analysis) ([]byte, error){
-	"json": (*Analysis).jsonOutput,
-	"text": (*Analysis).textOutput,
+	"json":  (*Analysis).jsonOutput,
+	"text":  (*Analysis).textOutput,
+	"sarif": (*Analysis).sarifOutput,
+	"junit": (*Analysis).junitOutput,
 }
 
 func getOutputFormats() []string {
@@ -47,6 +50,7 @@ func (a *Analysis) jsonOutput() ([]byte, error) {
 		Results:  a.Results,
 		Errors:   a.Errors,
 		Status:   status,
+		Resolved: a.Resolved,
 	}
 	output, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -70,11 +74,9 @@ func (a *Analysis) PrintStats() []byte {
 func (a *Analysis) textOutput() ([]byte, error) {
 	var output strings.Builder
 
-	// Print the AI provider used for this analysis (if explain was enabled).
-	if a.Explain {
-		output.WriteString(fmt.Sprintf("AI Provider: %s\n", color.YellowString(a.AnalysisAIProvider)))
-	} else {
-		output.WriteString(fmt.Sprintf("AI Provider: %s\n", color.YellowString("AI not used; --explain not set")))
+	// Streamed results have been written along with the header already.
+	if !a.streamed {
+		a.writeProviderHeader(&output)
 	}
 
 	if len(a.Errors) != 0 {
@@ -87,20 +89,76 @@ func (a *Analysis) textOutput() ([]byte, error) {
 	output.WriteString("\n")
 	if len(a.Results) == 0 {
 		output.WriteString(color.GreenString("No problems detected\n"))
+		a.writeResolved(&output)
 		return []byte(output.String()), nil
 	}
-	for n, result := range a.Results {
+	if !a.streamed {
+		for n, result := range a.Results {
+			writeTextResult(&output, n, result)
+			output.WriteString(color.GreenString(result.Details + "\n"))
+		}
+	}
+	a.writeResolved(&output)
+	return []byte(output.String()), nil
+}
+
+// writeProviderHeader prints the AI provider used for this analysis (if
+// explain was enabled).
+func (a *Analysis) writeProviderHeader(output *strings.Builder) {
+	if a.Explain {
+		output.WriteString(fmt.Sprintf("AI Provider: %s\n", color.YellowString(a.AnalysisAIProvider)))
+	} else {
+		output.WriteString(fmt.Sprintf("AI Provider: %s\n", color.YellowString("AI not used; --explain not set")))
+	}
+}
+
+// writeTextResult prints a result without its explanation.
+func writeTextResult(output *strings.Builder, n int, result common.Result) {
+	if result.Severity != "" {
+		output.WriteString(fmt.Sprintf("%s: %s %s %s(%s)\n", color.CyanString("%d", n),
+			severityString(result.Severity),
+			color.HiYellowString(result.Kind),
+			color.YellowString(result.Name),
+			color.CyanString(result.ParentObject)))
+	} else {
 		output.WriteString(fmt.Sprintf("%s: %s %s(%s)\n", color.CyanString("%d", n),
 			color.HiYellowString(result.Kind),
 			color.YellowString(result.Name),
 			color.CyanString(result.ParentObject)))
-		for _, err := range result.Error {
-			output.WriteString(fmt.Sprintf("- %s %s\n", color.RedString("Error:"), color.RedString(err.Text)))
-			if err.KubernetesDoc != "" {
-				output.WriteString(fmt.Sprintf("  %s %s\n", color.RedString("Kubernetes Doc:"), color.RedString(err.KubernetesDoc)))
-			}
+	}
+	if result.Count > 1 {
+		output.WriteString(fmt.Sprintf("- %s %s\n", color.CyanString("Affected (%d):", result.Count), strings.Join(result.Affected, ", ")))
+	}
+	for _, err := range result.Error {
+		output.WriteString(fmt.Sprintf("- %s %s\n", color.RedString("Error:"), color.RedString(err.Text)))
+		if err.KubernetesDoc != "" {
+			output.WriteString(fmt.Sprintf("  %s %s\n", color.RedString("Kubernetes Doc:"), color.RedString(err.KubernetesDoc)))
 		}
-		output.WriteString(color.GreenString(result.Details + "\n"))
 	}
-	return []byte(output.String()), nil
+	if result.Unexplained {
+		output.WriteString(color.YellowString("Not explained: AI token budget exhausted\n"))
+	}
+}
+
+// writeResolved lists the baseline findings that are no longer reported.
+func (a *Analysis) writeResolved(output *strings.Builder) {
+	if len(a.Resolved) == 0 {
+		return
+	}
+	output.WriteString(color.GreenString("\nResolved since baseline: %d\n", len(a.Resolved)))
+	writeTextResults(output, a.Resolved)
+}
+
+func severityString(severity common.Severity) string {
+	label := fmt.Sprintf("[%s]", severity)
+	switch severity {
+	case common.SeverityCritical:
+		return color.HiRedString(label)
+	case common.SeverityHigh:
+		return color.RedString(label)
+	case common.SeverityMedium:
+		return color.YellowString(label)
+	default:
+		return color.WhiteString(label)
+	}
 }
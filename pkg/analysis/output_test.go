@@ -14,9 +14,14 @@ limitations under the License.
 package analysis
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"os"
 	"testing"
 
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/stretchr/testify/require"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 func TestPrintOutput(t *testing.T) {
@@ -62,3 +67,121 @@ func TestPrintOutput(t *testing.T) {
 		})
 	}
 }
+
+// sarifAnalysis returns an analysis with results of each kind of SARIF
+// level and an analyzer error.
+func sarifAnalysis() *Analysis {
+	return &Analysis{
+		Results: []common.Result{
+			{
+				Kind:         "Pod",
+				Name:         "default/crashing",
+				ParentObject: "Deployment/web",
+				Error:        []common.Failure{{Text: "back-off restarting failed container"}},
+				Severity:     common.SeverityCritical,
+			},
+			{
+				Kind:     "ConfigMap",
+				Name:     "default/unused",
+				Error:    []common.Failure{{Text: "ConfigMap unused is not used by any pods in the namespace"}},
+				Severity: common.SeverityLow,
+			},
+		},
+		Errors: []string{"[Gateway] gateway api not installed"},
+	}
+}
+
+func TestSarifOutput(t *testing.T) {
+	output, err := sarifAnalysis().PrintOutput("sarif")
+	require.NoError(t, err)
+
+	var sarif SarifOutput
+	require.NoError(t, json.Unmarshal(output, &sarif))
+	require.Equal(t, "2.1.0", sarif.Version)
+	require.Len(t, sarif.Runs, 1)
+
+	run := sarif.Runs[0]
+	require.Len(t, run.Tool.Driver.Rules, 2)
+	require.Len(t, run.Results, 2)
+	require.Equal(t, "Pod", run.Results[0].RuleID)
+	require.Equal(t, "error", run.Results[0].Level)
+	require.Equal(t, "Pod/default/crashing/Deployment/web", run.Results[0].PartialFingerprints[sarifFingerprint])
+	require.Equal(t, "note", run.Results[1].Level)
+	require.Len(t, run.Invocations[0].ToolExecutionNotifications, 1)
+	require.Equal(t, "k8s/Pod/default/crashing", run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+	require.Equal(t, "k8s/ConfigMap/default/unused", run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI)
+
+	// testdata/sarif-2.1.0-subset.json is our own subset of the SARIF schema
+	// for the objects k8sgpt writes.
+	validation, err := gojsonschema.Validate(gojsonschema.NewReferenceLoader("file://./testdata/sarif-2.1.0-subset.json"), gojsonschema.NewBytesLoader(output))
+	require.NoError(t, err)
+	require.True(t, validation.Valid(), "%v", validation.Errors())
+}
+
+// TestSarifOfficialSchema validates the output against the official SARIF
+// schema, which is not vendored yet. Download it unmodified from
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/errata01/os/schemas/sarif-schema-2.1.0.json
+// to testdata/sarif-schema-2.1.0.json to run it.
+func TestSarifOfficialSchema(t *testing.T) {
+	if _, err := os.Stat("testdata/sarif-schema-2.1.0.json"); err != nil {
+		t.Skip("testdata/sarif-schema-2.1.0.json is not present")
+	}
+	output, err := sarifAnalysis().PrintOutput("sarif")
+	require.NoError(t, err)
+
+	validation, err := gojsonschema.Validate(gojsonschema.NewReferenceLoader("file://./testdata/sarif-schema-2.1.0.json"), gojsonschema.NewBytesLoader(output))
+	require.NoError(t, err)
+	require.True(t, validation.Valid(), "%v", validation.Errors())
+}
+
+func TestSarifURI(t *testing.T) {
+	tests := []struct {
+		result common.Result
+		uri    string
+	}{
+		{result: common.Result{Kind: "Pod", Name: "default/web-0"}, uri: "k8s/Pod/default/web-0"},
+		{result: common.Result{Kind: "Pod", Name: "default/web-0/sidecar"}, uri: "k8s/Pod/default/web-0"},
+		{result: common.Result{Kind: "Security/Deployment", Name: "apps/web"}, uri: "k8s/Deployment/apps/web"},
+		{result: common.Result{Kind: "Node", Name: "node-1"}, uri: "k8s/Node/node-1"},
+		{result: common.Result{Kind: "RBAC/ClusterRoleBinding", Name: "system:nodes"}, uri: "k8s/ClusterRoleBinding/system:nodes"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.uri, sarifURI(tt.result), tt.result.Name)
+	}
+}
+
+func TestJUnitOutput(t *testing.T) {
+	a := &Analysis{
+		Results: []common.Result{
+			{
+				Kind:  "Pod",
+				Name:  "default/a",
+				Error: []common.Failure{{Text: "first"}, {Text: "second"}},
+			},
+			{
+				Kind:  "Pod",
+				Name:  "default/b",
+				Error: []common.Failure{{Text: "third"}},
+			},
+			{
+				Kind:  "Service",
+				Name:  "default/svc",
+				Error: []common.Failure{{Text: "no endpoints"}},
+			},
+		},
+	}
+
+	output, err := a.PrintOutput("junit")
+	require.NoError(t, err)
+
+	var junit JUnitTestSuites
+	require.NoError(t, xml.Unmarshal(output, &junit))
+	require.Equal(t, 3, junit.Tests)
+	require.Equal(t, 3, junit.Failures)
+	require.Len(t, junit.Suites, 2)
+	require.Equal(t, "Pod", junit.Suites[0].Name)
+	require.Len(t, junit.Suites[0].TestCases, 2)
+	require.Equal(t, "Pod/default/a", junit.Suites[0].TestCases[0].Name)
+	require.Equal(t, "first", junit.Suites[0].TestCases[0].Failure.Message)
+	require.Contains(t, junit.Suites[0].TestCases[0].Failure.Content, "second")
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/integration"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/prompts"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/redact"
+)
+
+// promptTemplate returns the template for results of kind, or reports that
+// a structured explanation is requested for them. Templates for the kind
+// take precedence over structured explanations and the default template.
+func (a *Analysis) promptTemplate(kind string) (*prompts.Template, bool) {
+	if t, ok := a.Prompts.Lookup(kind, integrationOf(kind)); ok {
+		return t, false
+	}
+	if a.Structured {
+		return nil, true
+	}
+	return a.Prompts.Default(), false
+}
+
+// RenderPrompt returns the prompt that explains result, as it would be sent
+// to the AI backend, with the failures redacted when anonymize is set.
+func (a *Analysis) RenderPrompt(result common.Result, anonymize bool) (string, error) {
+	var redactor *redact.Redactor
+	if anonymize {
+		var err error
+		if redactor, err = a.NewRedactor(); err != nil {
+			return "", err
+		}
+	}
+	texts := failureTexts(result, redactor)
+	promptTemplate, structured := a.promptTemplate(result.Kind)
+	data := a.promptData(result, texts, redactor)
+	if structured {
+		return prompts.AppendContext(fmt.Sprintf(strings.TrimSpace(ai.PromptMap[ai.StructuredPrompt]), a.Language, data.Failures), data.Context), nil
+	}
+	return promptTemplate.Render(data)
+}
+
+// promptData returns the template variables for result, gathering the
+// context of the object.
+func (a *Analysis) promptData(result common.Result, texts []string, redactor *redact.Redactor) prompts.Data {
+	data := prompts.Data{
+		Language:    a.Language,
+		Failures:    strings.Join(texts, " "),
+		FailureList: texts,
+		Kind:        result.Kind,
+		Name:        result.Name,
+		Parent:      result.ParentObject,
+		Integration: integrationOf(result.Kind),
+		Context:     a.explainContext(result, redactor),
+	}
+	if a.Client != nil && a.Client.ServerVersion != nil {
+		data.ClusterVersion = a.Client.ServerVersion.GitVersion
+	}
+	if redactor != nil {
+		data.Name = redactor.Redact(data.Name)
+		data.Parent = redactor.Redact(data.Parent)
+	}
+	return data
+}
+
+// failureTexts returns the failure texts of result, redacted when a
+// redactor is given.
+func failureTexts(result common.Result, redactor *redact.Redactor) []string {
+	var texts []string
+	for _, failure := range result.Error {
+		if redactor != nil {
+			failure.Text = redactor.Redact(failure.Text)
+		}
+		texts = append(texts, failure.Text)
+	}
+	return texts
+}
+
+// integrationOf returns the integration whose analyzer reports results of
+// kind, or an empty string for the core analyzers.
+func integrationOf(kind string) string {
+	name, err := integration.NewIntegration().AnalyzerByIntegration(kind)
+	if err != nil {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,221 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/spf13/viper"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// sarifFingerprint identifies a finding across runs so code-scanning
+	// dashboards can track it as the same alert.
+	sarifFingerprint = "k8sgptResultKey/v1"
+)
+
+type SarifOutput struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []SarifRun `json:"runs"`
+}
+
+type SarifRun struct {
+	Tool        SarifTool         `json:"tool"`
+	Invocations []SarifInvocation `json:"invocations,omitempty"`
+	Results     []SarifResult     `json:"results"`
+}
+
+type SarifTool struct {
+	Driver SarifDriver `json:"driver"`
+}
+
+type SarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SarifRule `json:"rules"`
+}
+
+type SarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription SarifMessage `json:"shortDescription"`
+}
+
+type SarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []SarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type SarifNotification struct {
+	Level   string       `json:"level"`
+	Message SarifMessage `json:"message"`
+}
+
+type SarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	Level               string            `json:"level"`
+	Message             SarifMessage      `json:"message"`
+	Locations           []SarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          map[string]string `json:"properties,omitempty"`
+}
+
+type SarifMessage struct {
+	Text string `json:"text"`
+}
+
+type SarifLocation struct {
+	PhysicalLocation SarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []SarifLogicalLocation `json:"logicalLocations"`
+}
+
+// SarifPhysicalLocation points at the object of a result with a synthetic
+// URI, as code-scanning tools only show results that have one.
+type SarifPhysicalLocation struct {
+	ArtifactLocation SarifArtifactLocation `json:"artifactLocation"`
+}
+
+type SarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+func (a *Analysis) sarifOutput() ([]byte, error) {
+	rules := []SarifRule{}
+	ruleIndex := map[string]bool{}
+	results := []SarifResult{}
+
+	for _, result := range a.Results {
+		if !ruleIndex[result.Kind] {
+			ruleIndex[result.Kind] = true
+			rules = append(rules, SarifRule{
+				ID:               result.Kind,
+				Name:             result.Kind,
+				ShortDescription: SarifMessage{Text: fmt.Sprintf("Problems detected by the %s analyzer", result.Kind)},
+			})
+		}
+
+		texts := make([]string, 0, len(result.Error))
+		for _, failure := range result.Error {
+			texts = append(texts, failure.Text)
+		}
+
+		sarifResult := SarifResult{
+			RuleID:  result.Kind,
+			Level:   sarifLevel(result.Severity),
+			Message: SarifMessage{Text: strings.Join(texts, "\n")},
+			Locations: []SarifLocation{{
+				PhysicalLocation: SarifPhysicalLocation{
+					ArtifactLocation: SarifArtifactLocation{URI: sarifURI(result)},
+				},
+				LogicalLocations: []SarifLogicalLocation{{
+					Name:               result.Name,
+					FullyQualifiedName: resultKey(result),
+					Kind:               result.Kind,
+				}},
+			}},
+			PartialFingerprints: map[string]string{
+				sarifFingerprint: resultKey(result),
+			},
+		}
+		if result.Details != "" || result.Severity != "" {
+			sarifResult.Properties = map[string]string{}
+			if result.Details != "" {
+				sarifResult.Properties["details"] = result.Details
+			}
+			if result.Severity != "" {
+				sarifResult.Properties["severity"] = string(result.Severity)
+			}
+		}
+		results = append(results, sarifResult)
+	}
+
+	invocation := SarifInvocation{ExecutionSuccessful: true}
+	for _, aerror := range a.Errors {
+		invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, SarifNotification{
+			Level:   "warning",
+			Message: SarifMessage{Text: aerror},
+		})
+	}
+
+	output := SarifOutput{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []SarifRun{{
+			Tool: SarifTool{Driver: SarifDriver{
+				Name:           "k8sgpt",
+				Version:        viper.GetString("Version"),
+				InformationURI: "https://k8sgpt.ai",
+				Rules:          rules,
+			}},
+			Invocations: []SarifInvocation{invocation},
+			Results:     results,
+		}},
+	}
+	data, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling sarif: %v", err)
+	}
+	return data, nil
+}
+
+// sarifLevel maps a result severity onto the SARIF result levels.
+func sarifLevel(severity common.Severity) string {
+	switch severity {
+	case common.SeverityCritical, common.SeverityHigh:
+		return "error"
+	case common.SeverityLow, common.SeverityInfo:
+		return "note"
+	default:
+		return "warning"
+	}
+}
+
+// sarifURI returns the synthetic URI k8s/<Kind>/<namespace>/<name> of the
+// object of a result, without the namespace for cluster-scoped objects. The
+// kind is the one of the object, e.g. Pod for Security/Pod results.
+func sarifURI(result common.Result) string {
+	kind := result.Kind
+	if index := strings.LastIndex(kind, "/"); index >= 0 {
+		kind = kind[index+1:]
+	}
+	segments := []string{"k8s", url.PathEscape(kind)}
+	namespace, name := resultObject(result.Name)
+	if namespace != "" {
+		segments = append(segments, url.PathEscape(namespace))
+	}
+	return strings.Join(append(segments, url.PathEscape(name)), "/")
+}
+
+// resultKey identifies a result by its kind, name and parent object.
+func resultKey(result common.Result) string {
+	key := result.Kind + "/" + result.Name
+	if result.ParentObject != "" {
+		key += "/" + result.ParentObject
+	}
+	return key
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"sort"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+)
+
+// analyzerSeverity returns the default severity of the named analyzer.
+func analyzerSeverity(filter string) common.Severity {
+	return analyzer.GetDefaultSeverity(filter)
+}
+
+// withDefaultSeverity assigns the given severity to every failure the
+// analyzer left unclassified and sets the severity of each result.
+func withDefaultSeverity(results []common.Result, severity common.Severity) []common.Result {
+	for i := range results {
+		for j := range results[i].Error {
+			if results[i].Error[j].Severity == "" {
+				results[i].Error[j].Severity = severity
+			}
+		}
+		results[i].Severity = results[i].MaxSeverity()
+	}
+	return results
+}
+
+// applySeverity drops failures below MinSeverity, removes results left
+// without failures and orders the remaining results from the most to the
+// least severe.
+func (a *Analysis) applySeverity() {
+	a.Results = filterBySeverity(a.Results, a.MinSeverity)
+	sort.SliceStable(a.Results, func(i, j int) bool {
+		return a.Results[i].Severity.Rank() > a.Results[j].Severity.Rank()
+	})
+}
+
+// filterBySeverity drops the failures below min and the results left
+// without failures. An empty min keeps every result.
+func filterBySeverity(results []common.Result, min common.Severity) []common.Result {
+	if min == "" {
+		return results
+	}
+	filtered := results[:0]
+	for _, result := range results {
+		var failures []common.Failure
+		for _, failure := range result.Error {
+			if failure.Severity.AtLeast(min) {
+				failures = append(failures, failure)
+			}
+		}
+		if len(failures) == 0 {
+			continue
+		}
+		result.Error = failures
+		result.Severity = result.MaxSeverity()
+		filtered = append(filtered, result)
+	}
+	return filtered
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDefaultSeverity(t *testing.T) {
+	results := withDefaultSeverity([]common.Result{
+		{
+			Kind: "Pod",
+			Name: "default/crashing",
+			Error: []common.Failure{
+				{Text: "unclassified"},
+				{Text: "crash loop", Severity: common.SeverityCritical},
+			},
+		},
+		{
+			Kind:  "ConfigMap",
+			Name:  "default/unused",
+			Error: []common.Failure{{Text: "unclassified"}},
+		},
+	}, common.SeverityLow)
+
+	require.Equal(t, common.SeverityLow, results[0].Error[0].Severity)
+	require.Equal(t, common.SeverityCritical, results[0].Error[1].Severity)
+	require.Equal(t, common.SeverityCritical, results[0].Severity)
+	require.Equal(t, common.SeverityLow, results[1].Severity)
+}
+
+func TestApplySeverity(t *testing.T) {
+	newResults := func() []common.Result {
+		return []common.Result{
+			{
+				Kind:     "ConfigMap",
+				Name:     "default/empty",
+				Error:    []common.Failure{{Text: "empty", Severity: common.SeverityInfo}},
+				Severity: common.SeverityInfo,
+			},
+			{
+				Kind: "Pod",
+				Name: "default/crashing",
+				Error: []common.Failure{
+					{Text: "unready", Severity: common.SeverityMedium},
+					{Text: "crash loop", Severity: common.SeverityCritical},
+				},
+				Severity: common.SeverityCritical,
+			},
+			{
+				Kind:     "Service",
+				Name:     "default/no-endpoints",
+				Error:    []common.Failure{{Text: "no endpoints", Severity: common.SeverityMedium}},
+				Severity: common.SeverityMedium,
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		minSeverity   common.Severity
+		expectedNames []string
+		expectedCount []int
+	}{
+		{
+			name:          "no minimum sorts only",
+			expectedNames: []string{"default/crashing", "default/no-endpoints", "default/empty"},
+			expectedCount: []int{2, 1, 1},
+		},
+		{
+			name:          "medium drops info",
+			minSeverity:   common.SeverityMedium,
+			expectedNames: []string{"default/crashing", "default/no-endpoints"},
+			expectedCount: []int{2, 1},
+		},
+		{
+			name:          "critical keeps only critical failures",
+			minSeverity:   common.SeverityCritical,
+			expectedNames: []string{"default/crashing"},
+			expectedCount: []int{1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &Analysis{Results: newResults(), MinSeverity: tt.minSeverity}
+			a.applySeverity()
+
+			require.Len(t, a.Results, len(tt.expectedNames))
+			for i, result := range a.Results {
+				require.Equal(t, tt.expectedNames[i], result.Name)
+				require.Len(t, result.Error, tt.expectedCount[i])
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	severity, err := common.ParseSeverity(" High ")
+	require.NoError(t, err)
+	require.Equal(t, common.SeverityHigh, severity)
+
+	_, err = common.ParseSeverity("urgent")
+	require.ErrorContains(t, err, "unknown severity")
+}
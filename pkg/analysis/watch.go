@@ -0,0 +1,318 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ignore"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watchDebounce is how long watch mode waits after a change before it
+// re-runs the affected analyzers, so that bursts of updates (e.g. a rollout)
+// trigger a single run.
+var watchDebounce = 2 * time.Second
+
+// watchSyncTimeout is how long watch mode waits for the informers to list
+// their resources, e.g. before giving up on resources that are forbidden or
+// not served.
+var watchSyncTimeout = 30 * time.Second
+
+const (
+	WatchEventNew      = "new"
+	WatchEventResolved = "resolved"
+	WatchEventError    = "error"
+)
+
+// WatchEvent is a single line of the watch mode output.
+type WatchEvent struct {
+	Time     time.Time      `json:"time"`
+	Event    string         `json:"event"`
+	Analyzer string         `json:"analyzer"`
+	Result   *common.Result `json:"result,omitempty"`
+	Error    string         `json:"error,omitempty"`
+}
+
+// Watch runs the selected analyzers once and then keeps shared informers on
+// the resources they read. Whenever one of those objects changes, only the
+// affected analyzers are run again and the findings that appeared or
+// disappeared are written to out, as text or JSON lines. Analyzers whose
+// resources are unknown are re-run every resync interval instead; a zero
+// interval disables that. Unlike Analyze, Watch does not run the custom
+// analyzers, apply the baseline or deduplicate results. It returns when ctx
+// is cancelled.
+func (a *Analysis) Watch(ctx context.Context, out io.Writer, format string, resync time.Duration) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unsupported output format for watch mode: %s. Available format json,text", format)
+	}
+	a.Context = ctx
+
+	analyzers, err := a.selectAnalyzers(viper.GetStringSlice("active_filters"))
+	if err != nil {
+		return err
+	}
+	w := &watcher{
+		analysis:  a,
+		analyzers: analyzers,
+		out:       out,
+		format:    format,
+		previous:  map[string][]common.Result{},
+		dirty:     map[string]bool{},
+		trigger:   make(chan struct{}, 1),
+		informers: map[schema.GroupVersionResource]cache.GenericLister{},
+	}
+	for _, aerror := range append(a.Errors, a.ignoreRuleErrors(time.Now())...) {
+		w.emit(WatchEvent{Time: time.Now(), Event: WatchEventError, Error: aerror})
+	}
+
+	// Informers only observe namespaced resources of the analyzed namespace.
+	// The label selector is not applied because most analyzers also read
+	// related objects that do not carry the selected labels.
+	factory := informers.NewSharedInformerFactoryWithOptions(a.Client.GetClient(), 0, informers.WithNamespace(a.Namespace))
+	dependents := map[schema.GroupVersionResource][]string{}
+	var unwatched []string
+	for name := range analyzers {
+		resources := analyzer.GetAnalyzerResources(name)
+		if len(resources) == 0 {
+			unwatched = append(unwatched, name)
+			continue
+		}
+		for _, gvr := range resources {
+			dependents[gvr] = append(dependents[gvr], name)
+		}
+	}
+
+	var synced atomic.Bool
+	hasSynced := map[schema.GroupVersionResource]cache.InformerSynced{}
+	for gvr, names := range dependents {
+		informer, err := factory.ForResource(gvr)
+		if err != nil {
+			return fmt.Errorf("creating informer for %s: %w", gvr.String(), err)
+		}
+		w.informers[gvr] = informer.Lister()
+		hasSynced[gvr] = informer.Informer().HasSynced
+		names := names
+		_, err = informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(interface{}) {
+				if synced.Load() {
+					w.markDirty(names...)
+				}
+			},
+			UpdateFunc: func(interface{}, interface{}) {
+				if synced.Load() {
+					w.markDirty(names...)
+				}
+			},
+			DeleteFunc: func(interface{}) {
+				if synced.Load() {
+					w.markDirty(names...)
+				}
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("watching %s: %w", gvr.String(), err)
+		}
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+	// Resources that cannot be listed would block forever. Their analyzers
+	// read from the API server and are re-run on the resync interval.
+	syncCtx, cancelSync := context.WithTimeout(ctx, watchSyncTimeout)
+	defer cancelSync()
+	for gvr, informerSynced := range hasSynced {
+		if cache.WaitForCacheSync(syncCtx.Done(), informerSynced) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		delete(w.informers, gvr)
+		for _, name := range dependents[gvr] {
+			if !slices.Contains(unwatched, name) {
+				unwatched = append(unwatched, name)
+			}
+			w.emit(WatchEvent{Time: time.Now(), Event: WatchEventError, Analyzer: name,
+				Error: fmt.Sprintf("cannot watch %s, the analyzer is re-run on the resync interval instead", gvr.GroupResource().String())})
+		}
+	}
+
+	names := make([]string, 0, len(analyzers))
+	for name := range analyzers {
+		names = append(names, name)
+	}
+	// Changes that happen during the initial run trigger another run.
+	synced.Store(true)
+	w.run(names)
+
+	var resyncC <-chan time.Time
+	if resync > 0 && len(unwatched) > 0 {
+		ticker := time.NewTicker(resync)
+		defer ticker.Stop()
+		resyncC = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resyncC:
+			w.markDirty(unwatched...)
+		case <-w.trigger:
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(watchDebounce):
+			}
+			w.run(w.takeDirty())
+		}
+	}
+}
+
+type watcher struct {
+	analysis  *Analysis
+	analyzers map[string]common.IAnalyzer
+	out       io.Writer
+	format    string
+	// previous holds the last findings of every analyzer.
+	previous map[string][]common.Result
+	// informers serve the watched resources to the analyzers from their
+	// synced caches.
+	informers map[schema.GroupVersionResource]cache.GenericLister
+
+	mutex   sync.Mutex
+	dirty   map[string]bool
+	trigger chan struct{}
+}
+
+func (w *watcher) markDirty(names ...string) {
+	w.mutex.Lock()
+	for _, name := range names {
+		w.dirty[name] = true
+	}
+	w.mutex.Unlock()
+
+	select {
+	case w.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (w *watcher) takeDirty() []string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	names := make([]string, 0, len(w.dirty))
+	for name := range w.dirty {
+		names = append(names, name)
+	}
+	w.dirty = map[string]bool{}
+	return names
+}
+
+// run executes the named analyzers and reports how their findings changed
+// since their previous run.
+func (w *watcher) run(names []string) {
+	a := w.analysis
+	analyzerConfig := common.Analyzer{
+		Client:        a.Client,
+		Context:       a.Context,
+		Namespace:     a.Namespace,
+		LabelSelector: a.LabelSelector,
+		AIClient:      a.AIClient,
+		// Every run needs fresh data, so listings and owners are only shared
+		// within it. Watched resources are read from the informer caches.
+		Lister:        common.NewInformerLister(a.Client, a.Namespace, w.informers),
+		Owners:        util.NewOwnerResolver(a.Client),
+		TargetVersion: a.TargetVersion,
+	}
+
+	for _, name := range names {
+		if a.Context.Err() != nil {
+			return
+		}
+		results, err := w.analyzers[name].Analyze(analyzerConfig)
+		if err != nil {
+			w.emit(WatchEvent{Time: time.Now(), Event: WatchEventError, Analyzer: name, Error: err.Error()})
+			continue
+		}
+		results = a.dropAnnotatedResults(analyzerConfig.GetLister(), name, results)
+		results = ignore.Apply(a.IgnoreRules, results, time.Now())
+		results = filterBySeverity(withDefaultSeverity(results, analyzerSeverity(name)), a.MinSeverity)
+
+		diff := DiffResults(w.previous[name], results)
+		now := time.Now()
+		for i := range diff.New {
+			w.emit(WatchEvent{Time: now, Event: WatchEventNew, Analyzer: name, Result: &diff.New[i]})
+		}
+		for i := range diff.Resolved {
+			w.emit(WatchEvent{Time: now, Event: WatchEventResolved, Analyzer: name, Result: &diff.Resolved[i]})
+		}
+		w.previous[name] = results
+	}
+}
+
+func (w *watcher) emit(event WatchEvent) {
+	if w.format == "json" {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		fmt.Fprintln(w.out, string(data))
+		return
+	}
+
+	timestamp := event.Time.Format(time.TimeOnly)
+	switch event.Event {
+	case WatchEventError:
+		message := event.Error
+		if event.Analyzer != "" {
+			message = fmt.Sprintf("[%s] %s", event.Analyzer, event.Error)
+		}
+		fmt.Fprintf(w.out, "%s %s %s\n", timestamp, color.YellowString("WARNING"), color.YellowString(strings.TrimSpace(message)))
+	default:
+		label := color.RedString("NEW")
+		if event.Event == WatchEventResolved {
+			label = color.GreenString("RESOLVED")
+		}
+		texts := make([]string, 0, len(event.Result.Error))
+		for _, failure := range event.Result.Error {
+			texts = append(texts, failure.Text)
+		}
+		prefix := ""
+		if event.Result.Severity != "" {
+			prefix = severityString(event.Result.Severity) + " "
+		}
+		fmt.Fprintf(w.out, "%s %s %s%s %s(%s): %s\n", timestamp, label, prefix,
+			color.HiYellowString(event.Result.Kind),
+			color.YellowString(event.Result.Name),
+			color.CyanString(event.Result.ParentObject),
+			strings.Join(texts, "; "))
+	}
+}
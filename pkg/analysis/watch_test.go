@@ -0,0 +1,167 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// syncBuffer is a bytes.Buffer that can be written by Watch while the test
+// reads it.
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) events(t *testing.T) []WatchEvent {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	var events []WatchEvent
+	for _, line := range strings.Split(strings.TrimSpace(b.buffer.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var event WatchEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		events = append(events, event)
+	}
+	return events
+}
+
+func TestWatch(t *testing.T) {
+	defaultDebounce := watchDebounce
+	watchDebounce = 10 * time.Millisecond
+	t.Cleanup(func() { watchDebounce = defaultDebounce })
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "default"},
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+			Conditions: []v1.PodCondition{
+				{
+					Type:    v1.PodScheduled,
+					Reason:  "Unschedulable",
+					Message: "0/1 nodes are available",
+				},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(pod)
+	a := &Analysis{
+		Context:   context.Background(),
+		Filters:   []string{"Pod"},
+		Client:    &kubernetes.Client{Client: clientset},
+		Namespace: "default",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- a.Watch(ctx, out, "json", 0)
+	}()
+
+	// The initial run reports every existing finding as new.
+	require.Eventually(t, func() bool {
+		return len(out.events(t)) > 0
+	}, 5*time.Second, 20*time.Millisecond)
+	events := out.events(t)
+	require.Equal(t, WatchEventNew, events[0].Event)
+	require.Equal(t, "Pod", events[0].Analyzer)
+	require.Equal(t, "default/example", events[0].Result.Name)
+
+	require.NoError(t, clientset.CoreV1().Pods("default").Delete(ctx, pod.Name, metav1.DeleteOptions{}))
+	require.Eventually(t, func() bool {
+		events := out.events(t)
+		return events[len(events)-1].Event == WatchEventResolved
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatchUnsupportedFormat(t *testing.T) {
+	a := &Analysis{Context: context.Background()}
+	require.Error(t, a.Watch(context.Background(), &syncBuffer{}, "sarif", 0))
+}
+
+func TestWatchForbiddenResource(t *testing.T) {
+	defaultTimeout := watchSyncTimeout
+	watchSyncTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { watchSyncTimeout = defaultTimeout })
+
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(v1.Resource("pods"), "", errors.New("not allowed"))
+	})
+	a := &Analysis{
+		Context:   context.Background(),
+		Filters:   []string{"Pod"},
+		Client:    &kubernetes.Client{Client: clientset},
+		Namespace: "default",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &syncBuffer{}
+	done := make(chan error)
+	go func() {
+		done <- a.Watch(ctx, out, "json", 0)
+	}()
+
+	// Watch gives up on the informer and still runs the analyzer.
+	require.Eventually(t, func() bool {
+		return len(out.events(t)) >= 2
+	}, 5*time.Second, 20*time.Millisecond)
+	events := out.events(t)
+	require.Equal(t, WatchEventError, events[0].Event)
+	require.Equal(t, "Pod", events[0].Analyzer)
+	require.Contains(t, events[0].Error, "cannot watch pods")
+	require.Equal(t, WatchEventError, events[1].Event)
+	require.Contains(t, events[1].Error, "forbidden")
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestWatchEmitText(t *testing.T) {
+	out := &syncBuffer{}
+	w := &watcher{out: out, format: "text"}
+	w.emit(WatchEvent{Event: WatchEventError, Error: "ignore rule expired"})
+	w.emit(WatchEvent{Event: WatchEventError, Analyzer: "Pod", Error: "forbidden"})
+	require.NotContains(t, out.buffer.String(), "[]")
+	require.Contains(t, out.buffer.String(), "WARNING ignore rule expired")
+	require.Contains(t, out.buffer.String(), "WARNING [Pod] forbidden")
+}
@@ -14,14 +14,22 @@ limitations under the License.
 package analyzer
 
 import (
-	"fmt"
-	"os"
+	"strings"
 
-	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/integration"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 var (
@@ -65,9 +73,178 @@ var additionalAnalyzerMap = map[string]common.IAnalyzer{
 	"InstallPlan":             InstallPlanAnalyzer{},
 	"CatalogSource":           CatalogSourceAnalyzer{},
 	"OperatorGroup":           OperatorGroupAnalyzer{},
+	"ResourceQuota":           ResourceQuotaAnalyzer{},
+	"Workload":                WorkloadAnalyzer{},
+	"RBAC":                    RBACAnalyzer{},
+	"Deprecation":             DeprecationAnalyzer{},
 }
 
-func ListFilters() ([]string, []string, []string) {
+// analyzerSeverityMap holds the severity assigned to failures whose analyzer
+// did not classify them explicitly.
+var analyzerSeverityMap = map[string]common.Severity{
+	"Pod":                            common.SeverityHigh,
+	"DaemonSet":                      common.SeverityHigh,
+	"Deployment":                     common.SeverityHigh,
+	"ReplicaSet":                     common.SeverityHigh,
+	"PersistentVolumeClaim":          common.SeverityHigh,
+	"Service":                        common.SeverityMedium,
+	"Ingress":                        common.SeverityMedium,
+	"StatefulSet":                    common.SeverityHigh,
+	"Job":                            common.SeverityMedium,
+	"CronJob":                        common.SeverityMedium,
+	"Node":                           common.SeverityHigh,
+	"ValidatingWebhookConfiguration": common.SeverityMedium,
+	"MutatingWebhookConfiguration":   common.SeverityMedium,
+	"ConfigMap":                      common.SeverityLow,
+	"HorizontalPodAutoscaler":        common.SeverityMedium,
+	"PodDisruptionBudget":            common.SeverityMedium,
+	"NetworkPolicy":                  common.SeverityLow,
+	"Log":                            common.SeverityMedium,
+	"GatewayClass":                   common.SeverityMedium,
+	"Gateway":                        common.SeverityMedium,
+	"HTTPRoute":                      common.SeverityMedium,
+	"Storage":                        common.SeverityMedium,
+	"Security":                       common.SeverityMedium,
+	"ClusterCatalog":                 common.SeverityMedium,
+	"ClusterExtension":               common.SeverityMedium,
+	"ClusterServiceVersion":          common.SeverityMedium,
+	"Subscription":                   common.SeverityMedium,
+	"InstallPlan":                    common.SeverityMedium,
+	"CatalogSource":                  common.SeverityMedium,
+	"OperatorGroup":                  common.SeverityMedium,
+	"ResourceQuota":                  common.SeverityHigh,
+	"Workload":                       common.SeverityLow,
+	"RBAC":                           common.SeverityMedium,
+	"Deprecation":                    common.SeverityMedium,
+}
+
+// GetDefaultSeverity returns the severity used for unclassified failures of
+// the named analyzer. Integrations and unknown analyzers default to medium.
+func GetDefaultSeverity(name string) common.Severity {
+	if severity, ok := analyzerSeverityMap[name]; ok {
+		return severity
+	}
+	return common.SeverityMedium
+}
+
+var (
+	pods                   = corev1.SchemeGroupVersion.WithResource("pods")
+	services               = corev1.SchemeGroupVersion.WithResource("services")
+	endpoints              = corev1.SchemeGroupVersion.WithResource("endpoints")
+	configMaps             = corev1.SchemeGroupVersion.WithResource("configmaps")
+	nodes                  = corev1.SchemeGroupVersion.WithResource("nodes")
+	namespaces             = corev1.SchemeGroupVersion.WithResource("namespaces")
+	serviceAccounts        = corev1.SchemeGroupVersion.WithResource("serviceaccounts")
+	persistentVolumes      = corev1.SchemeGroupVersion.WithResource("persistentvolumes")
+	persistentVolumeClaims = corev1.SchemeGroupVersion.WithResource("persistentvolumeclaims")
+	replicationControllers = corev1.SchemeGroupVersion.WithResource("replicationcontrollers")
+	resourceQuotas         = corev1.SchemeGroupVersion.WithResource("resourcequotas")
+	limitRanges            = corev1.SchemeGroupVersion.WithResource("limitranges")
+	deployments            = appsv1.SchemeGroupVersion.WithResource("deployments")
+	replicaSets            = appsv1.SchemeGroupVersion.WithResource("replicasets")
+	statefulSets           = appsv1.SchemeGroupVersion.WithResource("statefulsets")
+	daemonSets             = appsv1.SchemeGroupVersion.WithResource("daemonsets")
+	jobs                   = batchv1.SchemeGroupVersion.WithResource("jobs")
+	cronJobs               = batchv1.SchemeGroupVersion.WithResource("cronjobs")
+	ingresses              = networkingv1.SchemeGroupVersion.WithResource("ingresses")
+	ingressClasses         = networkingv1.SchemeGroupVersion.WithResource("ingressclasses")
+	networkPolicies        = networkingv1.SchemeGroupVersion.WithResource("networkpolicies")
+	storageClasses         = storagev1.SchemeGroupVersion.WithResource("storageclasses")
+	podDisruptionBudgets   = policyv1.SchemeGroupVersion.WithResource("poddisruptionbudgets")
+	hpas                   = autoscalingv2.SchemeGroupVersion.WithResource("horizontalpodautoscalers")
+	roles                  = rbacv1.SchemeGroupVersion.WithResource("roles")
+	roleBindings           = rbacv1.SchemeGroupVersion.WithResource("rolebindings")
+	clusterRoles           = rbacv1.SchemeGroupVersion.WithResource("clusterroles")
+	clusterRoleBindings    = rbacv1.SchemeGroupVersion.WithResource("clusterrolebindings")
+	validatingWebhooks     = admissionregistrationv1.SchemeGroupVersion.WithResource("validatingwebhookconfigurations")
+	mutatingWebhooks       = admissionregistrationv1.SchemeGroupVersion.WithResource("mutatingwebhookconfigurations")
+)
+
+// analyzerResourceMap lists the resources each built-in analyzer reads, so
+// that watch mode knows which analyzers to re-run when an object changes.
+// Events are left out on purpose: they change far more often than the
+// objects they describe, which always change alongside them. Secrets are
+// left out as well so that watch mode does not cache their contents; a
+// missing secret that is created later is noticed with the next change of
+// the DaemonSet or Ingress. Log is not listed because log lines change
+// without any object changing; it is re-run every resync interval instead.
+var analyzerResourceMap = map[string][]schema.GroupVersionResource{
+	"Pod":                            {pods},
+	"DaemonSet":                      {daemonSets},
+	"Deployment":                     {deployments},
+	"ReplicaSet":                     {replicaSets},
+	"PersistentVolumeClaim":          {persistentVolumeClaims},
+	"Service":                        {services, endpoints},
+	"Ingress":                        {ingresses, ingressClasses, services},
+	"StatefulSet":                    {statefulSets, services, storageClasses, pods},
+	"Job":                            {jobs},
+	"CronJob":                        {cronJobs},
+	"Node":                           {nodes},
+	"ValidatingWebhookConfiguration": {validatingWebhooks, services, pods},
+	"MutatingWebhookConfiguration":   {mutatingWebhooks, services, pods},
+	"ConfigMap":                      {configMaps, pods},
+	"HorizontalPodAutoscaler":        {hpas, deployments, replicationControllers, replicaSets, statefulSets},
+	"PodDisruptionBudget":            {podDisruptionBudgets},
+	"NetworkPolicy":                  {networkPolicies, pods},
+	"Storage":                        {storageClasses, persistentVolumes, persistentVolumeClaims},
+	"Security":                       {serviceAccounts, roleBindings, roles, pods, namespaces, deployments, statefulSets, daemonSets, jobs, cronJobs},
+	"ResourceQuota":                  {resourceQuotas, limitRanges, replicaSets, deployments, statefulSets, daemonSets, jobs, cronJobs},
+	"Workload":                       {deployments, statefulSets, daemonSets, jobs, cronJobs},
+	"RBAC":                           {roles, roleBindings, clusterRoles, clusterRoleBindings, serviceAccounts},
+}
+
+// kindResourceMap resolves the kind of a result to the resource of the
+// object it describes.
+var kindResourceMap = map[string]schema.GroupVersionResource{
+	"Pod":                            pods,
+	"Log":                            pods,
+	"Service":                        services,
+	"ConfigMap":                      configMaps,
+	"Node":                           nodes,
+	"ServiceAccount":                 serviceAccounts,
+	"PersistentVolume":               persistentVolumes,
+	"PersistentVolumeClaim":          persistentVolumeClaims,
+	"Deployment":                     deployments,
+	"ReplicaSet":                     replicaSets,
+	"StatefulSet":                    statefulSets,
+	"DaemonSet":                      daemonSets,
+	"Job":                            jobs,
+	"CronJob":                        cronJobs,
+	"Ingress":                        ingresses,
+	"NetworkPolicy":                  networkPolicies,
+	"StorageClass":                   storageClasses,
+	"PodDisruptionBudget":            podDisruptionBudgets,
+	"HorizontalPodAutoscaler":        hpas,
+	"Role":                           roles,
+	"RoleBinding":                    roleBindings,
+	"ClusterRole":                    clusterRoles,
+	"ClusterRoleBinding":             clusterRoleBindings,
+	"ValidatingWebhookConfiguration": validatingWebhooks,
+	"MutatingWebhookConfiguration":   mutatingWebhooks,
+	"ResourceQuota":                  resourceQuotas,
+	"LimitRange":                     limitRanges,
+}
+
+// GetResultResource returns the resource of the object described by a
+// result of the given kind. Kinds such as "Security/Pod" resolve to their
+// object kind.
+func GetResultResource(kind string) (schema.GroupVersionResource, bool) {
+	if _, object, found := strings.Cut(kind, "/"); found {
+		kind = object
+	}
+	gvr, ok := kindResourceMap[kind]
+	return gvr, ok
+}
+
+// GetAnalyzerResources returns the resources read by the named analyzer, or
+// nil if they are unknown, e.g. for integrations and CRD based analyzers.
+func GetAnalyzerResources(name string) []schema.GroupVersionResource {
+	return analyzerResourceMap[name]
+}
+
+// ListFilters returns the names of the core, additional and integration
+// analyzers. It fails when the declarative rules cannot be loaded.
+func ListFilters() ([]string, []string, []string, error) {
 	coreKeys := make([]string, 0, len(coreAnalyzerMap))
 	for k := range coreAnalyzerMap {
 		coreKeys = append(coreKeys, k)
@@ -78,6 +255,15 @@ func ListFilters() ([]string, []string, []string) {
 		additionalKeys = append(additionalKeys, k)
 	}
 
+	// declarative rules are selected like additional analyzers
+	rules, err := LoadRuleAnalyzers()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, rule := range rules {
+		additionalKeys = append(additionalKeys, rule.Name())
+	}
+
 	integrationProvider := integration.NewIntegration()
 	var integrationAnalyzers []string
 
@@ -86,18 +272,28 @@ func ListFilters() ([]string, []string, []string) {
 		if b {
 			in, err := integrationProvider.Get(i)
 			if err != nil {
-				fmt.Println(color.RedString(err.Error()))
-				os.Exit(1)
+				return nil, nil, nil, err
 			}
 			integrationAnalyzers = append(integrationAnalyzers, in.GetAnalyzerName()...)
 		}
 	}
 
-	return coreKeys, additionalKeys, integrationAnalyzers
+	return coreKeys, additionalKeys, integrationAnalyzers, nil
 }
 
-func GetAnalyzerMap() (map[string]common.IAnalyzer, map[string]common.IAnalyzer) {
+// GetAnalyzerMap returns the core analyzers and all analyzers by name. It
+// fails when the declarative rules cannot be loaded.
+func GetAnalyzerMap() (map[string]common.IAnalyzer, map[string]common.IAnalyzer, error) {
+	rules, err := LoadRuleAnalyzers()
+	if err != nil {
+		return nil, nil, err
+	}
+	return GetAnalyzerMapWithRules(rules)
+}
 
+// GetAnalyzerMapWithRules is GetAnalyzerMap with rules loaded already by
+// LoadRuleAnalyzers.
+func GetAnalyzerMapWithRules(rules []*RuleAnalyzer) (map[string]common.IAnalyzer, map[string]common.IAnalyzer, error) {
 	coreAnalyzer := make(map[string]common.IAnalyzer)
 	mergedAnalyzerMap := make(map[string]common.IAnalyzer)
 
@@ -112,23 +308,26 @@ func GetAnalyzerMap() (map[string]common.IAnalyzer, map[string]common.IAnalyzer)
 		mergedAnalyzerMap[key] = value
 	}
 
+	// add declarative rules
+	for _, rule := range rules {
+		mergedAnalyzerMap[rule.Name()] = rule
+	}
+
 	integrationProvider := integration.NewIntegration()
 
 	for _, i := range integrationProvider.List() {
 		b, err := integrationProvider.IsActivate(i)
 		if err != nil {
-			fmt.Println(color.RedString(err.Error()))
-			os.Exit(1)
+			return nil, nil, err
 		}
 		if b {
 			in, err := integrationProvider.Get(i)
 			if err != nil {
-				fmt.Println(color.RedString(err.Error()))
-				os.Exit(1)
+				return nil, nil, err
 			}
 			in.AddAnalyzer(&mergedAnalyzerMap)
 		}
 	}
 
-	return coreAnalyzer, mergedAnalyzerMap
+	return coreAnalyzer, mergedAnalyzerMap, nil
 }
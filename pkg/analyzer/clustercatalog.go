@@ -82,10 +82,7 @@ func (ClusterCatalogAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.Catalog.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Catalog.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -81,10 +81,7 @@ func (ClusterExtensionAnalyzer) Analyze(a common.Analyzer) ([]common.Result, err
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.Extension.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Extension.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -39,7 +39,7 @@ func (ConfigMapAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	}
 
 	// Get all Pods to check ConfigMap usage
-	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
+	pods, err := a.GetLister().Pods(a.Context, a.Namespace, "")
 	if err != nil {
 		return nil, err
 	}
@@ -51,7 +51,7 @@ func (ConfigMapAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	configMapUsage := make(map[string][]string) // maps ConfigMap name to list of pods using it
 
 	// Analyze ConfigMap usage in Pods
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		// Check volume mounts, including projected ConfigMap sources.
 		for _, volume := range pod.Spec.Volumes {
 			if volume.ConfigMap != nil {
@@ -122,6 +122,7 @@ func (ConfigMapAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			failures = append(failures, common.Failure{
 				Text:      fmt.Sprintf("ConfigMap %s is not used by any pods in the namespace", cm.Name),
 				Sensitive: []common.Sensitive{},
+				Severity:  common.SeverityLow,
 			})
 		}
 
@@ -130,6 +131,7 @@ func (ConfigMapAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			failures = append(failures, common.Failure{
 				Text:      fmt.Sprintf("ConfigMap %s is empty", cm.Name),
 				Sensitive: []common.Sensitive{},
+				Severity:  common.SeverityInfo,
 			})
 		}
 
@@ -145,6 +147,7 @@ func (ConfigMapAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			failures = append(failures, common.Failure{
 				Text:      fmt.Sprintf("ConfigMap %s is larger than 1MB (%d bytes)", cm.Name, totalSize),
 				Sensitive: []common.Sensitive{},
+				Severity:  common.SeverityMedium,
 			})
 		}
 
@@ -93,10 +93,7 @@ func (DaemonSetAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.DaemonSet.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.DaemonSet.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
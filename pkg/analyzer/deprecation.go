@@ -0,0 +1,377 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// KubernetesVersion is a minor release of Kubernetes.
+type KubernetesVersion struct {
+	Major int
+	Minor int
+}
+
+// ParseKubernetesVersion parses versions such as "1.32", "v1.32" or
+// "v1.32.3-eks-1234"; the patch release is ignored.
+func ParseKubernetesVersion(value string) (KubernetesVersion, error) {
+	parts := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(value), "v"), ".", 3)
+	if len(parts) < 2 {
+		return KubernetesVersion{}, fmt.Errorf("invalid Kubernetes version %q, expected a version such as 1.32", value)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return KubernetesVersion{}, fmt.Errorf("invalid Kubernetes version %q, expected a version such as 1.32", value)
+	}
+	// Managed offerings report minor versions such as "32+".
+	minor, err := strconv.Atoi(strings.TrimRight(parts[1], "+"))
+	if err != nil {
+		return KubernetesVersion{}, fmt.Errorf("invalid Kubernetes version %q, expected a version such as 1.32", value)
+	}
+	return KubernetesVersion{Major: major, Minor: minor}, nil
+}
+
+func (v KubernetesVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// AtLeast reports whether v is other or a later release.
+func (v KubernetesVersion) AtLeast(other KubernetesVersion) bool {
+	return v.Major > other.Major || (v.Major == other.Major && v.Minor >= other.Minor)
+}
+
+// apiDeprecation is a group/version of a kind that is deprecated, and
+// removed from the API server in a later release.
+type apiDeprecation struct {
+	groupVersion string
+	kind         string
+	resource     string
+	deprecatedIn KubernetesVersion
+	removedIn    KubernetesVersion
+	// replacement is the group/version to migrate to, empty when the API is
+	// removed without one.
+	replacement string
+}
+
+func v1dot(minor int) KubernetesVersion {
+	return KubernetesVersion{Major: 1, Minor: minor}
+}
+
+// apiDeprecations is the built-in deprecation table, after
+// https://kubernetes.io/docs/reference/using-api/deprecation-guide/.
+var apiDeprecations = []apiDeprecation{
+	{"extensions/v1beta1", "Deployment", "deployments", v1dot(9), v1dot(16), "apps/v1"},
+	{"apps/v1beta1", "Deployment", "deployments", v1dot(9), v1dot(16), "apps/v1"},
+	{"apps/v1beta2", "Deployment", "deployments", v1dot(9), v1dot(16), "apps/v1"},
+	{"extensions/v1beta1", "DaemonSet", "daemonsets", v1dot(9), v1dot(16), "apps/v1"},
+	{"apps/v1beta2", "DaemonSet", "daemonsets", v1dot(9), v1dot(16), "apps/v1"},
+	{"extensions/v1beta1", "ReplicaSet", "replicasets", v1dot(9), v1dot(16), "apps/v1"},
+	{"apps/v1beta2", "ReplicaSet", "replicasets", v1dot(9), v1dot(16), "apps/v1"},
+	{"apps/v1beta1", "StatefulSet", "statefulsets", v1dot(9), v1dot(16), "apps/v1"},
+	{"apps/v1beta2", "StatefulSet", "statefulsets", v1dot(9), v1dot(16), "apps/v1"},
+	{"extensions/v1beta1", "NetworkPolicy", "networkpolicies", v1dot(9), v1dot(16), "networking.k8s.io/v1"},
+	{"extensions/v1beta1", "Ingress", "ingresses", v1dot(14), v1dot(22), "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "Ingress", "ingresses", v1dot(19), v1dot(22), "networking.k8s.io/v1"},
+	{"networking.k8s.io/v1beta1", "IngressClass", "ingressclasses", v1dot(19), v1dot(22), "networking.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "MutatingWebhookConfiguration", "mutatingwebhookconfigurations", v1dot(16), v1dot(22), "admissionregistration.k8s.io/v1"},
+	{"admissionregistration.k8s.io/v1beta1", "ValidatingWebhookConfiguration", "validatingwebhookconfigurations", v1dot(16), v1dot(22), "admissionregistration.k8s.io/v1"},
+	{"apiextensions.k8s.io/v1beta1", "CustomResourceDefinition", "customresourcedefinitions", v1dot(16), v1dot(22), "apiextensions.k8s.io/v1"},
+	{"apiregistration.k8s.io/v1beta1", "APIService", "apiservices", v1dot(19), v1dot(22), "apiregistration.k8s.io/v1"},
+	{"certificates.k8s.io/v1beta1", "CertificateSigningRequest", "certificatesigningrequests", v1dot(19), v1dot(22), "certificates.k8s.io/v1"},
+	{"coordination.k8s.io/v1beta1", "Lease", "leases", v1dot(19), v1dot(22), "coordination.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRole", "clusterroles", v1dot(17), v1dot(22), "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "ClusterRoleBinding", "clusterrolebindings", v1dot(17), v1dot(22), "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "Role", "roles", v1dot(17), v1dot(22), "rbac.authorization.k8s.io/v1"},
+	{"rbac.authorization.k8s.io/v1beta1", "RoleBinding", "rolebindings", v1dot(17), v1dot(22), "rbac.authorization.k8s.io/v1"},
+	{"scheduling.k8s.io/v1beta1", "PriorityClass", "priorityclasses", v1dot(14), v1dot(22), "scheduling.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIDriver", "csidrivers", v1dot(19), v1dot(22), "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSINode", "csinodes", v1dot(17), v1dot(22), "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "StorageClass", "storageclasses", v1dot(19), v1dot(22), "storage.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "VolumeAttachment", "volumeattachments", v1dot(19), v1dot(22), "storage.k8s.io/v1"},
+	{"batch/v1beta1", "CronJob", "cronjobs", v1dot(21), v1dot(25), "batch/v1"},
+	{"discovery.k8s.io/v1beta1", "EndpointSlice", "endpointslices", v1dot(21), v1dot(25), "discovery.k8s.io/v1"},
+	{"autoscaling/v2beta1", "HorizontalPodAutoscaler", "horizontalpodautoscalers", v1dot(22), v1dot(25), "autoscaling/v2"},
+	{"policy/v1beta1", "PodDisruptionBudget", "poddisruptionbudgets", v1dot(21), v1dot(25), "policy/v1"},
+	{"policy/v1beta1", "PodSecurityPolicy", "podsecuritypolicies", v1dot(21), v1dot(25), ""},
+	{"node.k8s.io/v1beta1", "RuntimeClass", "runtimeclasses", v1dot(20), v1dot(25), "node.k8s.io/v1"},
+	{"autoscaling/v2beta2", "HorizontalPodAutoscaler", "horizontalpodautoscalers", v1dot(23), v1dot(26), "autoscaling/v2"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "FlowSchema", "flowschemas", v1dot(23), v1dot(26), "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta1", "PriorityLevelConfiguration", "prioritylevelconfigurations", v1dot(23), v1dot(26), "flowcontrol.apiserver.k8s.io/v1"},
+	{"storage.k8s.io/v1beta1", "CSIStorageCapacity", "csistoragecapacities", v1dot(24), v1dot(27), "storage.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "FlowSchema", "flowschemas", v1dot(26), v1dot(29), "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta2", "PriorityLevelConfiguration", "prioritylevelconfigurations", v1dot(26), v1dot(29), "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "flowschemas", v1dot(29), v1dot(32), "flowcontrol.apiserver.k8s.io/v1"},
+	{"flowcontrol.apiserver.k8s.io/v1beta3", "PriorityLevelConfiguration", "prioritylevelconfigurations", v1dot(29), v1dot(32), "flowcontrol.apiserver.k8s.io/v1"},
+}
+
+// lastAppliedAnnotation holds the manifest last applied with kubectl apply.
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DeprecationAnalyzer finds objects authored against deprecated or removed
+// group/versions, from their last applied manifest and their managed
+// fields, as an upgrade readiness report for the target version.
+type DeprecationAnalyzer struct{}
+
+func (DeprecationAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+	kind := "Deprecation"
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	if a.Client.GetDynamicClient() == nil {
+		return nil, fmt.Errorf("dynamic client is nil in %s analyzer", kind)
+	}
+
+	server, target, err := deprecationVersions(a)
+	if err != nil {
+		return nil, err
+	}
+
+	// Deprecations by kind, in the order of the table
+	var kinds []string
+	byKind := map[string][]apiDeprecation{}
+	for _, deprecation := range apiDeprecations {
+		if !target.AtLeast(deprecation.deprecatedIn) {
+			continue
+		}
+		if _, ok := byKind[deprecation.kind]; !ok {
+			kinds = append(kinds, deprecation.kind)
+		}
+		byKind[deprecation.kind] = append(byKind[deprecation.kind], deprecation)
+	}
+
+	// A kind that cannot be checked is reported alongside the results of the
+	// others, rather than discarding the whole report.
+	var results []common.Result
+	var errs []error
+	discovery := servedResources{a: a, groupVersions: map[string]*metav1.APIResourceList{}}
+	served := 0
+	for _, objectKind := range kinds {
+		deprecations := byKind[objectKind]
+		gvr, namespaced, ok, err := discovery.find(deprecations)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("discovering %s: %w", objectKind, err))
+			continue
+		}
+		if !ok {
+			// The kind is not served, e.g. it was removed or its CRD is not
+			// installed, so there is nothing to check.
+			continue
+		}
+		served++
+		if a.Namespace != "" && !namespaced {
+			continue
+		}
+		resource := a.Client.GetDynamicClient().Resource(gvr)
+		options := metav1.ListOptions{LabelSelector: a.LabelSelector}
+		var items []metav1.Object
+		if namespaced {
+			objects, err := resource.Namespace(a.Namespace).List(a.Context, options)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("listing %s: %w", objectKind, err))
+				continue
+			}
+			for i := range objects.Items {
+				items = append(items, &objects.Items[i])
+			}
+		} else {
+			objects, err := resource.List(a.Context, options)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("listing %s: %w", objectKind, err))
+				continue
+			}
+			for i := range objects.Items {
+				items = append(items, &objects.Items[i])
+			}
+		}
+
+		resultKind := kind + "/" + objectKind
+		for _, item := range items {
+			var failures []common.Failure
+			for _, usage := range authoredVersions(item) {
+				for _, deprecation := range deprecations {
+					if deprecation.groupVersion == usage.groupVersion {
+						failures = append(failures, deprecationFailure(objectKind, item.GetName(), usage, deprecation, server, target))
+					}
+				}
+			}
+			if len(failures) == 0 {
+				continue
+			}
+			name := item.GetName()
+			if item.GetNamespace() != "" {
+				name = item.GetNamespace() + "/" + name
+			}
+			results = append(results, common.Result{
+				Kind:  resultKind,
+				Name:  name,
+				Error: failures,
+			})
+			AnalyzerErrorsMetric.WithLabelValues(resultKind, item.GetName(), item.GetNamespace()).Set(float64(len(failures)))
+		}
+	}
+	// An empty report must not hide that nothing could be checked.
+	if served == 0 && len(kinds) > 0 {
+		errs = append(errs, fmt.Errorf("discovery unavailable: none of the %d kinds with deprecated API versions is served, nothing was checked", len(kinds)))
+		return nil, errors.Join(errs...)
+	}
+	return results, errors.Join(errs...)
+}
+
+// deprecationVersions returns the version of the server, if known, and the
+// version to check readiness for: the target version of the analysis, or
+// else the minor release after the server.
+func deprecationVersions(a common.Analyzer) (*KubernetesVersion, KubernetesVersion, error) {
+	var server *KubernetesVersion
+	if info := a.Client.ServerVersion; info != nil {
+		if version, err := ParseKubernetesVersion(info.Major + "." + info.Minor); err == nil {
+			server = &version
+		}
+	}
+	if a.TargetVersion != "" {
+		target, err := ParseKubernetesVersion(a.TargetVersion)
+		return server, target, err
+	}
+	if server == nil {
+		return nil, KubernetesVersion{}, fmt.Errorf("unknown server version, set the target version with --target-version")
+	}
+	return server, KubernetesVersion{Major: server.Major, Minor: server.Minor + 1}, nil
+}
+
+// servedResources looks up the resources the server serves with discovery.
+type servedResources struct {
+	a             common.Analyzer
+	groupVersions map[string]*metav1.APIResourceList // nil when not served
+}
+
+// find returns the resource to list the objects of a kind with: the
+// replacement group/version, or a deprecated one the server still serves.
+// Group/versions the server does not serve are skipped, other discovery
+// errors are returned.
+func (s *servedResources) find(deprecations []apiDeprecation) (schema.GroupVersionResource, bool, bool, error) {
+	var candidates []string
+	for _, deprecation := range deprecations {
+		if deprecation.replacement != "" {
+			candidates = append(candidates, deprecation.replacement)
+		}
+	}
+	for _, deprecation := range deprecations {
+		candidates = append(candidates, deprecation.groupVersion)
+	}
+
+	resourceName := deprecations[0].resource
+	for _, groupVersion := range candidates {
+		list, ok := s.groupVersions[groupVersion]
+		if !ok {
+			var err error
+			list, err = s.a.Client.GetClient().Discovery().ServerResourcesForGroupVersion(groupVersion)
+			if err != nil && !apierrors.IsNotFound(err) {
+				return schema.GroupVersionResource{}, false, false, fmt.Errorf("discovering %s: %w", groupVersion, err)
+			}
+			s.groupVersions[groupVersion] = list
+		}
+		if list == nil {
+			continue
+		}
+		for _, resource := range list.APIResources {
+			if resource.Name == resourceName {
+				gv, err := schema.ParseGroupVersion(groupVersion)
+				if err != nil {
+					continue
+				}
+				return gv.WithResource(resourceName), resource.Namespaced, true, nil
+			}
+		}
+	}
+	return schema.GroupVersionResource{}, false, false, nil
+}
+
+// versionUsage is a group/version an object was written with.
+type versionUsage struct {
+	groupVersion string
+	sources      []string
+}
+
+// authoredVersions returns the group/versions of the last applied
+// manifest and the managed fields of object.
+func authoredVersions(object metav1.Object) []versionUsage {
+	var usages []versionUsage
+	add := func(groupVersion, source string) {
+		for i := range usages {
+			if usages[i].groupVersion == groupVersion {
+				usages[i].sources = append(usages[i].sources, source)
+				return
+			}
+		}
+		usages = append(usages, versionUsage{groupVersion: groupVersion, sources: []string{source}})
+	}
+
+	if lastApplied, ok := object.GetAnnotations()[lastAppliedAnnotation]; ok {
+		var manifest struct {
+			APIVersion string `json:"apiVersion"`
+		}
+		if err := json.Unmarshal([]byte(lastApplied), &manifest); err == nil && manifest.APIVersion != "" {
+			add(manifest.APIVersion, "the last applied configuration")
+		}
+	}
+	managers := map[string][]string{}
+	for _, entry := range object.GetManagedFields() {
+		if entry.APIVersion != "" && entry.Manager != "" {
+			managers[entry.APIVersion] = append(managers[entry.APIVersion], entry.Manager)
+		}
+	}
+	groupVersions := make([]string, 0, len(managers))
+	for groupVersion := range managers {
+		groupVersions = append(groupVersions, groupVersion)
+	}
+	sort.Strings(groupVersions)
+	for _, groupVersion := range groupVersions {
+		add(groupVersion, "the fields managed by "+strings.Join(managers[groupVersion], ", "))
+	}
+	return usages
+}
+
+func deprecationFailure(kind, name string, usage versionUsage, deprecation apiDeprecation, server *KubernetesVersion, target KubernetesVersion) common.Failure {
+	migrate := "migrate to " + deprecation.replacement
+	if deprecation.replacement == "" {
+		migrate = "it has no replacement"
+	}
+	text := fmt.Sprintf("%s %s is written as %s in %s, which is deprecated since %s and removed in %s; %s",
+		kind, name, usage.groupVersion, strings.Join(usage.sources, " and "), deprecation.deprecatedIn, deprecation.removedIn, migrate)
+	severity := common.SeverityLow
+	switch {
+	case server != nil && server.AtLeast(deprecation.removedIn):
+		text += fmt.Sprintf(". The server runs %s, so re-applying the manifest fails", server)
+		severity = common.SeverityHigh
+	case target.AtLeast(deprecation.removedIn):
+		text += fmt.Sprintf(". Manifests using it fail on %s", target)
+		severity = common.SeverityHigh
+	}
+	return common.Failure{
+		Text:      text,
+		Sensitive: []common.Sensitive{},
+		Severity:  severity,
+	}
+}
@@ -0,0 +1,229 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestParseKubernetesVersion(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected KubernetesVersion
+		err      bool
+	}{
+		{value: "1.32", expected: KubernetesVersion{Major: 1, Minor: 32}},
+		{value: "v1.29.4-eks-036c24b", expected: KubernetesVersion{Major: 1, Minor: 29}},
+		{value: "1.28+", expected: KubernetesVersion{Major: 1, Minor: 28}},
+		{value: "1", err: true},
+		{value: "latest", err: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseKubernetesVersion(tt.value)
+			if tt.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func deprecatedObject(apiVersion, kind, namespace, name string, lastApplied string, managed ...metav1.ManagedFieldsEntry) *unstructured.Unstructured {
+	object := &unstructured.Unstructured{}
+	object.SetAPIVersion(apiVersion)
+	object.SetKind(kind)
+	object.SetNamespace(namespace)
+	object.SetName(name)
+	if lastApplied != "" {
+		object.SetAnnotations(map[string]string{lastAppliedAnnotation: lastApplied})
+	}
+	object.SetManagedFields(managed)
+	return object
+}
+
+func deprecationClient(serverVersion *version.Info) *kubernetes.Client {
+	clientset := fake.NewSimpleClientset()
+	clientset.Resources = []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{{Name: "deployments", Namespaced: true, Kind: "Deployment"}}},
+		{GroupVersion: "networking.k8s.io/v1", APIResources: []metav1.APIResource{{Name: "ingresses", Namespaced: true, Kind: "Ingress"}}},
+		// The server predates flowcontrol.apiserver.k8s.io/v1.
+		{GroupVersion: "flowcontrol.apiserver.k8s.io/v1beta3", APIResources: []metav1.APIResource{{Name: "flowschemas", Kind: "FlowSchema"}}},
+	}
+
+	listKinds := map[schema.GroupVersionResource]string{
+		{Group: "apps", Version: "v1", Resource: "deployments"}:                              "DeploymentList",
+		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}:                   "IngressList",
+		{Group: "flowcontrol.apiserver.k8s.io", Version: "v1beta3", Resource: "flowschemas"}: "FlowSchemaList",
+	}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds,
+		deprecatedObject("apps/v1", "Deployment", "default", "web", `{"apiVersion":"extensions/v1beta1","kind":"Deployment"}`,
+			metav1.ManagedFieldsEntry{Manager: "kube-controller-manager", APIVersion: "apps/v1"}),
+		// Should not be reported, it is written with apps/v1.
+		deprecatedObject("apps/v1", "Deployment", "default", "api", `{"apiVersion":"apps/v1","kind":"Deployment"}`,
+			metav1.ManagedFieldsEntry{Manager: "kubectl-client-side-apply", APIVersion: "apps/v1"}),
+		deprecatedObject("networking.k8s.io/v1", "Ingress", "default", "web", "",
+			metav1.ManagedFieldsEntry{Manager: "helm", APIVersion: "networking.k8s.io/v1beta1"},
+			metav1.ManagedFieldsEntry{Manager: "argocd", APIVersion: "networking.k8s.io/v1beta1"}),
+		deprecatedObject("flowcontrol.apiserver.k8s.io/v1beta3", "FlowSchema", "", "batch-jobs", "",
+			metav1.ManagedFieldsEntry{Manager: "kubectl", APIVersion: "flowcontrol.apiserver.k8s.io/v1beta3"}),
+	)
+	return &kubernetes.Client{Client: clientset, DynamicClient: dynamicClient, ServerVersion: serverVersion}
+}
+
+func TestDeprecationAnalyzer(t *testing.T) {
+	const (
+		deployment = "Deployment web is written as extensions/v1beta1 in the last applied configuration, which is deprecated since 1.9 and removed in 1.16; migrate to apps/v1. The server runs 1.28, so re-applying the manifest fails"
+		ingress    = "Ingress web is written as networking.k8s.io/v1beta1 in the fields managed by helm, argocd, which is deprecated since 1.19 and removed in 1.22; migrate to networking.k8s.io/v1. The server runs 1.28, so re-applying the manifest fails"
+		flowSchema = "FlowSchema batch-jobs is written as flowcontrol.apiserver.k8s.io/v1beta3 in the fields managed by kubectl, which is deprecated since 1.29 and removed in 1.32; migrate to flowcontrol.apiserver.k8s.io/v1"
+	)
+
+	tests := []struct {
+		name          string
+		serverVersion *version.Info
+		targetVersion string
+		namespace     string
+		expected      map[string]common.Failure
+		err           string
+	}{
+		{
+			name:          "release after the server",
+			serverVersion: &version.Info{Major: "1", Minor: "28"},
+			expected: map[string]common.Failure{
+				"Deprecation/Deployment default/web": {Text: deployment, Severity: common.SeverityHigh},
+				"Deprecation/Ingress default/web":    {Text: ingress, Severity: common.SeverityHigh},
+				"Deprecation/FlowSchema batch-jobs":  {Text: flowSchema, Severity: common.SeverityLow},
+			},
+		},
+		{
+			name:          "target version",
+			serverVersion: &version.Info{Major: "1", Minor: "28"},
+			targetVersion: "1.32",
+			expected: map[string]common.Failure{
+				"Deprecation/Deployment default/web": {Text: deployment, Severity: common.SeverityHigh},
+				"Deprecation/Ingress default/web":    {Text: ingress, Severity: common.SeverityHigh},
+				"Deprecation/FlowSchema batch-jobs":  {Text: flowSchema + ". Manifests using it fail on 1.32", Severity: common.SeverityHigh},
+			},
+		},
+		{
+			name:          "namespace filter",
+			serverVersion: &version.Info{Major: "1", Minor: "28"},
+			namespace:     "default",
+			expected: map[string]common.Failure{
+				"Deprecation/Deployment default/web": {Text: deployment, Severity: common.SeverityHigh},
+				"Deprecation/Ingress default/web":    {Text: ingress, Severity: common.SeverityHigh},
+			},
+		},
+		{
+			name: "unknown server version",
+			err:  "unknown server version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := DeprecationAnalyzer{}.Analyze(common.Analyzer{
+				Client:        deprecationClient(tt.serverVersion),
+				Context:       context.Background(),
+				Namespace:     tt.namespace,
+				TargetVersion: tt.targetVersion,
+			})
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+
+			got := map[string]common.Failure{}
+			for _, result := range results {
+				require.Len(t, result.Error, 1)
+				got[result.Kind+" "+result.Name] = common.Failure{Text: result.Error[0].Text, Severity: result.Error[0].Severity}
+			}
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDeprecationAnalyzerDiscovery(t *testing.T) {
+	target := "1.32"
+
+	// Discovery errors other than an unserved group/version are returned.
+	client := deprecationClient(nil)
+	client.Client.(*fake.Clientset).PrependReactor("get", "resource", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{}, "", errors.New("discovery is not allowed"))
+	})
+	_, err := DeprecationAnalyzer{}.Analyze(common.Analyzer{Client: client, Context: context.Background(), TargetVersion: target})
+	require.ErrorContains(t, err, "discovery is not allowed")
+
+	// A report where nothing could be checked is an error, not a clean one.
+	client = deprecationClient(nil)
+	client.Client.(*fake.Clientset).Resources = nil
+	_, err = DeprecationAnalyzer{}.Analyze(common.Analyzer{Client: client, Context: context.Background(), TargetVersion: target})
+	require.ErrorContains(t, err, "discovery unavailable")
+}
+
+func TestDeprecationAnalyzerSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	manifest := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: '{"apiVersion":"extensions/v1beta1","kind":"Deployment"}'
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "deployment.yaml"), []byte(manifest), 0o644))
+	client, err := kubernetes.NewSnapshotClient(dir)
+	require.NoError(t, err)
+
+	results, err := DeprecationAnalyzer{}.Analyze(common.Analyzer{Client: client, Context: context.Background(), TargetVersion: "1.32"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "default/web", results[0].Name)
+}
+
+func TestDeprecationAnalyzerPartialResults(t *testing.T) {
+	client := deprecationClient(&version.Info{Major: "1", Minor: "28"})
+	client.DynamicClient.(*dynamicfake.FakeDynamicClient).PrependReactor("list", "ingresses", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, "", errors.New("listing is not allowed"))
+	})
+
+	// The kinds that could be listed are still reported.
+	results, err := DeprecationAnalyzer{}.Analyze(common.Analyzer{Client: client, Context: context.Background()})
+	require.ErrorContains(t, err, "listing Ingress")
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Kind+" "+result.Name)
+	}
+	require.ElementsMatch(t, []string{"Deprecation/Deployment default/web", "Deprecation/FlowSchema batch-jobs"}, names)
+}
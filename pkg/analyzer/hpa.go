@@ -178,10 +178,7 @@ func (HpaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.HorizontalPodAutoscalers.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.HorizontalPodAutoscalers.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
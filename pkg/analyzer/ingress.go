@@ -110,7 +110,7 @@ func (IngressAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 					if path.Backend.Service == nil {
 						continue
 					}
-					_, err := a.Client.GetClient().CoreV1().Services(ing.Namespace).Get(a.Context, path.Backend.Service.Name, metav1.GetOptions{})
+					_, err := a.GetLister().Service(a.Context, ing.Namespace, path.Backend.Service.Name)
 					if err != nil {
 						doc := apiDoc.GetApiDocV2("spec.rules.http.paths.backend.service")
 
@@ -175,10 +175,7 @@ func (IngressAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.Ingress.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Ingress.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -58,7 +58,7 @@ func (MutatingWebhookAnalyzer) Analyze(a common.Analyzer) ([]common.Result, erro
 			}
 			svc := webhook.ClientConfig.Service
 			// Get the service
-			service, err := a.Client.GetClient().CoreV1().Services(svc.Namespace).Get(context.Background(), svc.Name, v1.GetOptions{})
+			service, err := a.GetLister().Service(a.Context, svc.Namespace, svc.Name)
 			if err != nil {
 				// If the service is not found, we can't check the pods
 				failures = append(failures, common.Failure{
@@ -88,14 +88,12 @@ func (MutatingWebhookAnalyzer) Analyze(a common.Analyzer) ([]common.Result, erro
 				continue
 			}
 			// Get pods within service
-			pods, err := a.Client.GetClient().CoreV1().Pods(svc.Namespace).List(context.Background(), v1.ListOptions{
-				LabelSelector: util.MapToString(service.Spec.Selector),
-			})
+			pods, err := a.GetLister().Pods(a.Context, svc.Namespace, util.MapToString(service.Spec.Selector))
 			if err != nil {
 				return nil, err
 			}
 
-			if len(pods.Items) == 0 {
+			if len(pods) == 0 {
 				failures = append(failures, common.Failure{
 					Text:          fmt.Sprintf("No active pods found within service %s as mapped to by Mutating Webhook %s", svc.Name, webhook.Name),
 					KubernetesDoc: apiDoc.GetApiDocV2("spec.webhook.clientConfig.service"),
@@ -108,7 +106,7 @@ func (MutatingWebhookAnalyzer) Analyze(a common.Analyzer) ([]common.Result, erro
 				})
 
 			}
-			for _, pod := range pods.Items {
+			for _, pod := range pods {
 				if pod.Status.Phase != "Running" {
 					doc := apiDoc.GetApiDocV2("spec.webhook")
 					failures = append(failures, common.Failure{
@@ -151,10 +149,7 @@ func (MutatingWebhookAnalyzer) Analyze(a common.Analyzer) ([]common.Result, erro
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.MutatingWebhook.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.MutatingWebhook.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
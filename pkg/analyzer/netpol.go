@@ -20,6 +20,7 @@ import (
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -69,11 +70,11 @@ func (NetworkPolicyAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error)
 			})
 		} else {
 			// Check if policy is not applied to any pods
-			podList, err := util.GetPodListByLabels(a.Client.GetClient(), a.Namespace, policy.Spec.PodSelector.MatchLabels)
+			pods, err := a.GetLister().Pods(a.Context, a.Namespace, labels.SelectorFromSet(policy.Spec.PodSelector.MatchLabels).String())
 			if err != nil {
 				return nil, err
 			}
-			if len(podList.Items) == 0 {
+			if len(pods) == 0 {
 				failures = append(failures, common.Failure{
 					Text: fmt.Sprintf("Network policy is not applied to any pods: %s", policy.Name),
 					Sensitive: []common.Sensitive{
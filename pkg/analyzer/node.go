@@ -79,10 +79,7 @@ func (NodeAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.Node.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Node.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -98,10 +95,24 @@ func addNodeConditionFailure(failures []common.Failure, nodeName string, nodeCon
 				Masked:   util.MaskString(nodeName),
 			},
 		},
+		Severity: nodeConditionSeverity(nodeCondition),
 	})
 	return failures
 }
 
+// nodeConditionSeverity treats a node that is not ready as critical, since
+// every workload scheduled on it is affected; pressure conditions are high.
+func nodeConditionSeverity(nodeCondition v1.NodeCondition) common.Severity {
+	switch nodeCondition.Type {
+	case v1.NodeReady:
+		return common.SeverityCritical
+	case v1.NodeMemoryPressure, v1.NodeDiskPressure, v1.NodePIDPressure, v1.NodeNetworkUnavailable:
+		return common.SeverityHigh
+	default:
+		return common.SeverityMedium
+	}
+}
+
 // isKnownNodeConditionType checks if the condition type is a standard Kubernetes node condition
 func isKnownNodeConditionType(conditionType v1.NodeConditionType) bool {
 	switch conditionType {
@@ -19,7 +19,6 @@ import (
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	v1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type PodAnalyzer struct {
@@ -34,15 +33,13 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	})
 
 	// search all namespaces for pods that are not running
-	list, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{
-		LabelSelector: a.LabelSelector,
-	})
+	list, err := a.GetLister().Pods(a.Context, a.Namespace, a.LabelSelector)
 	if err != nil {
 		return nil, err
 	}
 	var preAnalysis = map[string]common.PreAnalysis{}
 
-	for _, pod := range list.Items {
+	for _, pod := range list {
 		var failures []common.Failure
 
 		// Check for pending pods
@@ -54,6 +51,7 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 						failures = append(failures, common.Failure{
 							Text:      containerStatus.Message,
 							Sensitive: []common.Sensitive{},
+							Severity:  common.SeverityHigh,
 						})
 					}
 				}
@@ -65,6 +63,7 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 					failures = append(failures, common.Failure{
 						Text:      text,
 						Sensitive: []common.Sensitive{},
+						Severity:  common.SeverityLow,
 					})
 				}
 			}
@@ -75,6 +74,7 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			failure := common.Failure{
 				Text:      fmt.Sprintf("Pod %s has been evicted", pod.Name),
 				Sensitive: []common.Sensitive{},
+				Severity:  common.SeverityMedium,
 			}
 
 			if pod.Status.Message != "" {
@@ -106,10 +106,7 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.Pod.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Pod.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -133,6 +130,7 @@ func analyzeContainerStatusFailures(a common.Analyzer, statuses []v1.ContainerSt
 					failures = append(failures, common.Failure{
 						Text:      evt.Message,
 						Sensitive: []common.Sensitive{},
+						Severity:  common.SeverityHigh,
 					})
 				}
 			} else if containerStatus.State.Waiting.Reason == "CrashLoopBackOff" && containerStatus.LastTerminationState.Terminated != nil {
@@ -140,11 +138,13 @@ func analyzeContainerStatusFailures(a common.Analyzer, statuses []v1.ContainerSt
 				failures = append(failures, common.Failure{
 					Text:      fmt.Sprintf("the last termination reason is %s container=%s pod=%s", containerStatus.LastTerminationState.Terminated.Reason, containerStatus.Name, name),
 					Sensitive: []common.Sensitive{},
+					Severity:  common.SeverityCritical,
 				})
 			} else if isErrorReason(containerStatus.State.Waiting.Reason) && containerStatus.State.Waiting.Message != "" {
 				failures = append(failures, common.Failure{
 					Text:      containerStatus.State.Waiting.Message,
 					Sensitive: []common.Sensitive{},
+					Severity:  waitingReasonSeverity(containerStatus.State.Waiting.Reason),
 				})
 			}
 		} else if containerStatus.State.Terminated != nil {
@@ -159,6 +159,7 @@ func analyzeContainerStatusFailures(a common.Analyzer, statuses []v1.ContainerSt
 				failures = append(failures, common.Failure{
 					Text:      fmt.Sprintf("the termination reason is %s exitCode=%d container=%s pod=%s", reason, exitCode, containerStatus.Name, name),
 					Sensitive: []common.Sensitive{},
+					Severity:  common.SeverityHigh,
 				})
 			}
 		} else {
@@ -173,6 +174,7 @@ func analyzeContainerStatusFailures(a common.Analyzer, statuses []v1.ContainerSt
 					failures = append(failures, common.Failure{
 						Text:      evt.Message,
 						Sensitive: []common.Sensitive{},
+						Severity:  common.SeverityMedium,
 					})
 				}
 			}
@@ -196,6 +198,15 @@ func isErrorReason(reason string) bool {
 	return false
 }
 
+// waitingReasonSeverity classifies a container waiting reason; crash loops
+// take the workload down, the remaining reasons block it from starting.
+func waitingReasonSeverity(reason string) common.Severity {
+	if reason == "CrashLoopBackOff" {
+		return common.SeverityCritical
+	}
+	return common.SeverityHigh
+}
+
 func isEvtErrorReason(reason string) bool {
 	failureReasons := []string{
 		"FailedCreatePodSandBox", "FailedMount",
@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podSecurityLevel is a level of the Pod Security Standards, see
+// https://kubernetes.io/docs/concepts/security/pod-security-standards/.
+type podSecurityLevel int
+
+const (
+	levelPrivileged podSecurityLevel = iota
+	levelBaseline
+	levelRestricted
+)
+
+func (l podSecurityLevel) String() string {
+	switch l {
+	case levelBaseline:
+		return "baseline"
+	case levelRestricted:
+		return "restricted"
+	default:
+		return "privileged"
+	}
+}
+
+func parsePodSecurityLevel(value string) (podSecurityLevel, bool) {
+	switch value {
+	case "privileged":
+		return levelPrivileged, true
+	case "baseline":
+		return levelBaseline, true
+	case "restricted":
+		return levelRestricted, true
+	}
+	return levelPrivileged, false
+}
+
+// podSecurityLabelPrefix prefixes the namespace labels of Pod Security
+// Admission, e.g. pod-security.kubernetes.io/enforce=baseline.
+const podSecurityLabelPrefix = "pod-security.kubernetes.io/"
+
+// podSecurityModes are the admission modes, from the strictest.
+var podSecurityModes = []string{"enforce", "audit", "warn"}
+
+// namespacePodSecurity holds the Pod Security Admission labels of a
+// namespace.
+type namespacePodSecurity struct {
+	name    string
+	levels  map[string]podSecurityLevel // By mode
+	labeled bool
+}
+
+func newNamespacePodSecurity(namespace *v1.Namespace) namespacePodSecurity {
+	policy := namespacePodSecurity{name: namespace.Name, levels: map[string]podSecurityLevel{}}
+	for _, mode := range podSecurityModes {
+		if level, ok := parsePodSecurityLevel(namespace.Labels[podSecurityLabelPrefix+mode]); ok {
+			policy.levels[mode] = level
+			policy.labeled = true
+		}
+	}
+	return policy
+}
+
+// target returns the level pods are evaluated against: the strictest level
+// of the labels, or restricted for namespaces without labels, to show what
+// would break before enforcing it.
+func (p namespacePodSecurity) target() podSecurityLevel {
+	if !p.labeled {
+		return levelRestricted
+	}
+	target := levelPrivileged
+	for _, level := range p.levels {
+		if level > target {
+			target = level
+		}
+	}
+	return target
+}
+
+// failure turns a violation into a failure, stating how the namespace
+// handles it.
+func (p namespacePodSecurity) failure(violation podSecurityViolation) common.Failure {
+	var mode string
+	var severity common.Severity
+	switch p.mode(violation.level) {
+	case "enforce":
+		mode, severity = "enforces, so new pods are rejected", common.SeverityHigh
+	case "audit":
+		mode, severity = "audits", common.SeverityMedium
+	case "warn":
+		mode, severity = "warns about", common.SeverityMedium
+	default:
+		mode, severity = "does not enforce yet", common.SeverityLow
+		if violation.level == levelBaseline {
+			severity = common.SeverityMedium
+		}
+	}
+	return common.Failure{
+		Text:      fmt.Sprintf("Violates the %s Pod Security Standard, which namespace %s %s: %s", violation.level, p.name, mode, violation.text),
+		Sensitive: []common.Sensitive{},
+		Severity:  severity,
+	}
+}
+
+// mode returns the strictest mode the namespace applies level with, or "".
+func (p namespacePodSecurity) mode(level podSecurityLevel) string {
+	for _, mode := range podSecurityModes {
+		if modeLevel, ok := p.levels[mode]; ok && modeLevel >= level {
+			return mode
+		}
+	}
+	return ""
+}
+
+// podSecurityPolicies resolves the Pod Security Admission labels of
+// namespaces. Namespaces that cannot be read, e.g. for lack of
+// permissions, are evaluated as unlabeled.
+type podSecurityPolicies struct {
+	a          common.Analyzer
+	namespaces map[string]namespacePodSecurity
+}
+
+func (p *podSecurityPolicies) get(name string) namespacePodSecurity {
+	if policy, ok := p.namespaces[name]; ok {
+		return policy
+	}
+	policy := namespacePodSecurity{name: name, levels: map[string]podSecurityLevel{}}
+	if namespace, err := p.a.Client.GetClient().CoreV1().Namespaces().Get(p.a.Context, name, metav1.GetOptions{}); err == nil {
+		policy = newNamespacePodSecurity(namespace)
+	}
+	p.namespaces[name] = policy
+	return policy
+}
+
+// failures evaluates a pod, or a pod template, of namespace.
+func (p *podSecurityPolicies) failures(namespace string, annotations map[string]string, spec v1.PodSpec) []common.Failure {
+	policy := p.get(namespace)
+	target := policy.target()
+	var failures []common.Failure
+	for _, violation := range evaluatePodSecurity(annotations, spec) {
+		if violation.level <= target {
+			failures = append(failures, policy.failure(violation))
+		}
+	}
+	return failures
+}
+
+// podSecurityViolation is a check of the Pod Security Standards a pod
+// fails.
+type podSecurityViolation struct {
+	level podSecurityLevel
+	text  string
+}
+
+var (
+	// baselineCapabilities may be added at the baseline level.
+	baselineCapabilities = map[v1.Capability]bool{
+		"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true, "MKNOD": true,
+		"NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+	}
+	// safeSysctls may be set at the baseline level.
+	safeSysctls = map[string]bool{
+		"kernel.shm_rmid_forced": true, "net.ipv4.ip_local_port_range": true, "net.ipv4.ip_unprivileged_port_start": true,
+		"net.ipv4.tcp_syncookies": true, "net.ipv4.ping_group_range": true, "net.ipv4.ip_local_reserved_ports": true,
+		"net.ipv4.tcp_keepalive_time": true, "net.ipv4.tcp_fin_timeout": true, "net.ipv4.tcp_keepalive_intvl": true,
+		"net.ipv4.tcp_keepalive_probes": true,
+	}
+	// seLinuxTypes may be set at the baseline level.
+	seLinuxTypes = map[string]bool{"": true, "container_t": true, "container_init_t": true, "container_kvm_t": true, "container_engine_t": true}
+)
+
+// appArmorAnnotationPrefix prefixes the legacy AppArmor annotations of pods.
+const appArmorAnnotationPrefix = "container.apparmor.security.beta.kubernetes.io/"
+
+// evaluatePodSecurity returns the checks of the baseline and restricted
+// Pod Security Standards a pod with annotations and spec fails.
+func evaluatePodSecurity(annotations map[string]string, spec v1.PodSpec) []podSecurityViolation {
+	var violations []podSecurityViolation
+	add := func(level podSecurityLevel, format string, args ...any) {
+		violations = append(violations, podSecurityViolation{level: level, text: fmt.Sprintf(format, args...)})
+	}
+
+	podContext := spec.SecurityContext
+	if podContext == nil {
+		podContext = &v1.PodSecurityContext{}
+	}
+
+	// Baseline
+	if spec.HostNetwork {
+		add(levelBaseline, "hostNetwork is true")
+	}
+	if spec.HostPID {
+		add(levelBaseline, "hostPID is true")
+	}
+	if spec.HostIPC {
+		add(levelBaseline, "hostIPC is true")
+	}
+	if podContext.WindowsOptions != nil && isTrue(podContext.WindowsOptions.HostProcess) {
+		add(levelBaseline, "the pod runs as a Windows HostProcess")
+	}
+	if podContext.SELinuxOptions != nil && !allowedSELinux(podContext.SELinuxOptions) {
+		add(levelBaseline, "the pod sets a custom SELinux user, role or type")
+	}
+	if podContext.SeccompProfile != nil && podContext.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+		add(levelBaseline, "the pod seccomp profile is Unconfined")
+	}
+	if podContext.AppArmorProfile != nil && podContext.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined {
+		add(levelBaseline, "the pod AppArmor profile is Unconfined")
+	}
+	for _, sysctl := range podContext.Sysctls {
+		if !safeSysctls[sysctl.Name] {
+			add(levelBaseline, "the pod sets the unsafe sysctl %s", sysctl.Name)
+		}
+	}
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := annotations[key]
+		if container, ok := strings.CutPrefix(key, appArmorAnnotationPrefix); ok && value != "runtime/default" && !strings.HasPrefix(value, "localhost/") {
+			add(levelBaseline, "container %s has the AppArmor profile %s", container, value)
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			add(levelBaseline, "volume %s is a hostPath of %s", volume.Name, volume.HostPath.Path)
+		} else if kind := restrictedVolumeKind(volume.VolumeSource); kind != "" {
+			add(levelRestricted, "volume %s has the type %s", volume.Name, kind)
+		}
+	}
+
+	// Restricted, at the pod level
+	if podContext.RunAsUser != nil && *podContext.RunAsUser == 0 {
+		add(levelRestricted, "the pod runs as user 0")
+	}
+
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		sc := container.SecurityContext
+		if sc == nil {
+			sc = &v1.SecurityContext{}
+		}
+		name := container.Name
+
+		// Baseline
+		if isTrue(sc.Privileged) {
+			add(levelBaseline, "container %s is privileged", name)
+		}
+		if sc.WindowsOptions != nil && isTrue(sc.WindowsOptions.HostProcess) {
+			add(levelBaseline, "container %s runs as a Windows HostProcess", name)
+		}
+		if sc.Capabilities != nil {
+			for _, capability := range sc.Capabilities.Add {
+				if !baselineCapabilities[capability] {
+					add(levelBaseline, "container %s adds the capability %s", name, capability)
+				} else if capability != "NET_BIND_SERVICE" {
+					add(levelRestricted, "container %s adds the capability %s", name, capability)
+				}
+			}
+		}
+		for _, port := range container.Ports {
+			if port.HostPort != 0 {
+				add(levelBaseline, "container %s uses the host port %d", name, port.HostPort)
+			}
+		}
+		if sc.SELinuxOptions != nil && !allowedSELinux(sc.SELinuxOptions) {
+			add(levelBaseline, "container %s sets a custom SELinux user, role or type", name)
+		}
+		if sc.ProcMount != nil && *sc.ProcMount != v1.DefaultProcMount {
+			add(levelBaseline, "container %s sets the procMount %s", name, *sc.ProcMount)
+		}
+		if sc.SeccompProfile != nil && sc.SeccompProfile.Type == v1.SeccompProfileTypeUnconfined {
+			add(levelBaseline, "container %s seccomp profile is Unconfined", name)
+		}
+		if sc.AppArmorProfile != nil && sc.AppArmorProfile.Type == v1.AppArmorProfileTypeUnconfined {
+			add(levelBaseline, "container %s AppArmor profile is Unconfined", name)
+		}
+
+		// Restricted
+		if !isFalse(sc.AllowPrivilegeEscalation) {
+			add(levelRestricted, "container %s does not set allowPrivilegeEscalation to false", name)
+		}
+		if isFalse(sc.RunAsNonRoot) || (sc.RunAsNonRoot == nil && !isTrue(podContext.RunAsNonRoot)) {
+			add(levelRestricted, "container %s does not set runAsNonRoot to true", name)
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			add(levelRestricted, "container %s runs as user 0", name)
+		}
+		if sc.SeccompProfile == nil && podContext.SeccompProfile == nil {
+			add(levelRestricted, "container %s does not set a RuntimeDefault or Localhost seccomp profile", name)
+		}
+		if sc.Capabilities == nil || !dropsAll(sc.Capabilities.Drop) {
+			add(levelRestricted, "container %s does not drop ALL capabilities", name)
+		}
+	}
+	return violations
+}
+
+// restrictedVolumeKind returns the type of a volume the restricted level
+// does not allow, or "".
+func restrictedVolumeKind(source v1.VolumeSource) string {
+	switch {
+	case source.ConfigMap != nil, source.CSI != nil, source.DownwardAPI != nil, source.EmptyDir != nil,
+		source.Ephemeral != nil, source.PersistentVolumeClaim != nil, source.Projected != nil, source.Secret != nil:
+		return ""
+	case source.NFS != nil:
+		return "nfs"
+	case source.ISCSI != nil:
+		return "iscsi"
+	case source.RBD != nil:
+		return "rbd"
+	case source.CephFS != nil:
+		return "cephfs"
+	case source.GitRepo != nil:
+		return "gitRepo"
+	case source.Image != nil:
+		return "image"
+	default:
+		return "other than configMap, csi, downwardAPI, emptyDir, ephemeral, persistentVolumeClaim, projected or secret"
+	}
+}
+
+func allowedSELinux(options *v1.SELinuxOptions) bool {
+	return options.User == "" && options.Role == "" && seLinuxTypes[options.Type]
+}
+
+func dropsAll(capabilities []v1.Capability) bool {
+	for _, capability := range capabilities {
+		if capability == "ALL" {
+			return true
+		}
+	}
+	return false
+}
+
+func isTrue(value *bool) bool {
+	return value != nil && *value
+}
+
+func isFalse(value *bool) bool {
+	return value != nil && !*value
+}
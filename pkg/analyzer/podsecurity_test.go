@@ -0,0 +1,292 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// restrictedSpec passes every check of the restricted Pod Security Standard.
+func restrictedSpec() v1.PodSpec {
+	return v1.PodSpec{
+		SecurityContext: &v1.PodSecurityContext{
+			RunAsNonRoot:   boolPtr(true),
+			SeccompProfile: &v1.SeccompProfile{Type: v1.SeccompProfileTypeRuntimeDefault},
+		},
+		Containers: []v1.Container{
+			{
+				Name: "app",
+				SecurityContext: &v1.SecurityContext{
+					AllowPrivilegeEscalation: boolPtr(false),
+					Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}, Add: []v1.Capability{"NET_BIND_SERVICE"}},
+				},
+			},
+		},
+		Volumes: []v1.Volume{
+			{Name: "config", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{}}},
+		},
+	}
+}
+
+func TestEvaluatePodSecurity(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*v1.PodSpec)
+		annotations map[string]string
+		baseline    []string
+		restricted  []string
+	}{
+		{
+			name:   "restricted",
+			modify: func(*v1.PodSpec) {},
+		},
+		{
+			name: "host namespaces and ports",
+			modify: func(spec *v1.PodSpec) {
+				spec.HostNetwork = true
+				spec.HostPID = true
+				spec.HostIPC = true
+				spec.Containers[0].Ports = []v1.ContainerPort{{ContainerPort: 80, HostPort: 80}}
+			},
+			baseline: []string{"hostNetwork is true", "hostPID is true", "hostIPC is true", "container app uses the host port 80"},
+		},
+		{
+			name: "volumes",
+			modify: func(spec *v1.PodSpec) {
+				spec.Volumes = append(spec.Volumes,
+					v1.Volume{Name: "root", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/"}}},
+					v1.Volume{Name: "share", VolumeSource: v1.VolumeSource{NFS: &v1.NFSVolumeSource{Server: "nfs", Path: "/"}}},
+				)
+			},
+			baseline:   []string{"volume root is a hostPath of /"},
+			restricted: []string{"volume share has the type nfs"},
+		},
+		{
+			name: "capabilities",
+			modify: func(spec *v1.PodSpec) {
+				spec.Containers[0].SecurityContext.Capabilities = &v1.Capabilities{Add: []v1.Capability{"SYS_ADMIN", "CHOWN"}}
+			},
+			baseline:   []string{"container app adds the capability SYS_ADMIN"},
+			restricted: []string{"container app adds the capability CHOWN", "container app does not drop ALL capabilities"},
+		},
+		{
+			name: "privileged and unconfined",
+			modify: func(spec *v1.PodSpec) {
+				spec.Containers[0].SecurityContext.Privileged = boolPtr(true)
+				spec.Containers[0].SecurityContext.SeccompProfile = &v1.SeccompProfile{Type: v1.SeccompProfileTypeUnconfined}
+				spec.SecurityContext.Sysctls = []v1.Sysctl{{Name: "kernel.msgmax", Value: "65536"}}
+			},
+			annotations: map[string]string{appArmorAnnotationPrefix + "app": "unconfined"},
+			baseline: []string{
+				"the pod sets the unsafe sysctl kernel.msgmax",
+				"container app has the AppArmor profile unconfined",
+				"container app is privileged",
+				"container app seccomp profile is Unconfined",
+			},
+		},
+		{
+			name: "root and privilege escalation",
+			modify: func(spec *v1.PodSpec) {
+				spec.SecurityContext = nil
+				spec.InitContainers = []v1.Container{{Name: "init", SecurityContext: &v1.SecurityContext{
+					RunAsUser:                int64Ptr(0),
+					AllowPrivilegeEscalation: boolPtr(false),
+					RunAsNonRoot:             boolPtr(true),
+					Capabilities:             &v1.Capabilities{Drop: []v1.Capability{"ALL"}},
+				}}}
+			},
+			restricted: []string{
+				"container init runs as user 0",
+				"container init does not set a RuntimeDefault or Localhost seccomp profile",
+				"container app does not set runAsNonRoot to true",
+				"container app does not set a RuntimeDefault or Localhost seccomp profile",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := restrictedSpec()
+			tt.modify(&spec)
+			var baseline, restricted []string
+			for _, violation := range evaluatePodSecurity(tt.annotations, spec) {
+				if violation.level == levelBaseline {
+					baseline = append(baseline, violation.text)
+				} else {
+					restricted = append(restricted, violation.text)
+				}
+			}
+			require.Equal(t, tt.baseline, baseline)
+			require.Equal(t, tt.restricted, restricted)
+		})
+	}
+}
+
+func TestSecurityAnalyzerPodSecurityStandards(t *testing.T) {
+	// Breaks the baseline level with hostNetwork and the restricted level
+	// with a missing seccomp profile.
+	spec := restrictedSpec()
+	spec.HostNetwork = true
+	spec.SecurityContext.SeccompProfile = nil
+
+	tests := []struct {
+		name     string
+		labels   map[string]string
+		failures []common.Failure
+	}{
+		{
+			name: "unlabeled namespace",
+			failures: []common.Failure{
+				{Text: "Violates the baseline Pod Security Standard, which namespace apps does not enforce yet: hostNetwork is true", Severity: common.SeverityMedium},
+				{Text: "Violates the restricted Pod Security Standard, which namespace apps does not enforce yet: container app does not set a RuntimeDefault or Localhost seccomp profile", Severity: common.SeverityLow},
+			},
+		},
+		{
+			name:   "enforced baseline",
+			labels: map[string]string{"pod-security.kubernetes.io/enforce": "baseline"},
+			failures: []common.Failure{
+				{Text: "Violates the baseline Pod Security Standard, which namespace apps enforces, so new pods are rejected: hostNetwork is true", Severity: common.SeverityHigh},
+			},
+		},
+		{
+			name: "enforced baseline and warned restricted",
+			labels: map[string]string{
+				"pod-security.kubernetes.io/enforce": "baseline",
+				"pod-security.kubernetes.io/warn":    "restricted",
+			},
+			failures: []common.Failure{
+				{Text: "Violates the baseline Pod Security Standard, which namespace apps enforces, so new pods are rejected: hostNetwork is true", Severity: common.SeverityHigh},
+				{Text: "Violates the restricted Pod Security Standard, which namespace apps warns about: container app does not set a RuntimeDefault or Localhost seccomp profile", Severity: common.SeverityMedium},
+			},
+		},
+		{
+			name:   "privileged namespace",
+			labels: map[string]string{"pod-security.kubernetes.io/enforce": "privileged"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(
+				&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps", Labels: tt.labels}},
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "apps"},
+					Spec:       appsv1.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: spec}},
+				},
+				&appsv1.ReplicaSet{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "web-7d9",
+						Namespace:       "apps",
+						OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: boolPtr(true)}},
+					},
+				},
+				&appsv1.ReplicaSet{
+					// Has no template that is evaluated on its own.
+					ObjectMeta: metav1.ObjectMeta{Name: "standalone", Namespace: "apps"},
+				},
+				&v1.Pod{
+					// Should be evaluated through its Deployment only.
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "web-7d9-x",
+						Namespace:       "apps",
+						OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web-7d9", Controller: boolPtr(true)}},
+					},
+					Spec: spec,
+				},
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "standalone-x",
+						Namespace:       "apps",
+						OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "standalone", Controller: boolPtr(true)}},
+					},
+					Spec: spec,
+				},
+				&v1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "debug", Namespace: "apps"},
+					Spec:       spec,
+				},
+			)
+
+			results, err := SecurityAnalyzer{}.Analyze(common.Analyzer{
+				Client:    &kubernetes.Client{Client: client},
+				Context:   context.Background(),
+				Namespace: "apps",
+			})
+			require.NoError(t, err)
+			sort.Slice(results, func(i, j int) bool {
+				return results[i].Kind < results[j].Kind
+			})
+
+			var names []string
+			for _, result := range results {
+				names = append(names, result.Kind+" "+result.Name)
+				var failures []common.Failure
+				for _, failure := range result.Error {
+					failures = append(failures, common.Failure{Text: failure.Text, Severity: failure.Severity})
+				}
+				require.Equal(t, tt.failures, failures, result.Kind)
+			}
+			if len(tt.failures) == 0 {
+				require.Empty(t, names)
+			} else {
+				require.ElementsMatch(t, []string{"Security/Deployment apps/web", "Security/Pod apps/debug", "Security/Pod apps/standalone-x"}, names)
+			}
+		})
+	}
+}
+
+func TestSecurityAnalyzerPodSecurityLabelSelector(t *testing.T) {
+	spec := restrictedSpec()
+	spec.HostNetwork = true
+
+	client := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "apps"}},
+		&appsv1.StatefulSet{
+			// Left out by the label selector, so its pods are evaluated.
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "apps"},
+			Spec:       appsv1.StatefulSetSpec{Template: v1.PodTemplateSpec{Spec: spec}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "db-0",
+				Namespace:       "apps",
+				Labels:          map[string]string{"tier": "data"},
+				OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "StatefulSet", Name: "db", Controller: boolPtr(true)}},
+			},
+			Spec: spec,
+		},
+	)
+
+	results, err := SecurityAnalyzer{}.Analyze(common.Analyzer{
+		Client:        &kubernetes.Client{Client: client},
+		Context:       context.Background(),
+		Namespace:     "apps",
+		LabelSelector: "tier=data",
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "Security/Pod", results[0].Kind)
+	require.Equal(t, "apps/db-0", results[0].Name)
+	require.Contains(t, results[0].Error[0].Text, "hostNetwork is true")
+}
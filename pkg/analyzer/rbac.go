@@ -0,0 +1,369 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// bootstrapAnnotation marks the roles and bindings Kubernetes maintains
+// itself. They are left out of the risk checks.
+const bootstrapAnnotation = "rbac.authorization.kubernetes.io/autoupdate"
+
+// rbacRisk is a dangerous permission a rule can grant.
+type rbacRisk struct {
+	description string
+	severity    common.Severity
+	// clusterWide risks are only reported for ClusterRoleBindings.
+	clusterWide bool
+	matches     func(rule rbacv1.PolicyRule) bool
+}
+
+var rbacRisks = []rbacRisk{
+	{
+		description: "can read secrets in all namespaces",
+		severity:    common.SeverityHigh,
+		clusterWide: true,
+		matches: func(rule rbacv1.PolicyRule) bool {
+			return ruleGrants(rule, "", "secrets", "get", "list", "watch")
+		},
+	},
+	{
+		description: "can exec into pods",
+		severity:    common.SeverityMedium,
+		matches: func(rule rbacv1.PolicyRule) bool {
+			return ruleGrants(rule, "", "pods/exec", "create", "get")
+		},
+	},
+	{
+		description: "can create pods, and so run them as any service account of their namespace",
+		severity:    common.SeverityMedium,
+		matches: func(rule rbacv1.PolicyRule) bool {
+			return ruleGrants(rule, "", "pods", "create")
+		},
+	},
+	{
+		description: "can escalate or bind roles beyond its own permissions",
+		severity:    common.SeverityHigh,
+		matches: func(rule rbacv1.PolicyRule) bool {
+			return ruleGrants(rule, rbacv1.GroupName, "roles", "escalate", "bind") ||
+				ruleGrants(rule, rbacv1.GroupName, "clusterroles", "escalate", "bind")
+		},
+	},
+	{
+		description: "can impersonate other users, groups or service accounts",
+		severity:    common.SeverityHigh,
+		matches: func(rule rbacv1.PolicyRule) bool {
+			return ruleGrants(rule, "", "users", "impersonate") ||
+				ruleGrants(rule, "", "groups", "impersonate") ||
+				ruleGrants(rule, "", "serviceaccounts", "impersonate")
+		},
+	},
+	{
+		description: "can modify admission webhooks",
+		severity:    common.SeverityHigh,
+		matches: func(rule rbacv1.PolicyRule) bool {
+			verbs := []string{"create", "update", "patch", "delete"}
+			return ruleGrants(rule, "admissionregistration.k8s.io", "validatingwebhookconfigurations", verbs...) ||
+				ruleGrants(rule, "admissionregistration.k8s.io", "mutatingwebhookconfigurations", verbs...)
+		},
+	},
+}
+
+type RBACAnalyzer struct{}
+
+func (RBACAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+	kind := "RBAC"
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	client := a.Client.GetClient().RbacV1()
+	clusterRoles, err := client.ClusterRoles().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	r := rbacResolver{
+		a:               a,
+		clusterRoles:    map[string]rbacv1.ClusterRole{},
+		roles:           map[string]rbacv1.Role{},
+		serviceAccounts: map[string]bool{},
+	}
+	for _, clusterRole := range clusterRoles.Items {
+		r.clusterRoles[clusterRole.Name] = clusterRole
+	}
+	roles, err := client.Roles(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles.Items {
+		r.roles[role.Namespace+"/"+role.Name] = role
+	}
+
+	var results []common.Result
+
+	clusterRoleBindings, err := client.ClusterRoleBindings().List(a.Context, metav1.ListOptions{LabelSelector: a.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range clusterRoleBindings.Items {
+		failures, err := r.bindingFailures("ClusterRoleBinding "+binding.Name, binding.ObjectMeta, binding.RoleRef, binding.Subjects, true)
+		if err != nil {
+			return nil, err
+		}
+		results = r.appendResult(results, "RBAC/ClusterRoleBinding", binding.ObjectMeta, failures)
+	}
+
+	// All RoleBindings are listed to find the unused Roles, only the ones
+	// that match the label selector are analyzed.
+	selector, err := labels.Parse(a.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	roleBindings, err := client.RoleBindings(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	boundRoles := map[string]bool{}
+	for _, binding := range roleBindings.Items {
+		if binding.RoleRef.Kind == "Role" {
+			boundRoles[binding.Namespace+"/"+binding.RoleRef.Name] = true
+		}
+		if !selector.Matches(labels.Set(binding.Labels)) {
+			continue
+		}
+		failures, err := r.bindingFailures(fmt.Sprintf("RoleBinding %s/%s", binding.Namespace, binding.Name), binding.ObjectMeta, binding.RoleRef, binding.Subjects, false)
+		if err != nil {
+			return nil, err
+		}
+		results = r.appendResult(results, "RBAC/RoleBinding", binding.ObjectMeta, failures)
+	}
+
+	for _, role := range roles.Items {
+		if boundRoles[role.Namespace+"/"+role.Name] || role.Annotations[bootstrapAnnotation] == "true" {
+			continue
+		}
+		results = r.appendResult(results, "RBAC/Role", role.ObjectMeta, []common.Failure{{
+			Text:      fmt.Sprintf("Role %s/%s is not referenced by any RoleBinding", role.Namespace, role.Name),
+			Sensitive: []common.Sensitive{},
+			Severity:  common.SeverityLow,
+		}})
+	}
+
+	return results, nil
+}
+
+// rbacResolver resolves the roles and subjects of bindings.
+type rbacResolver struct {
+	a               common.Analyzer
+	clusterRoles    map[string]rbacv1.ClusterRole
+	roles           map[string]rbacv1.Role // By namespace/name
+	serviceAccounts map[string]bool        // Existence by namespace/name
+}
+
+// bindingFailures reports the risky permissions a binding grants, each
+// with its path from the subject to the rule, and the role and service
+// accounts it references that do not exist.
+func (r *rbacResolver) bindingFailures(binding string, meta metav1.ObjectMeta, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject, clusterWide bool) ([]common.Failure, error) {
+	var failures []common.Failure
+
+	if clusterWide && r.a.Namespace != "" {
+		// With a namespace filter, cluster bindings are only about the
+		// service accounts of the namespace.
+		subjects = subjectsInNamespace(subjects, r.a.Namespace)
+		if len(subjects) == 0 {
+			return nil, nil
+		}
+	}
+
+	rules, found, err := r.rules(meta.Namespace, roleRef)
+	if err != nil {
+		return nil, err
+	}
+	role := fmt.Sprintf("%s %s", roleRef.Kind, roleRef.Name)
+	if !found {
+		failures = append(failures, common.Failure{
+			Text:      fmt.Sprintf("%s references %s which does not exist", binding, role),
+			Sensitive: []common.Sensitive{},
+			Severity:  common.SeverityMedium,
+		})
+	}
+
+	for _, subject := range subjects {
+		// Kubernetes manages its own bindings and system identities.
+		if meta.Annotations[bootstrapAnnotation] == "true" || strings.HasPrefix(subject.Name, "system:") {
+			continue
+		}
+
+		subjectName := subject.Name
+		if subject.Kind == rbacv1.ServiceAccountKind {
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = meta.Namespace
+			}
+			subjectName = namespace + "/" + subject.Name
+			exists, err := r.serviceAccountExists(namespace, subject.Name)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				failures = append(failures, common.Failure{
+					Text:      fmt.Sprintf("%s binds ServiceAccount %s which does not exist", binding, subjectName),
+					Sensitive: []common.Sensitive{},
+					Severity:  common.SeverityLow,
+				})
+				continue
+			}
+		}
+
+		path := fmt.Sprintf("%s %s → %s → %s", subject.Kind, subjectName, binding, role)
+		sensitive := []common.Sensitive{{Unmasked: subjectName, Masked: util.MaskString(subjectName)}}
+		if clusterWide && roleRef.Kind == "ClusterRole" && roleRef.Name == "cluster-admin" {
+			failures = append(failures, common.Failure{
+				Text:      fmt.Sprintf("%s grants full control of the cluster", path),
+				Sensitive: sensitive,
+				Severity:  common.SeverityHigh,
+			})
+			continue
+		}
+		if subject.Kind != rbacv1.ServiceAccountKind {
+			continue
+		}
+		for _, risk := range rbacRisks {
+			if risk.clusterWide && !clusterWide {
+				continue
+			}
+			for _, rule := range rules {
+				if risk.matches(rule) {
+					failures = append(failures, common.Failure{
+						Text:      fmt.Sprintf("%s → %s: %s", path, describeRule(rule), risk.description),
+						Sensitive: sensitive,
+						Severity:  risk.severity,
+					})
+					break
+				}
+			}
+		}
+	}
+	return failures, nil
+}
+
+func subjectsInNamespace(subjects []rbacv1.Subject, namespace string) []rbacv1.Subject {
+	var inNamespace []rbacv1.Subject
+	for _, subject := range subjects {
+		if subject.Kind == rbacv1.ServiceAccountKind && subject.Namespace == namespace {
+			inNamespace = append(inNamespace, subject)
+		}
+	}
+	return inNamespace
+}
+
+// rules returns the rules of the role a binding of namespace references.
+func (r *rbacResolver) rules(namespace string, roleRef rbacv1.RoleRef) ([]rbacv1.PolicyRule, bool, error) {
+	if roleRef.Kind == "ClusterRole" {
+		clusterRole, ok := r.clusterRoles[roleRef.Name]
+		return clusterRole.Rules, ok, nil
+	}
+	key := namespace + "/" + roleRef.Name
+	if role, ok := r.roles[key]; ok {
+		return role.Rules, true, nil
+	}
+	// The roles of other namespaces are not listed with a namespace filter.
+	role, err := r.a.Client.GetClient().RbacV1().Roles(namespace).Get(r.a.Context, roleRef.Name, metav1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	r.roles[key] = *role
+	return role.Rules, true, nil
+}
+
+func (r *rbacResolver) serviceAccountExists(namespace, name string) (bool, error) {
+	key := namespace + "/" + name
+	if exists, ok := r.serviceAccounts[key]; ok {
+		return exists, nil
+	}
+	_, err := r.a.Client.GetClient().CoreV1().ServiceAccounts(namespace).Get(r.a.Context, name, metav1.GetOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return false, err
+	}
+	r.serviceAccounts[key] = err == nil
+	return err == nil, nil
+}
+
+func (r *rbacResolver) appendResult(results []common.Result, kind string, meta metav1.ObjectMeta, failures []common.Failure) []common.Result {
+	if len(failures) == 0 {
+		return results
+	}
+	name := meta.Name
+	if meta.Namespace != "" {
+		name = meta.Namespace + "/" + meta.Name
+	}
+	AnalyzerErrorsMetric.WithLabelValues(kind, meta.Name, meta.Namespace).Set(float64(len(failures)))
+	return append(results, common.Result{
+		Kind:  kind,
+		Name:  name,
+		Error: failures,
+	})
+}
+
+// ruleGrants reports whether rule grants one of verbs on resource of
+// apiGroup, directly or through wildcards. Rules limited to named objects
+// are scoped to those objects and not considered a risk.
+func ruleGrants(rule rbacv1.PolicyRule, apiGroup, resource string, verbs ...string) bool {
+	if len(rule.ResourceNames) > 0 {
+		return false
+	}
+	if !containsAny(rule.APIGroups, apiGroup) || !containsAny(rule.Resources, resource) {
+		return false
+	}
+	for _, verb := range verbs {
+		if containsAny(rule.Verbs, verb) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAny reports whether list holds value or the "*" wildcard.
+func containsAny(list []string, value string) bool {
+	for _, item := range list {
+		if item == value || item == rbacv1.ResourceAll {
+			return true
+		}
+	}
+	return false
+}
+
+func describeRule(rule rbacv1.PolicyRule) string {
+	groups := make([]string, len(rule.APIGroups))
+	for i, group := range rule.APIGroups {
+		if group == "" {
+			group = "core"
+		}
+		groups[i] = group
+	}
+	return fmt.Sprintf("rule %s on %s (%s)", strings.Join(rule.Verbs, ","), strings.Join(rule.Resources, ","), strings.Join(groups, ","))
+}
@@ -0,0 +1,200 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func rbacObjects() []runtime.Object {
+	serviceAccount := func(namespace, name string) rbacv1.Subject {
+		return rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Namespace: namespace, Name: name}
+	}
+	return []runtime.Object{
+		&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "deployer", Namespace: "ci"}},
+		&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "debugger", Namespace: "apps"}},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "secret-reader"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{"get"}},
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"get", "list"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			// Scoped to a single secret.
+			ObjectMeta: metav1.ObjectMeta{Name: "registry-credentials-reader"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{""}, Resources: []string{"secrets"}, ResourceNames: []string{"registry-credentials"}, Verbs: []string{"get"}},
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "webhook-admin"},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"admissionregistration.k8s.io"}, Resources: []string{"mutatingwebhookconfigurations"}, Verbs: []string{"patch"}},
+				{APIGroups: []string{""}, Resources: []string{"users"}, Verbs: []string{"impersonate"}},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "ops-admin"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.UserKind, Name: "alice@example.com"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			// Should not be reported, Kubernetes maintains it.
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster-admin", Annotations: map[string]string{bootstrapAnnotation: "true"}},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "cluster-admin"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "system:masters"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployer-secrets"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+			Subjects:   []rbacv1.Subject{serviceAccount("ci", "deployer"), serviceAccount("ci", "removed")},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployer-webhooks"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "webhook-admin"},
+			Subjects:   []rbacv1.Subject{serviceAccount("ci", "deployer")},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "deployer-registry"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "registry-credentials-reader"},
+			Subjects:   []rbacv1.Subject{serviceAccount("ci", "deployer")},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "stale"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "deleted"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.GroupKind, Name: "devs"}},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "exec", Namespace: "apps"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods", "pods/exec"}, Verbs: []string{"create"}}},
+		},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "leftover", Namespace: "apps"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "debugger-exec", Namespace: "apps"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "exec"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "debugger"}},
+		},
+		&rbacv1.RoleBinding{
+			// Reading secrets is only reported cluster-wide.
+			ObjectMeta: metav1.ObjectMeta{Name: "debugger-secrets", Namespace: "apps"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "secret-reader"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "debugger"}},
+		},
+	}
+}
+
+func TestRBACAnalyzer(t *testing.T) {
+	tests := []struct {
+		name          string
+		namespace     string
+		labelSelector string
+		expected      map[string][]string
+	}{
+		{
+			name: "all namespaces",
+			expected: map[string][]string{
+				"RBAC/ClusterRoleBinding ops-admin": {
+					"User alice@example.com → ClusterRoleBinding ops-admin → ClusterRole cluster-admin grants full control of the cluster",
+				},
+				"RBAC/ClusterRoleBinding deployer-secrets": {
+					"ServiceAccount ci/deployer → ClusterRoleBinding deployer-secrets → ClusterRole secret-reader → rule get,list on secrets (core): can read secrets in all namespaces",
+					"ClusterRoleBinding deployer-secrets binds ServiceAccount ci/removed which does not exist",
+				},
+				"RBAC/ClusterRoleBinding deployer-webhooks": {
+					"ServiceAccount ci/deployer → ClusterRoleBinding deployer-webhooks → ClusterRole webhook-admin → rule impersonate on users (core): can impersonate other users, groups or service accounts",
+					"ServiceAccount ci/deployer → ClusterRoleBinding deployer-webhooks → ClusterRole webhook-admin → rule patch on mutatingwebhookconfigurations (admissionregistration.k8s.io): can modify admission webhooks",
+				},
+				"RBAC/ClusterRoleBinding stale": {
+					"ClusterRoleBinding stale references ClusterRole deleted which does not exist",
+				},
+				"RBAC/RoleBinding apps/debugger-exec": {
+					"ServiceAccount apps/debugger → RoleBinding apps/debugger-exec → Role exec → rule create on pods,pods/exec (core): can exec into pods",
+					"ServiceAccount apps/debugger → RoleBinding apps/debugger-exec → Role exec → rule create on pods,pods/exec (core): can create pods, and so run them as any service account of their namespace",
+				},
+				"RBAC/Role apps/leftover": {
+					"Role apps/leftover is not referenced by any RoleBinding",
+				},
+			},
+		},
+		{
+			name:      "namespace filter",
+			namespace: "ci",
+			expected: map[string][]string{
+				"RBAC/ClusterRoleBinding deployer-secrets": {
+					"ServiceAccount ci/deployer → ClusterRoleBinding deployer-secrets → ClusterRole secret-reader → rule get,list on secrets (core): can read secrets in all namespaces",
+					"ClusterRoleBinding deployer-secrets binds ServiceAccount ci/removed which does not exist",
+				},
+				"RBAC/ClusterRoleBinding deployer-webhooks": {
+					"ServiceAccount ci/deployer → ClusterRoleBinding deployer-webhooks → ClusterRole webhook-admin → rule impersonate on users (core): can impersonate other users, groups or service accounts",
+					"ServiceAccount ci/deployer → ClusterRoleBinding deployer-webhooks → ClusterRole webhook-admin → rule patch on mutatingwebhookconfigurations (admissionregistration.k8s.io): can modify admission webhooks",
+				},
+			},
+		},
+		{
+			// Role exec stays bound by a RoleBinding without the label.
+			name:          "label selector",
+			labelSelector: "team=ops",
+			expected: map[string][]string{
+				"RBAC/Role apps/leftover": {
+					"Role apps/leftover is not referenced by any RoleBinding",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := RBACAnalyzer{}.Analyze(common.Analyzer{
+				Client:        &kubernetes.Client{Client: fake.NewSimpleClientset(rbacObjects()...)},
+				Context:       context.Background(),
+				Namespace:     tt.namespace,
+				LabelSelector: tt.labelSelector,
+			})
+			require.NoError(t, err)
+
+			got := map[string][]string{}
+			for _, result := range results {
+				var texts []string
+				for _, failure := range result.Error {
+					texts = append(texts, failure.Text)
+				}
+				sort.Strings(texts)
+				got[result.Kind+" "+result.Name] = texts
+			}
+			for _, texts := range tt.expected {
+				sort.Strings(texts)
+			}
+			require.Equal(t, tt.expected, got)
+		})
+	}
+}
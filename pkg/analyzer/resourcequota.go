@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// quotaNearExhaustion is the share of a quota above which it is reported.
+const quotaNearExhaustion = 0.9
+
+// exceededQuota matches the quota named in the error of a rejected pod,
+// e.g. `pods "web-1" is forbidden: exceeded quota: compute, requested: ...`.
+var exceededQuota = regexp.MustCompile(`exceeded quota: ([^,\s]+)`)
+
+type ResourceQuotaAnalyzer struct{}
+
+func (ResourceQuotaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+	kind := "ResourceQuota"
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	quotaResults, err := analyzeResourceQuotas(a)
+	if err != nil {
+		return nil, err
+	}
+
+	limitRangeResults, err := analyzeLimitRanges(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(quotaResults, limitRangeResults...), nil
+}
+
+// analyzeResourceQuotas reports quotas at or near exhaustion, along with
+// the ReplicaSets, Jobs and other controllers that failed to create pods
+// because of them.
+func analyzeResourceQuotas(a common.Analyzer) ([]common.Result, error) {
+	kind := "ResourceQuota"
+
+	quotas, err := a.Client.GetClient().CoreV1().ResourceQuotas(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	failures := map[string][]common.Failure{}
+	metas := map[string]metav1.ObjectMeta{}
+	for _, quota := range quotas.Items {
+		key := fmt.Sprintf("%s/%s", quota.Namespace, quota.Name)
+		metas[key] = quota.ObjectMeta
+		for _, name := range sortedResourceNames(quota.Status.Hard) {
+			hard := quota.Status.Hard[name]
+			used, ok := quota.Status.Used[name]
+			if !ok || hard.IsZero() {
+				continue
+			}
+			ratio := float64(used.MilliValue()) / float64(hard.MilliValue())
+			switch {
+			case ratio >= 1:
+				failures[key] = append(failures[key], common.Failure{
+					Text:      fmt.Sprintf("ResourceQuota %s has exhausted %s: %s used of %s", quota.Name, name, used.String(), hard.String()),
+					Sensitive: []common.Sensitive{},
+				})
+			case ratio >= quotaNearExhaustion:
+				failures[key] = append(failures[key], common.Failure{
+					Text:      fmt.Sprintf("ResourceQuota %s is near exhaustion of %s: %s used of %s (%.0f%%)", quota.Name, name, used.String(), hard.String(), 100*ratio),
+					Sensitive: []common.Sensitive{},
+				})
+			}
+		}
+	}
+
+	rejected, err := quotaRejections(a)
+	if err != nil {
+		return nil, err
+	}
+	for key, objects := range rejected {
+		for _, object := range objects {
+			failures[key] = append(failures[key], common.Failure{
+				Text: fmt.Sprintf("%s %s cannot create pods: %s", object.kind, object.name, object.message),
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: object.name,
+						Masked:   util.MaskString(object.name),
+					},
+				},
+			})
+		}
+	}
+
+	var results []common.Result
+	for key, keyFailures := range failures {
+		namespace, name, _ := strings.Cut(key, "/")
+		result := common.Result{
+			Kind:  kind,
+			Name:  key,
+			Error: keyFailures,
+		}
+		if meta, ok := metas[key]; ok {
+			result.SetOwnerChain(a.GetOwners().Chain(a.Context, meta))
+		}
+		results = append(results, result)
+		AnalyzerErrorsMetric.WithLabelValues(kind, name, namespace).Set(float64(len(keyFailures)))
+	}
+	return results, nil
+}
+
+// rejectedObject is a controller whose pods were rejected by a quota.
+type rejectedObject struct {
+	kind    string
+	name    string
+	message string
+}
+
+// quotaRejections returns the controllers that failed to create pods
+// because of a quota, by namespace/name of the quota. They are found in
+// FailedCreate events and in the conditions of ReplicaSets, which outlive
+// the events.
+func quotaRejections(a common.Analyzer) (map[string][]rejectedObject, error) {
+	rejected := map[string][]rejectedObject{}
+	seen := map[string]bool{}
+	add := func(namespace, kind, name, message string) {
+		match := exceededQuota.FindStringSubmatch(message)
+		if match == nil {
+			return
+		}
+		key := fmt.Sprintf("%s/%s", namespace, match[1])
+		object := fmt.Sprintf("%s/%s", namespace, name)
+		if seen[key+"/"+kind+"/"+object] {
+			return
+		}
+		seen[key+"/"+kind+"/"+object] = true
+		rejected[key] = append(rejected[key], rejectedObject{kind: kind, name: object, message: strings.TrimPrefix(message, "Error creating: ")})
+	}
+
+	events, err := a.Client.GetClient().CoreV1().Events(a.Namespace).List(a.Context, metav1.ListOptions{
+		FieldSelector: "reason=FailedCreate",
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events.Items {
+		// Clients that ignore field selectors return all events.
+		if event.Reason == "FailedCreate" {
+			add(event.Namespace, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Message)
+		}
+	}
+
+	replicaSets, err := a.Client.GetClient().AppsV1().ReplicaSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range replicaSets.Items {
+		for _, condition := range rs.Status.Conditions {
+			if condition.Type == "ReplicaFailure" && condition.Reason == "FailedCreate" {
+				add(rs.Namespace, "ReplicaSet", rs.Name, condition.Message)
+			}
+		}
+	}
+	return rejected, nil
+}
+
+// analyzeLimitRanges reports workloads whose container resources conflict
+// with the LimitRanges of their namespace, so that their pods are rejected.
+func analyzeLimitRanges(a common.Analyzer) ([]common.Result, error) {
+	kind := "ResourceQuota/LimitRange"
+
+	limitRanges, err := a.Client.GetClient().CoreV1().LimitRanges(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	templates := map[string][]podTemplate{}
+	var results []common.Result
+	for _, limitRange := range limitRanges.Items {
+		namespaceTemplates, ok := templates[limitRange.Namespace]
+		if !ok {
+			if namespaceTemplates, err = listPodTemplates(a, limitRange.Namespace); err != nil {
+				return nil, err
+			}
+			templates[limitRange.Namespace] = namespaceTemplates
+		}
+
+		var failures []common.Failure
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != v1.LimitTypeContainer {
+				continue
+			}
+			for _, template := range namespaceTemplates {
+				for _, container := range template.spec.Containers {
+					for _, text := range limitRangeConflicts(item, container) {
+						workload := fmt.Sprintf("%s/%s", template.meta.Namespace, template.meta.Name)
+						failures = append(failures, common.Failure{
+							Text: fmt.Sprintf("%s %s container %s %s of LimitRange %s", template.kind, workload, container.Name, text, limitRange.Name),
+							Sensitive: []common.Sensitive{
+								{
+									Unmasked: workload,
+									Masked:   util.MaskString(workload),
+								},
+							},
+						})
+					}
+				}
+			}
+		}
+
+		if len(failures) > 0 {
+			result := common.Result{
+				Kind:  kind,
+				Name:  fmt.Sprintf("%s/%s", limitRange.Namespace, limitRange.Name),
+				Error: failures,
+			}
+			result.SetOwnerChain(a.GetOwners().Chain(a.Context, limitRange.ObjectMeta))
+			results = append(results, result)
+			AnalyzerErrorsMetric.WithLabelValues(kind, limitRange.Name, limitRange.Namespace).Set(float64(len(failures)))
+		}
+	}
+	return results, nil
+}
+
+// limitRangeConflicts describes how the resources of container break the
+// limits of item.
+func limitRangeConflicts(item v1.LimitRangeItem, container v1.Container) []string {
+	var conflicts []string
+	requests := container.Resources.Requests
+	limits := container.Resources.Limits
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		request, hasRequest := requests[name]
+		limit, hasLimit := limits[name]
+		if maximum, ok := item.Max[name]; ok {
+			if hasRequest && request.Cmp(maximum) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("requests %s %s above the maximum %s", name, request.String(), maximum.String()))
+			}
+			if hasLimit && limit.Cmp(maximum) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("limits %s %s above the maximum %s", name, limit.String(), maximum.String()))
+			}
+		}
+		if minimum, ok := item.Min[name]; ok && hasRequest && request.Cmp(minimum) < 0 {
+			conflicts = append(conflicts, fmt.Sprintf("requests %s %s below the minimum %s", name, request.String(), minimum.String()))
+		}
+		// Without a limit the container gets the default one, which must
+		// not be below its request.
+		if defaultLimit, ok := item.Default[name]; ok && hasRequest && !hasLimit && request.Cmp(defaultLimit) > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("requests %s %s above the default limit %s", name, request.String(), defaultLimit.String()))
+		}
+		if ratio, ok := item.MaxLimitRequestRatio[name]; ok && hasRequest && hasLimit && !request.IsZero() {
+			if float64(limit.MilliValue())/float64(request.MilliValue()) > float64(ratio.MilliValue())/1000 {
+				conflicts = append(conflicts, fmt.Sprintf("has a %s limit to request ratio above the maximum %s", name, ratio.String()))
+			}
+		}
+	}
+	return conflicts
+}
+
+func sortedResourceNames(list v1.ResourceList) []v1.ResourceName {
+	names := make([]v1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
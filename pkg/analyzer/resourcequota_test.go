@@ -0,0 +1,258 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+const quotaMessage = `pods "web-7d9-x" is forbidden: exceeded quota: compute, requested: requests.cpu=500m, used: requests.cpu=2, limited: requests.cpu=2`
+
+func TestResourceQuotaAnalyzer(t *testing.T) {
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: fake.NewSimpleClientset(
+				&v1.ResourceQuota{
+					ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "default"},
+					Status: v1.ResourceQuotaStatus{
+						Hard: v1.ResourceList{
+							v1.ResourceRequestsCPU:    resource.MustParse("2"),
+							v1.ResourceRequestsMemory: resource.MustParse("4Gi"),
+							v1.ResourcePods:           resource.MustParse("10"),
+						},
+						Used: v1.ResourceList{
+							v1.ResourceRequestsCPU:    resource.MustParse("2"),
+							v1.ResourceRequestsMemory: resource.MustParse("3800Mi"),
+							v1.ResourcePods:           resource.MustParse("3"),
+						},
+					},
+				},
+				&v1.ResourceQuota{
+					// Should not be reported, it has room left.
+					ObjectMeta: metav1.ObjectMeta{Name: "objects", Namespace: "default"},
+					Status: v1.ResourceQuotaStatus{
+						Hard: v1.ResourceList{v1.ResourceConfigMaps: resource.MustParse("10")},
+						Used: v1.ResourceList{v1.ResourceConfigMaps: resource.MustParse("1")},
+					},
+				},
+				&v1.ResourceQuota{
+					// Should not be discovered as it is not in the default namespace.
+					ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "other"},
+					Status: v1.ResourceQuotaStatus{
+						Hard: v1.ResourceList{v1.ResourcePods: resource.MustParse("1")},
+						Used: v1.ResourceList{v1.ResourcePods: resource.MustParse("1")},
+					},
+				},
+				&v1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "web-7d9.1", Namespace: "default"},
+					InvolvedObject: v1.ObjectReference{Kind: "ReplicaSet", Name: "web-7d9", Namespace: "default"},
+					Reason:         "FailedCreate",
+					Message:        "Error creating: " + quotaMessage,
+				},
+				&v1.Event{
+					ObjectMeta:     metav1.ObjectMeta{Name: "backup.1", Namespace: "default"},
+					InvolvedObject: v1.ObjectReference{Kind: "Job", Name: "backup", Namespace: "default"},
+					Reason:         "FailedCreate",
+					Message:        `Error creating: pods "backup-x" is forbidden: exceeded quota: compute, requested: requests.cpu=1`,
+				},
+				&v1.Event{
+					// Should not be linked, it is not about a quota.
+					ObjectMeta:     metav1.ObjectMeta{Name: "api.1", Namespace: "default"},
+					InvolvedObject: v1.ObjectReference{Kind: "ReplicaSet", Name: "api", Namespace: "default"},
+					Reason:         "FailedCreate",
+					Message:        `Error creating: pods "api-x" is forbidden: error looking up service account default/api`,
+				},
+				&appsv1.ReplicaSet{
+					// Should be linked once, it also has an event.
+					ObjectMeta: metav1.ObjectMeta{Name: "web-7d9", Namespace: "default"},
+					Status: appsv1.ReplicaSetStatus{
+						Conditions: []appsv1.ReplicaSetCondition{
+							{
+								Type:    appsv1.ReplicaSetReplicaFailure,
+								Reason:  "FailedCreate",
+								Message: quotaMessage,
+							},
+						},
+					},
+				},
+				&appsv1.ReplicaSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "worker-5f6", Namespace: "default"},
+					Status: appsv1.ReplicaSetStatus{
+						Conditions: []appsv1.ReplicaSetCondition{
+							{
+								Type:    appsv1.ReplicaSetReplicaFailure,
+								Reason:  "FailedCreate",
+								Message: `pods "worker-5f6-x" is forbidden: exceeded quota: compute, requested: requests.cpu=1`,
+							},
+						},
+					},
+				},
+			),
+		},
+		Context:   context.Background(),
+		Namespace: "default",
+	}
+
+	results, err := ResourceQuotaAnalyzer{}.Analyze(config)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "ResourceQuota", results[0].Kind)
+	require.Equal(t, "default/compute", results[0].Name)
+
+	var texts []string
+	for _, failure := range results[0].Error {
+		texts = append(texts, failure.Text)
+	}
+	sort.Strings(texts)
+	require.Equal(t, []string{
+		"Job default/backup cannot create pods: pods \"backup-x\" is forbidden: exceeded quota: compute, requested: requests.cpu=1",
+		"ReplicaSet default/web-7d9 cannot create pods: " + quotaMessage,
+		"ReplicaSet default/worker-5f6 cannot create pods: pods \"worker-5f6-x\" is forbidden: exceeded quota: compute, requested: requests.cpu=1",
+		"ResourceQuota compute has exhausted requests.cpu: 2 used of 2",
+		"ResourceQuota compute is near exhaustion of requests.memory: 3800Mi used of 4Gi (93%)",
+	}, texts)
+
+	// Only the FailedCreate events are listed.
+	var selectors []string
+	for _, action := range config.Client.Client.(*fake.Clientset).Actions() {
+		if list, ok := action.(clienttesting.ListAction); ok && list.GetResource().Resource == "events" {
+			selectors = append(selectors, list.GetListRestrictions().Fields.String())
+		}
+	}
+	require.Equal(t, []string{"reason=FailedCreate"}, selectors)
+}
+
+func TestResourceQuotaAnalyzerLimitRanges(t *testing.T) {
+	container := func(name string, requests, limits v1.ResourceList) v1.Container {
+		return v1.Container{Name: name, Resources: v1.ResourceRequirements{Requests: requests, Limits: limits}}
+	}
+
+	tests := []struct {
+		name     string
+		limit    v1.LimitRangeItem
+		template v1.PodSpec
+		failures []string
+	}{
+		{
+			name: "request above the default limit",
+			limit: v1.LimitRangeItem{
+				Type:    v1.LimitTypeContainer,
+				Default: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+			template: v1.PodSpec{Containers: []v1.Container{
+				container("app", v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}, nil),
+			}},
+			failures: []string{"Deployment default/web container app requests memory 1Gi above the default limit 512Mi of LimitRange limits"},
+		},
+		{
+			name: "request with its own limit",
+			limit: v1.LimitRangeItem{
+				Type:    v1.LimitTypeContainer,
+				Default: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+			},
+			template: v1.PodSpec{Containers: []v1.Container{
+				container("app", v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}, v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}),
+			}},
+		},
+		{
+			name: "outside min and max",
+			limit: v1.LimitRangeItem{
+				Type: v1.LimitTypeContainer,
+				Max:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+				Min:  v1.ResourceList{v1.ResourceMemory: resource.MustParse("64Mi")},
+			},
+			template: v1.PodSpec{Containers: []v1.Container{
+				container("app", v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("32Mi")}, v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}),
+			}},
+			failures: []string{
+				"Deployment default/web container app limits cpu 2 above the maximum 1 of LimitRange limits",
+				"Deployment default/web container app requests memory 32Mi below the minimum 64Mi of LimitRange limits",
+			},
+		},
+		{
+			name: "limit to request ratio",
+			limit: v1.LimitRangeItem{
+				Type:                 v1.LimitTypeContainer,
+				MaxLimitRequestRatio: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+			},
+			template: v1.PodSpec{Containers: []v1.Container{
+				container("app", v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}, v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}),
+			}},
+			failures: []string{"Deployment default/web container app has a cpu limit to request ratio above the maximum 2 of LimitRange limits"},
+		},
+		{
+			name: "pod limits are left out",
+			limit: v1.LimitRangeItem{
+				Type: v1.LimitTypePod,
+				Max:  v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+			},
+			template: v1.PodSpec{Containers: []v1.Container{
+				container("app", v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}, nil),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := common.Analyzer{
+				Client: &kubernetes.Client{
+					Client: fake.NewSimpleClientset(
+						&v1.LimitRange{
+							ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "default"},
+							Spec:       v1.LimitRangeSpec{Limits: []v1.LimitRangeItem{tt.limit}},
+						},
+						&appsv1.Deployment{
+							ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+							Spec:       appsv1.DeploymentSpec{Template: v1.PodTemplateSpec{Spec: tt.template}},
+						},
+						&batchv1.Job{
+							// Should not be checked as it is not in the namespace of the LimitRange.
+							ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "other"},
+							Spec:       batchv1.JobSpec{Template: v1.PodTemplateSpec{Spec: tt.template}},
+						},
+					),
+				},
+				Context: context.Background(),
+			}
+
+			results, err := ResourceQuotaAnalyzer{}.Analyze(config)
+			require.NoError(t, err)
+			if len(tt.failures) == 0 {
+				require.Empty(t, results)
+				return
+			}
+			require.Len(t, results, 1)
+			require.Equal(t, "ResourceQuota/LimitRange", results[0].Kind)
+			require.Equal(t, "default/limits", results[0].Name)
+			var texts []string
+			for _, failure := range results[0].Error {
+				texts = append(texts, failure.Text)
+			}
+			require.ElementsMatch(t, tt.failures, texts)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/ext"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/integration"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RulesConfigKey is the config key that holds the declarative rules.
+const RulesConfigKey = "rules"
+
+// ruleCostLimit bounds the work a single rule evaluation may do.
+const ruleCostLimit = 1000000
+
+// RuleConfiguration describes a declarative check. Every object of the given
+// kind for which Expression evaluates to false is reported.
+//
+//	rules:
+//	- name: DeploymentLimits
+//	  apiVersion: apps/v1
+//	  kind: Deployment
+//	  expression: object.spec.template.spec.containers.all(c, has(c.resources.limits))
+//	  message: Deployment has containers without resource limits
+type RuleConfiguration struct {
+	// Name is the analyzer name used with --filter and k8sgpt filters add.
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	APIVersion  string `mapstructure:"apiVersion"`
+	Kind        string `mapstructure:"kind"`
+	// Resource is the plural resource name, guessed from Kind when empty.
+	Resource      string `mapstructure:"resource"`
+	ClusterScoped bool   `mapstructure:"clusterScoped"`
+	// Expression is a CEL expression over `object` that must be true.
+	Expression string `mapstructure:"expression"`
+	Message    string `mapstructure:"message"`
+	// MessageExpression is a CEL expression over `object` that returns the
+	// failure text, taking precedence over Message.
+	MessageExpression string          `mapstructure:"messageExpression"`
+	Severity          common.Severity `mapstructure:"severity"`
+}
+
+// RuleAnalyzer evaluates a compiled RuleConfiguration.
+type RuleAnalyzer struct {
+	config     RuleConfiguration
+	resource   schema.GroupVersionResource
+	expression cel.Program
+	message    cel.Program
+}
+
+// NewRuleAnalyzer validates and compiles a rule.
+func NewRuleAnalyzer(config RuleConfiguration) (*RuleAnalyzer, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("rule name must be set")
+	}
+	if config.Kind == "" || config.APIVersion == "" {
+		return nil, fmt.Errorf("rule %s: apiVersion and kind must be set", config.Name)
+	}
+	if config.Expression == "" {
+		return nil, fmt.Errorf("rule %s: expression must be set", config.Name)
+	}
+	if config.Severity != "" {
+		severity, err := common.ParseSeverity(string(config.Severity))
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", config.Name, err)
+		}
+		config.Severity = severity
+	}
+
+	gv, err := schema.ParseGroupVersion(config.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: %w", config.Name, err)
+	}
+	resource, _ := meta.UnsafeGuessKindToResource(gv.WithKind(config.Kind))
+	if config.Resource != "" {
+		resource = gv.WithResource(config.Resource)
+	}
+
+	env, err := cel.NewEnv(cel.Variable("object", cel.DynType), ext.Strings())
+	if err != nil {
+		return nil, err
+	}
+	r := &RuleAnalyzer{config: config, resource: resource}
+	if r.expression, err = compileRuleExpression(env, config.Expression, cel.BoolType); err != nil {
+		return nil, fmt.Errorf("rule %s: expression: %w", config.Name, err)
+	}
+	if config.MessageExpression != "" {
+		if r.message, err = compileRuleExpression(env, config.MessageExpression, cel.StringType); err != nil {
+			return nil, fmt.Errorf("rule %s: messageExpression: %w", config.Name, err)
+		}
+	}
+	return r, nil
+}
+
+func compileRuleExpression(env *cel.Env, expression string, outputType *cel.Type) (cel.Program, error) {
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !outputType.IsExactType(ast.OutputType()) && !cel.DynType.IsExactType(ast.OutputType()) {
+		return nil, fmt.Errorf("must evaluate to %s, got %s", outputType, ast.OutputType())
+	}
+	return env.Program(ast, cel.CostLimit(ruleCostLimit))
+}
+
+// loadedRules holds the rules compiled from the configuration, so that they
+// are only compiled again when the configuration changes.
+var loadedRules struct {
+	sync.Mutex
+	loaded  bool
+	configs []RuleConfiguration
+	rules   []*RuleAnalyzer
+	err     error
+}
+
+// LoadRuleAnalyzers compiles the rules of the configuration file. The
+// result is kept until the rules in the configuration change.
+func LoadRuleAnalyzers() ([]*RuleAnalyzer, error) {
+	var configs []RuleConfiguration
+	if err := viper.UnmarshalKey(RulesConfigKey, &configs); err != nil {
+		return nil, fmt.Errorf("loading rules: %w", err)
+	}
+
+	loadedRules.Lock()
+	defer loadedRules.Unlock()
+	if loadedRules.loaded && reflect.DeepEqual(loadedRules.configs, configs) {
+		return loadedRules.rules, loadedRules.err
+	}
+	rules, err := compileRules(configs)
+	loadedRules.loaded = true
+	loadedRules.configs, loadedRules.rules, loadedRules.err = configs, rules, err
+	return rules, err
+}
+
+func compileRules(configs []RuleConfiguration) ([]*RuleAnalyzer, error) {
+	rules := make([]*RuleAnalyzer, 0, len(configs))
+	names := map[string]bool{}
+	integrationProvider := integration.NewIntegration()
+	for _, config := range configs {
+		_, core := coreAnalyzerMap[config.Name]
+		_, additional := additionalAnalyzerMap[config.Name]
+		// Integrations are checked whether they are active or not, so that
+		// activating one later does not shadow a rule.
+		_, err := integrationProvider.AnalyzerByIntegration(config.Name)
+		if core || additional || err == nil || names[config.Name] {
+			return nil, fmt.Errorf("rule %s: an analyzer with this name already exists", config.Name)
+		}
+		names[config.Name] = true
+
+		rule, err := NewRuleAnalyzer(config)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func (r *RuleAnalyzer) Name() string {
+	return r.config.Name
+}
+
+func (r *RuleAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+	kind := r.config.Name
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	if a.Client == nil || a.Client.GetDynamicClient() == nil {
+		return nil, fmt.Errorf("dynamic client is nil in rule %s", kind)
+	}
+
+	resource := a.Client.GetDynamicClient().Resource(r.resource)
+	listOptions := metav1.ListOptions{LabelSelector: a.LabelSelector}
+	var list *unstructured.UnstructuredList
+	var err error
+	if r.config.ClusterScoped {
+		list, err = resource.List(a.Context, listOptions)
+	} else {
+		list, err = resource.Namespace(a.Namespace).List(a.Context, listOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var results []common.Result
+	for _, item := range list.Items {
+		text, failed := r.evaluate(item.Object)
+		if !failed {
+			continue
+		}
+
+		name, namespace := item.GetName(), item.GetNamespace()
+		fullName := name
+		if namespace != "" {
+			fullName = fmt.Sprintf("%s/%s", namespace, name)
+		}
+
+		AnalyzerErrorsMetric.WithLabelValues(kind, name, namespace).Set(1)
+		result := common.Result{
+			Kind: fmt.Sprintf("%s/%s", kind, r.config.Kind),
+			Name: fullName,
+			Error: []common.Failure{{
+				Text:     text,
+				Severity: r.config.Severity,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: name,
+						Masked:   util.MaskString(name),
+					},
+				},
+			}},
+		}
+		result.SetOwnerChain(a.GetOwners().Chain(a.Context, metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       namespace,
+			OwnerReferences: item.GetOwnerReferences(),
+		}))
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// evaluate reports whether object violates the rule and the failure text.
+// Objects the expression cannot be evaluated on are reported as well, since
+// a missing field usually means the check does not hold.
+func (r *RuleAnalyzer) evaluate(object map[string]interface{}) (string, bool) {
+	vars := map[string]interface{}{"object": object}
+	out, _, err := r.expression.Eval(vars)
+	if err != nil {
+		return fmt.Sprintf("%s (rule %s could not be evaluated: %s)", r.defaultMessage(), r.config.Name, err), true
+	}
+	if passed, ok := out.Value().(bool); ok && passed {
+		return "", false
+	}
+
+	if r.message != nil {
+		if out, _, err := r.message.Eval(vars); err == nil {
+			if text, ok := out.Value().(string); ok && text != "" {
+				return text, true
+			}
+		}
+	}
+	return r.defaultMessage(), true
+}
+
+func (r *RuleAnalyzer) defaultMessage() string {
+	switch {
+	case r.config.Message != "":
+		return r.config.Message
+	case r.config.Description != "":
+		return r.config.Description
+	default:
+		return fmt.Sprintf("%s does not satisfy rule %s", r.config.Kind, r.config.Name)
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+func ruleDeployment(name string, limits v1.ResourceList) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: appsv1.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					Containers: []v1.Container{
+						{Name: "app", Image: "nginx", Resources: v1.ResourceRequirements{Limits: limits}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRuleAnalyzer(t *testing.T) {
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(scheme.Scheme,
+		ruleDeployment("limited", v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")}),
+		ruleDeployment("unlimited", nil),
+	)
+	config := common.Analyzer{
+		Client:    &kubernetes.Client{DynamicClient: dynamicClient},
+		Context:   context.Background(),
+		Namespace: "default",
+	}
+
+	tests := []struct {
+		name     string
+		rule     RuleConfiguration
+		severity common.Severity
+		expected []string
+	}{
+		{
+			name: "message",
+			rule: RuleConfiguration{
+				Name:       "DeploymentLimits",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Expression: "object.spec.template.spec.containers.all(c, has(c.resources.limits))",
+				Message:    "Deployment has containers without resource limits",
+				Severity:   common.SeverityHigh,
+			},
+			severity: common.SeverityHigh,
+			expected: []string{"Deployment has containers without resource limits"},
+		},
+		{
+			name: "mixed case severity",
+			rule: RuleConfiguration{
+				Name:       "DeploymentLimits",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Expression: "object.spec.template.spec.containers.all(c, has(c.resources.limits))",
+				Message:    "Deployment has containers without resource limits",
+				Severity:   "High",
+			},
+			severity: common.SeverityHigh,
+			expected: []string{"Deployment has containers without resource limits"},
+		},
+		{
+			name: "message expression",
+			rule: RuleConfiguration{
+				Name:              "DeploymentLimits",
+				APIVersion:        "apps/v1",
+				Kind:              "Deployment",
+				Expression:        "object.spec.template.spec.containers.all(c, has(c.resources.limits))",
+				MessageExpression: "'Deployment ' + object.metadata.name + ' has no limits'",
+			},
+			expected: []string{"Deployment unlimited has no limits"},
+		},
+		{
+			name: "evaluation error",
+			rule: RuleConfiguration{
+				Name:       "DeploymentStrategy",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Expression: "object.spec.strategy.type == 'RollingUpdate'",
+			},
+			expected: []string{
+				"Deployment does not satisfy rule DeploymentStrategy (rule DeploymentStrategy could not be evaluated: no such key: type)",
+				"Deployment does not satisfy rule DeploymentStrategy (rule DeploymentStrategy could not be evaluated: no such key: type)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := NewRuleAnalyzer(tt.rule)
+			require.NoError(t, err)
+			results, err := rule.Analyze(config)
+			require.NoError(t, err)
+
+			var texts []string
+			for _, result := range results {
+				require.Equal(t, tt.rule.Name+"/Deployment", result.Kind)
+				require.Len(t, result.Error, 1)
+				require.Equal(t, tt.severity, result.Error[0].Severity)
+				texts = append(texts, result.Error[0].Text)
+			}
+			require.ElementsMatch(t, tt.expected, texts)
+		})
+	}
+}
+
+func TestRuleAnalyzerOwnerChain(t *testing.T) {
+	controller := true
+	deployment := ruleDeployment("web", nil)
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "web-5d8f",
+			Namespace:       "default",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", Controller: &controller}},
+		},
+	}
+	config := common.Analyzer{
+		Client:    &kubernetes.Client{DynamicClient: dynamicfake.NewSimpleDynamicClient(scheme.Scheme, deployment, replicaSet)},
+		Context:   context.Background(),
+		Namespace: "default",
+	}
+
+	rule, err := NewRuleAnalyzer(RuleConfiguration{Name: "ReplicaSetReplicas", APIVersion: "apps/v1", Kind: "ReplicaSet", Expression: "has(object.spec.replicas)"})
+	require.NoError(t, err)
+	results, err := rule.Analyze(config)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, []string{"Deployment/web"}, results[0].OwnerChain)
+	require.Equal(t, "Deployment/web", results[0].ParentObject)
+}
+
+func TestNewRuleAnalyzerErrors(t *testing.T) {
+	valid := RuleConfiguration{Name: "IngressTLS", APIVersion: "networking.k8s.io/v1", Kind: "Ingress", Expression: "has(object.spec.tls)"}
+	_, err := NewRuleAnalyzer(valid)
+	require.NoError(t, err)
+
+	for name, mutate := range map[string]func(*RuleConfiguration){
+		"no name":          func(r *RuleConfiguration) { r.Name = "" },
+		"no kind":          func(r *RuleConfiguration) { r.Kind = "" },
+		"syntax":           func(r *RuleConfiguration) { r.Expression = "has(object.spec" },
+		"not a bool":       func(r *RuleConfiguration) { r.Expression = "'yes'" },
+		"message not text": func(r *RuleConfiguration) { r.MessageExpression = "1 + 1" },
+		"severity":         func(r *RuleConfiguration) { r.Severity = "urgent" },
+	} {
+		t.Run(name, func(t *testing.T) {
+			rule := valid
+			mutate(&rule)
+			_, err := NewRuleAnalyzer(rule)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestNewRuleAnalyzerOutputTypes(t *testing.T) {
+	for name, rule := range map[string]RuleConfiguration{
+		"static bool":      {Expression: "1 < 2"},
+		"dynamic bool":     {Expression: "object.spec.replicas > 1"},
+		"static message":   {Expression: "true", MessageExpression: "'replicas: ' + string(1)"},
+		"dynamic message":  {Expression: "true", MessageExpression: "object.metadata.name"},
+		"parsed attribute": {Expression: "has(object.spec.tls)"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			rule.Name, rule.APIVersion, rule.Kind = "Rule", "apps/v1", "Deployment"
+			_, err := NewRuleAnalyzer(rule)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestLoadRuleAnalyzers(t *testing.T) {
+	t.Cleanup(func() { viper.Set(RulesConfigKey, nil) })
+
+	viper.Set(RulesConfigKey, []map[string]interface{}{
+		{"name": "IngressTLS", "apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "expression": "has(object.spec.tls)"},
+	})
+	rules, err := LoadRuleAnalyzers()
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+
+	// The compiled rules are reused until the configuration changes.
+	again, err := LoadRuleAnalyzers()
+	require.NoError(t, err)
+	require.Same(t, rules[0], again[0])
+
+	_, analyzers, err := GetAnalyzerMap()
+	require.NoError(t, err)
+	require.Contains(t, analyzers, "IngressTLS")
+	_, additional, _, err := ListFilters()
+	require.NoError(t, err)
+	require.Contains(t, additional, "IngressTLS")
+
+	viper.Set(RulesConfigKey, []map[string]interface{}{
+		{"name": "Pod", "apiVersion": "v1", "kind": "Pod", "expression": "true"},
+	})
+	_, err = LoadRuleAnalyzers()
+	require.ErrorContains(t, err, "already exists")
+	// An invalid rule is returned as an error instead of exiting.
+	_, _, err = GetAnalyzerMap()
+	require.ErrorContains(t, err, "already exists")
+	_, _, _, err = ListFilters()
+	require.ErrorContains(t, err, "already exists")
+
+	// Integration analyzers are reserved even when they are not active.
+	viper.Set(RulesConfigKey, []map[string]interface{}{
+		{"name": "PrometheusConfigValidate", "apiVersion": "v1", "kind": "Pod", "expression": "true"},
+	})
+	_, err = LoadRuleAnalyzers()
+	require.ErrorContains(t, err, "already exists")
+}
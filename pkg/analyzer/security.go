@@ -45,12 +45,20 @@ func (SecurityAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	}
 	results = append(results, rbResults...)
 
+	// Evaluate workload pod templates against the Pod Security Standards
+	policies := &podSecurityPolicies{a: a, namespaces: map[string]namespacePodSecurity{}}
+	templateResults, evaluated, err := analyzeWorkloadPodSecurity(a, policies)
+	if err != nil {
+		return nil, err
+	}
+
 	// Analyze Pod Security Contexts
-	podResults, err := analyzePodSecurityContexts(a)
+	podResults, err := analyzePodSecurityContexts(a, policies, evaluated)
 	if err != nil {
 		return nil, err
 	}
 	results = append(results, podResults...)
+	results = append(results, templateResults...)
 
 	return results, nil
 }
@@ -70,13 +78,13 @@ func analyzeServiceAccounts(a common.Analyzer) ([]common.Result, error) {
 
 		// Check for default service account usage
 		if sa.Name == "default" {
-			pods, err := a.Client.GetClient().CoreV1().Pods(sa.Namespace).List(a.Context, metav1.ListOptions{})
+			pods, err := a.GetLister().Pods(a.Context, sa.Namespace, "")
 			if err != nil {
 				continue
 			}
 
 			defaultSAUsers := []string{}
-			for _, pod := range pods.Items {
+			for _, pod := range pods {
 				if pod.Spec.ServiceAccountName == "default" {
 					defaultSAUsers = append(defaultSAUsers, pod.Name)
 				}
@@ -127,6 +135,7 @@ func analyzeRoleBindings(a common.Analyzer) ([]common.Result, error) {
 				failures = append(failures, common.Failure{
 					Text:      fmt.Sprintf("RoleBinding %s references Role %s which contains wildcard permissions - this is not recommended for security best practices", rb.Name, role.Name),
 					Sensitive: []common.Sensitive{},
+					Severity:  common.SeverityHigh,
 				})
 			}
 		}
@@ -144,17 +153,15 @@ func analyzeRoleBindings(a common.Analyzer) ([]common.Result, error) {
 	return results, nil
 }
 
-func analyzePodSecurityContexts(a common.Analyzer) ([]common.Result, error) {
+func analyzePodSecurityContexts(a common.Analyzer, policies *podSecurityPolicies, evaluated map[string]bool) ([]common.Result, error) {
 	var results []common.Result
 
-	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{
-		LabelSelector: a.LabelSelector,
-	})
+	pods, err := a.GetLister().Pods(a.Context, a.Namespace, a.LabelSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pod := range pods.Items {
+	for _, pod := range pods {
 		var failures []common.Failure
 
 		// Check for privileged containers first (most critical)
@@ -164,6 +171,7 @@ func analyzePodSecurityContexts(a common.Analyzer) ([]common.Result, error) {
 				failures = append(failures, common.Failure{
 					Text:      fmt.Sprintf("Container %s in pod %s is running as privileged which poses security risks", container.Name, pod.Name),
 					Sensitive: []common.Sensitive{},
+					Severity:  common.SeverityHigh,
 				})
 				hasPrivilegedContainer = true
 				break
@@ -175,22 +183,92 @@ func analyzePodSecurityContexts(a common.Analyzer) ([]common.Result, error) {
 			failures = append(failures, common.Failure{
 				Text:      fmt.Sprintf("Pod %s does not have a security context defined which may pose security risks", pod.Name),
 				Sensitive: []common.Sensitive{},
+				Severity:  common.SeverityLow,
 			})
 		}
 
+		if len(failures) > 0 {
+			failures = failures[:1]
+		}
+
+		// Pods of workloads are evaluated through their template
+		if !podOfEvaluatedWorkload(a, pod.ObjectMeta, evaluated) {
+			failures = append(failures, policies.failures(pod.Namespace, pod.Annotations, pod.Spec)...)
+		}
+
 		if len(failures) > 0 {
 			results = append(results, common.Result{
 				Kind:  "Security/Pod",
 				Name:  fmt.Sprintf("%s/%s", pod.Namespace, pod.Name),
-				Error: failures[:1],
+				Error: failures,
 			})
-			AnalyzerErrorsMetric.WithLabelValues("Security/Pod", pod.Name, pod.Namespace).Set(1)
+			AnalyzerErrorsMetric.WithLabelValues("Security/Pod", pod.Name, pod.Namespace).Set(float64(len(failures)))
 		}
 	}
 
 	return results, nil
 }
 
+// analyzeWorkloadPodSecurity evaluates the pod templates of workloads
+// against the Pod Security Standards, once per workload. It also returns the
+// workloads it evaluated, keyed by workloadKey.
+func analyzeWorkloadPodSecurity(a common.Analyzer, policies *podSecurityPolicies) ([]common.Result, map[string]bool, error) {
+	var results []common.Result
+
+	templates, err := listPodTemplates(a, a.Namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	evaluated := map[string]bool{}
+	for _, template := range templates {
+		evaluated[workloadKey(template.meta.Namespace, template.kind+"/"+template.meta.Name)] = true
+		failures := policies.failures(template.meta.Namespace, template.annotations, template.spec)
+		if len(failures) > 0 {
+			kind := "Security/" + template.kind
+			result := common.Result{
+				Kind:  kind,
+				Name:  fmt.Sprintf("%s/%s", template.meta.Namespace, template.meta.Name),
+				Error: failures,
+			}
+			result.SetOwnerChain(a.GetOwners().Chain(a.Context, template.meta))
+			results = append(results, result)
+			AnalyzerErrorsMetric.WithLabelValues(kind, template.meta.Name, template.meta.Namespace).Set(float64(len(failures)))
+		}
+	}
+
+	return results, evaluated, nil
+}
+
+// workloadKey identifies a workload by its namespace and its Kind/name as
+// found in owner chains.
+func workloadKey(namespace, owner string) string {
+	return namespace + "/" + owner
+}
+
+// podOfEvaluatedWorkload reports whether the pod template of the workload
+// that manages a pod has been evaluated in this run. Pods of ReplicaSets and
+// Jobs are only covered when the ReplicaSet or Job is itself managed by an
+// evaluated Deployment or CronJob, so standalone ReplicaSets and workloads
+// left out by the label selector are evaluated through their pods.
+func podOfEvaluatedWorkload(a common.Analyzer, meta metav1.ObjectMeta, evaluated map[string]bool) bool {
+	owner := metav1.GetControllerOfNoCopy(&meta)
+	if owner == nil {
+		return false
+	}
+	switch owner.Kind {
+	case "StatefulSet", "DaemonSet":
+		return evaluated[workloadKey(meta.Namespace, owner.Kind+"/"+owner.Name)]
+	case "ReplicaSet", "Job":
+		if evaluated[workloadKey(meta.Namespace, owner.Kind+"/"+owner.Name)] {
+			return true
+		}
+		chain := a.GetOwners().Chain(a.Context, meta)
+		return len(chain) > 1 && evaluated[workloadKey(meta.Namespace, chain[1])]
+	}
+	return false
+}
+
 func containsWildcard(slice []string) bool {
 	for _, item := range slice {
 		if item == "*" {
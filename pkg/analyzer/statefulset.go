@@ -53,7 +53,7 @@ func (StatefulSetAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 		// get serviceName
 		serviceName := sts.Spec.ServiceName
-		_, err := a.Client.GetClient().CoreV1().Services(sts.Namespace).Get(a.Context, serviceName, metav1.GetOptions{})
+		_, err := a.GetLister().Service(a.Context, sts.Namespace, serviceName)
 		if err != nil {
 			doc := apiDoc.GetApiDocV2("spec.serviceName")
 
@@ -145,10 +145,7 @@ func (StatefulSetAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Error: value.FailureDetails,
 		}
 
-		parent, found := util.GetParent(a.Client, value.StatefulSet.ObjectMeta)
-		if found {
-			currentAnalysis.ParentObject = parent
-		}
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.StatefulSet.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -159,14 +159,12 @@ func analyzePersistentVolumes(a common.Analyzer) ([]common.Result, error) {
 func analyzePersistentVolumeClaims(a common.Analyzer) ([]common.Result, error) {
 	var results []common.Result
 
-	pvcs, err := a.Client.GetClient().CoreV1().PersistentVolumeClaims(a.Namespace).List(a.Context, metav1.ListOptions{
-		LabelSelector: a.LabelSelector,
-	})
+	pvcs, err := a.GetLister().PersistentVolumeClaims(a.Context, a.Namespace, a.LabelSelector)
 	if err != nil {
 		return nil, err
 	}
 
-	for _, pvc := range pvcs.Items {
+	for _, pvc := range pvcs {
 		var failures []common.Failure
 
 		// Check for PVC state issues first (most critical)
@@ -0,0 +1,242 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// WorkloadAnalyzer reviews the pod templates of workloads: resources,
+// probes and images. It reports once per workload rather than per pod.
+type WorkloadAnalyzer struct{}
+
+func (WorkloadAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+	kind := "Workload"
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	templates, err := listPodTemplates(a, a.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []common.Result
+	for _, template := range templates {
+		var failures []common.Failure
+		for _, text := range reviewPodTemplate(template) {
+			failures = append(failures, common.Failure{
+				Text:      text,
+				Sensitive: []common.Sensitive{},
+			})
+		}
+		if len(failures) == 0 {
+			continue
+		}
+
+		resultKind := kind + "/" + template.kind
+		result := common.Result{
+			Kind:  resultKind,
+			Name:  fmt.Sprintf("%s/%s", template.meta.Namespace, template.meta.Name),
+			Error: failures,
+		}
+		result.SetOwnerChain(a.GetOwners().Chain(a.Context, template.meta))
+		results = append(results, result)
+		AnalyzerErrorsMetric.WithLabelValues(resultKind, template.meta.Name, template.meta.Namespace).Set(float64(len(failures)))
+	}
+	return results, nil
+}
+
+// reviewPodTemplate describes the hygiene issues of a pod template.
+func reviewPodTemplate(template podTemplate) []string {
+	var issues []string
+	// Jobs run to completion, so they are not probed.
+	longRunning := template.kind != "Job" && template.kind != "CronJob"
+	for _, container := range template.spec.InitContainers {
+		issues = append(issues, reviewImage(container)...)
+	}
+	for _, container := range template.spec.Containers {
+		if missing := missingResources(container); len(missing) > 0 {
+			issues = append(issues, fmt.Sprintf("Container %s does not set %s", container.Name, strings.Join(missing, ", ")))
+		}
+		if longRunning {
+			if container.ReadinessProbe == nil {
+				issues = append(issues, fmt.Sprintf("Container %s has no readiness probe", container.Name))
+			}
+			if container.LivenessProbe == nil {
+				issues = append(issues, fmt.Sprintf("Container %s has no liveness probe", container.Name))
+			}
+		}
+		for _, probe := range []struct {
+			name  string
+			probe *v1.Probe
+		}{
+			{"liveness", container.LivenessProbe},
+			{"readiness", container.ReadinessProbe},
+			{"startup", container.StartupProbe},
+		} {
+			if issue := reviewProbePort(container, probe.probe); issue != "" {
+				issues = append(issues, fmt.Sprintf("Container %s %s probe %s", container.Name, probe.name, issue))
+			}
+		}
+		issues = append(issues, reviewImage(container)...)
+	}
+	return issues
+}
+
+// missingResources lists the requests and limits container does not set.
+func missingResources(container v1.Container) []string {
+	var missing []string
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		if _, ok := container.Resources.Requests[name]; !ok {
+			missing = append(missing, fmt.Sprintf("a %s request", name))
+		}
+	}
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		if _, ok := container.Resources.Limits[name]; !ok {
+			missing = append(missing, fmt.Sprintf("a %s limit", name))
+		}
+	}
+	return missing
+}
+
+// reviewProbePort describes why the port of probe does not match the
+// ports declared by container, or returns "".
+func reviewProbePort(container v1.Container, probe *v1.Probe) string {
+	if probe == nil {
+		return ""
+	}
+	var port intstr.IntOrString
+	switch {
+	case probe.HTTPGet != nil:
+		port = probe.HTTPGet.Port
+	case probe.TCPSocket != nil:
+		port = probe.TCPSocket.Port
+	case probe.GRPC != nil:
+		port = intstr.FromInt32(probe.GRPC.Port)
+	default:
+		return ""
+	}
+	for _, declared := range container.Ports {
+		if port.Type == intstr.String && declared.Name == port.StrVal {
+			return ""
+		}
+		if port.Type == intstr.Int && declared.ContainerPort == port.IntVal {
+			return ""
+		}
+	}
+	if port.Type == intstr.String {
+		return fmt.Sprintf("uses port %s which is not a named port of the container", port.StrVal)
+	}
+	return fmt.Sprintf("uses port %d which is not a declared container port", port.IntVal)
+}
+
+// reviewImage describes the issues of the image of container: floating
+// tags, missing digests and pulls of pinned digests.
+func reviewImage(container v1.Container) []string {
+	var issues []string
+	image, digest, pinned := strings.Cut(container.Image, "@")
+	tag := ""
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		tag = image[i+1:]
+	}
+	switch {
+	case pinned:
+		if container.ImagePullPolicy == v1.PullAlways {
+			issues = append(issues, fmt.Sprintf("Container %s pulls image %s pinned to digest %s with imagePullPolicy Always, IfNotPresent avoids a registry round trip", container.Name, image, digest))
+		}
+	case tag == "" || tag == "latest":
+		issues = append(issues, fmt.Sprintf("Container %s uses image %s with the latest tag, pin a version or a digest", container.Name, container.Image))
+	default:
+		issues = append(issues, fmt.Sprintf("Container %s uses image %s which is not pinned to a digest", container.Name, container.Image))
+	}
+	return issues
+}
+
+// podTemplate is the pod template of a workload.
+type podTemplate struct {
+	kind        string
+	meta        metav1.ObjectMeta
+	annotations map[string]string // Annotations of the pod template
+	spec        v1.PodSpec
+}
+
+// listPodTemplates returns the pod templates of the Deployments,
+// StatefulSets, DaemonSets, Jobs and CronJobs of namespace. Jobs created by
+// a CronJob are left out, its template covers them.
+func listPodTemplates(a common.Analyzer, namespace string) ([]podTemplate, error) {
+	var templates []podTemplate
+	client := a.Client.GetClient()
+	options := metav1.ListOptions{LabelSelector: a.LabelSelector}
+
+	deployments, err := client.AppsV1().Deployments(namespace).List(a.Context, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		templates = append(templates, podTemplate{kind: "Deployment", meta: deployment.ObjectMeta, annotations: deployment.Spec.Template.Annotations, spec: deployment.Spec.Template.Spec})
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(namespace).List(a.Context, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, statefulSet := range statefulSets.Items {
+		templates = append(templates, podTemplate{kind: "StatefulSet", meta: statefulSet.ObjectMeta, annotations: statefulSet.Spec.Template.Annotations, spec: statefulSet.Spec.Template.Spec})
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(a.Context, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, daemonSet := range daemonSets.Items {
+		templates = append(templates, podTemplate{kind: "DaemonSet", meta: daemonSet.ObjectMeta, annotations: daemonSet.Spec.Template.Annotations, spec: daemonSet.Spec.Template.Spec})
+	}
+
+	jobs, err := client.BatchV1().Jobs(namespace).List(a.Context, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs.Items {
+		if ownedByCronJob(job.ObjectMeta) {
+			continue
+		}
+		templates = append(templates, podTemplate{kind: "Job", meta: job.ObjectMeta, annotations: job.Spec.Template.Annotations, spec: job.Spec.Template.Spec})
+	}
+
+	cronJobs, err := client.BatchV1().CronJobs(namespace).List(a.Context, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, cronJob := range cronJobs.Items {
+		templates = append(templates, podTemplate{kind: "CronJob", meta: cronJob.ObjectMeta, annotations: cronJob.Spec.JobTemplate.Spec.Template.Annotations, spec: cronJob.Spec.JobTemplate.Spec.Template.Spec})
+	}
+	return templates, nil
+}
+
+func ownedByCronJob(meta metav1.ObjectMeta) bool {
+	for _, owner := range meta.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// healthyContainer passes every check of the Workload analyzer.
+func healthyContainer() v1.Container {
+	resources := v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("100m"),
+		v1.ResourceMemory: resource.MustParse("128Mi"),
+	}
+	probe := &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/healthz", Port: intstr.FromString("http")}}}
+	return v1.Container{
+		Name:           "app",
+		Image:          "registry.example.com/app:1.2.3@sha256:4b8e",
+		Ports:          []v1.ContainerPort{{Name: "http", ContainerPort: 8080}},
+		Resources:      v1.ResourceRequirements{Requests: resources, Limits: resources},
+		LivenessProbe:  probe,
+		ReadinessProbe: probe,
+	}
+}
+
+func TestReviewPodTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		kind   string
+		modify func(*v1.Container)
+		issues []string
+	}{
+		{
+			name:   "healthy",
+			kind:   "Deployment",
+			modify: func(*v1.Container) {},
+		},
+		{
+			name: "missing resources",
+			kind: "Deployment",
+			modify: func(c *v1.Container) {
+				c.Resources = v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")}}
+			},
+			issues: []string{"Container app does not set a memory request, a cpu limit, a memory limit"},
+		},
+		{
+			name: "missing probes",
+			kind: "StatefulSet",
+			modify: func(c *v1.Container) {
+				c.LivenessProbe = nil
+				c.ReadinessProbe = nil
+			},
+			issues: []string{
+				"Container app has no readiness probe",
+				"Container app has no liveness probe",
+			},
+		},
+		{
+			name: "jobs are not probed",
+			kind: "CronJob",
+			modify: func(c *v1.Container) {
+				c.LivenessProbe = nil
+				c.ReadinessProbe = nil
+			},
+		},
+		{
+			name: "probe ports",
+			kind: "Deployment",
+			modify: func(c *v1.Container) {
+				c.LivenessProbe = &v1.Probe{ProbeHandler: v1.ProbeHandler{TCPSocket: &v1.TCPSocketAction{Port: intstr.FromInt32(9090)}}}
+				c.StartupProbe = &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Port: intstr.FromString("metrics")}}}
+			},
+			issues: []string{
+				"Container app liveness probe uses port 9090 which is not a declared container port",
+				"Container app startup probe uses port metrics which is not a named port of the container",
+			},
+		},
+		{
+			name: "latest tag",
+			kind: "DaemonSet",
+			modify: func(c *v1.Container) {
+				c.Image = "registry.example.com:5000/app"
+			},
+			issues: []string{"Container app uses image registry.example.com:5000/app with the latest tag, pin a version or a digest"},
+		},
+		{
+			name: "no digest",
+			kind: "Deployment",
+			modify: func(c *v1.Container) {
+				c.Image = "nginx:1.27"
+			},
+			issues: []string{"Container app uses image nginx:1.27 which is not pinned to a digest"},
+		},
+		{
+			name: "always pulls a digest",
+			kind: "Deployment",
+			modify: func(c *v1.Container) {
+				c.ImagePullPolicy = v1.PullAlways
+			},
+			issues: []string{"Container app pulls image registry.example.com/app:1.2.3 pinned to digest sha256:4b8e with imagePullPolicy Always, IfNotPresent avoids a registry round trip"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := healthyContainer()
+			tt.modify(&container)
+			template := podTemplate{kind: tt.kind, spec: v1.PodSpec{Containers: []v1.Container{container}}}
+			require.Equal(t, tt.issues, reviewPodTemplate(template))
+		})
+	}
+}
+
+func TestWorkloadAnalyzer(t *testing.T) {
+	unpinned := healthyContainer()
+	unpinned.Image = "nginx:latest"
+	podSpec := func(container v1.Container) v1.PodTemplateSpec {
+		return v1.PodTemplateSpec{Spec: v1.PodSpec{Containers: []v1.Container{container}}}
+	}
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: fake.NewSimpleClientset(
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+					Spec:       appsv1.DeploymentSpec{Template: podSpec(unpinned)},
+				},
+				&appsv1.Deployment{
+					// Should not be reported, it passes every check.
+					ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"},
+					Spec:       appsv1.DeploymentSpec{Template: podSpec(healthyContainer())},
+				},
+				&batchv1.CronJob{
+					ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+					Spec: batchv1.CronJobSpec{JobTemplate: batchv1.JobTemplateSpec{
+						Spec: batchv1.JobSpec{Template: podSpec(unpinned)},
+					}},
+				},
+				&batchv1.Job{
+					// Should not be reported, its CronJob is.
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "backup-28000000",
+						Namespace:       "default",
+						OwnerReferences: []metav1.OwnerReference{{Kind: "CronJob", Name: "backup"}},
+					},
+					Spec: batchv1.JobSpec{Template: podSpec(unpinned)},
+				},
+				&appsv1.StatefulSet{
+					// Should not be discovered as it is not in the default namespace.
+					ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "other"},
+					Spec:       appsv1.StatefulSetSpec{Template: podSpec(unpinned)},
+				},
+			),
+		},
+		Context:   context.Background(),
+		Namespace: "default",
+	}
+
+	results, err := WorkloadAnalyzer{}.Analyze(config)
+	require.NoError(t, err)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Kind < results[j].Kind
+	})
+
+	require.Len(t, results, 2)
+	require.Equal(t, "Workload/CronJob", results[0].Kind)
+	require.Equal(t, "default/backup", results[0].Name)
+	require.Len(t, results[0].Error, 1)
+	require.Equal(t, "Workload/Deployment", results[1].Kind)
+	require.Equal(t, "default/web", results[1].Name)
+	require.Len(t, results[1].Error, 1)
+}
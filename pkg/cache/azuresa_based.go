@@ -102,9 +102,14 @@ func (s *AzureCache) List() ([]CacheObjectDetails, error) {
 		}
 
 		for _, blob := range resp.Segment.BlobItems {
+			var size int64
+			if blob.Properties.ContentLength != nil {
+				size = *blob.Properties.ContentLength
+			}
 			files = append(files, CacheObjectDetails{
 				Name:      *blob.Name,
 				UpdatedAt: *blob.Properties.LastModified,
+				Size:      size,
 			})
 		}
 	}
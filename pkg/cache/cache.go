@@ -107,6 +107,13 @@ func GetCacheConfiguration() (ICache, error) {
 }
 
 func AddRemoteCache(cacheInfo CacheProvider) error {
+	if cacheInfo.Policy.IsZero() {
+		current, err := ParseCacheConfiguration()
+		if err != nil {
+			return err
+		}
+		cacheInfo.Policy = current.Policy
+	}
 
 	viper.Set("cache", cacheInfo)
 
@@ -124,7 +131,7 @@ func RemoveRemoteCache() error {
 		return status.Error(codes.Internal, "cache unmarshal")
 	}
 
-	cacheInfo = CacheProvider{}
+	cacheInfo = CacheProvider{Policy: cacheInfo.Policy}
 	viper.Set("cache", cacheInfo)
 	err = viper.WriteConfig()
 	if err != nil {
@@ -134,3 +141,18 @@ func RemoveRemoteCache() error {
 	return nil
 
 }
+
+// SetPolicy stores the eviction policy of the cache in the configuration.
+func SetPolicy(policy Policy) error {
+	cacheInfo, err := ParseCacheConfiguration()
+	if err != nil {
+		return status.Error(codes.Internal, "cache unmarshal")
+	}
+
+	cacheInfo.Policy = policy
+	viper.Set("cache", cacheInfo)
+	if err := viper.WriteConfig(); err != nil {
+		return status.Error(codes.Internal, "unable to write config")
+	}
+	return nil
+}
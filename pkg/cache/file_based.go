@@ -1,21 +1,41 @@
 package cache
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/adrg/xdg"
-	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 )
 
 var _ (ICache) = (*FileBasedCache)(nil)
 
+// indexFile records the hits, misses and last use of the entries of the
+// file cache. List leaves out files starting with a dot.
+const indexFile = ".index.json"
+
 type FileBasedCache struct {
 	noCache bool
+	policy  Policy
+	mutex   sync.Mutex
+}
+
+// fileIndex is the content of indexFile.
+type fileIndex struct {
+	Hits     int64                `json:"hits"`
+	Misses   int64                `json:"misses"`
+	LastUsed map[string]time.Time `json:"lastUsed,omitempty"`
 }
 
 func (f *FileBasedCache) Configure(cacheInfo CacheProvider) error {
+	f.policy = cacheInfo.Policy
 	return nil
 }
 
@@ -36,6 +56,9 @@ func (*FileBasedCache) List() ([]CacheObjectDetails, error) {
 
 	var result []CacheObjectDetails
 	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
 		info, err := file.Info()
 		if err != nil {
 			return nil, err
@@ -43,13 +66,16 @@ func (*FileBasedCache) List() ([]CacheObjectDetails, error) {
 		result = append(result, CacheObjectDetails{
 			Name:      file.Name(),
 			UpdatedAt: info.ModTime(),
+			Size:      info.Size(),
 		})
 	}
 
 	return result, nil
 }
 
-func (*FileBasedCache) Exists(key string) bool {
+// Exists reports whether key holds an entry younger than the TTL. Expired
+// entries are removed, and a missing entry counts as a miss.
+func (f *FileBasedCache) Exists(key string) bool {
 	path, err := xdg.CacheFile(filepath.Join("k8sgpt", key))
 
 	if err != nil {
@@ -57,54 +83,88 @@ func (*FileBasedCache) Exists(key string) bool {
 		return false
 	}
 
-	exists, err := util.FileExists(path)
-
-	if err != nil {
+	info, err := os.Stat(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		fmt.Fprintln(os.Stderr, "warning: error while testing if cache key exists:", err)
 		return false
 	}
-
-	return exists
+	if err == nil && f.expired(info.ModTime(), time.Now()) {
+		_ = f.Remove(key)
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		f.updateIndex(func(index *fileIndex) { index.Misses++ })
+		return false
+	}
+	return true
 }
 
-func (*FileBasedCache) Load(key string) (string, error) {
+// Load returns the entry of key and counts a hit, which makes it the most
+// recently used entry.
+func (f *FileBasedCache) Load(key string) (string, error) {
 	path, err := xdg.CacheFile(filepath.Join("k8sgpt", key))
 
 	if err != nil {
 		return "", err
 	}
 
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if f.expired(info.ModTime(), time.Now()) {
+		_ = f.Remove(key)
+		return "", fmt.Errorf("cache entry %s expired: %w", key, fs.ErrNotExist)
+	}
+
 	data, err := os.ReadFile(path)
 
 	if err != nil {
 		return "", err
 	}
 
+	f.updateIndex(func(index *fileIndex) {
+		index.Hits++
+		index.LastUsed[key] = time.Now()
+	})
 	return string(data), nil
 }
 
-func (*FileBasedCache) Remove(key string) error {
-	path, err := xdg.CacheFile(filepath.Join("k8sgpt", key))
-
-	if err != nil {
+func (f *FileBasedCache) Remove(key string) error {
+	if err := f.removeFile(key); err != nil {
 		return err
 	}
 
-	if err := os.Remove(path); err != nil {
+	f.updateIndex(func(index *fileIndex) { delete(index.LastUsed, key) })
+	return nil
+}
+
+// removeFile removes the entry of key without updating the index.
+func (*FileBasedCache) removeFile(key string) error {
+	path, err := xdg.CacheFile(filepath.Join("k8sgpt", key))
+
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return os.Remove(path)
 }
 
-func (*FileBasedCache) Store(key string, data string) error {
+// Store writes the entry of key, then applies the policy: expired entries
+// are removed, and the least recently used ones while there are more than
+// MaxEntries or MaxSize.
+func (f *FileBasedCache) Store(key string, data string) error {
 	path, err := xdg.CacheFile(filepath.Join("k8sgpt", key))
 
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(path, []byte(data), 0600)
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		return err
+	}
+	f.updateIndex(func(index *fileIndex) { index.LastUsed[key] = time.Now() })
+	return f.evict(time.Now())
 }
 
 func (s *FileBasedCache) GetName() string {
@@ -114,3 +174,107 @@ func (s *FileBasedCache) GetName() string {
 func (s *FileBasedCache) DisableCache() {
 	s.noCache = true
 }
+
+func (f *FileBasedCache) expired(updatedAt, now time.Time) bool {
+	return f.policy.TTL > 0 && now.Sub(updatedAt) > f.policy.TTL
+}
+
+// evict removes the entries the policy does not allow. The index is read
+// and written once, however many entries are removed.
+func (f *FileBasedCache) evict(now time.Time) error {
+	if f.policy.IsZero() {
+		return nil
+	}
+	entries, err := f.List()
+	if err != nil {
+		return err
+	}
+	f.updateIndex(func(index *fileIndex) {
+		lastUsed := func(entry CacheObjectDetails) time.Time {
+			if used, ok := index.LastUsed[entry.Name]; ok && used.After(entry.UpdatedAt) {
+				return used
+			}
+			return entry.UpdatedAt
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			return lastUsed(entries[i]).Before(lastUsed(entries[j]))
+		})
+
+		var size int64
+		for _, entry := range entries {
+			size += entry.Size
+		}
+		count := len(entries)
+		for _, entry := range entries {
+			over := (f.policy.MaxEntries > 0 && count > f.policy.MaxEntries) || (f.policy.MaxSize > 0 && size > f.policy.MaxSize)
+			if !over && !f.expired(entry.UpdatedAt, now) {
+				continue
+			}
+			if err = f.removeFile(entry.Name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return
+			}
+			err = nil
+			delete(index.LastUsed, entry.Name)
+			count--
+			size -= entry.Size
+		}
+	})
+	return err
+}
+
+// HitStats returns the hits and misses counted since the cache was
+// created.
+func (f *FileBasedCache) HitStats() (int64, int64) {
+	index := f.loadIndex()
+	return index.Hits, index.Misses
+}
+
+func (f *FileBasedCache) loadIndex() fileIndex {
+	index := fileIndex{LastUsed: map[string]time.Time{}}
+	path, err := xdg.CacheFile(filepath.Join("k8sgpt", indexFile))
+	if err != nil {
+		return index
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return index
+	}
+	// A damaged index only loses the statistics.
+	_ = json.Unmarshal(data, &index)
+	if index.LastUsed == nil {
+		index.LastUsed = map[string]time.Time{}
+	}
+	return index
+}
+
+// updateIndex applies update to the index. The index is replaced through a
+// rename, so other processes never read it half written. It only serves
+// statistics and eviction, so failures to write it are ignored.
+func (f *FileBasedCache) updateIndex(update func(*fileIndex)) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	index := f.loadIndex()
+	update(&index)
+	path, err := xdg.CacheFile(filepath.Join("k8sgpt", indexFile))
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), indexFile+".*")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
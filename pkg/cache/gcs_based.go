@@ -109,6 +109,7 @@ func (s *GCSCache) List() ([]CacheObjectDetails, error) {
 		files = append(files, CacheObjectDetails{
 			Name:      attrs.Name,
 			UpdatedAt: attrs.Updated,
+			Size:      attrs.Size,
 		})
 	}
 	return files, nil
@@ -126,6 +126,7 @@ func (s *S3Cache) List() ([]CacheObjectDetails, error) {
 		keys = append(keys, CacheObjectDetails{
 			Name:      *item.Key,
 			UpdatedAt: *item.LastModified,
+			Size:      aws.Int64Value(item.Size),
 		})
 	}
 
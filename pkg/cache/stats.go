@@ -0,0 +1,73 @@
+package cache
+
+import "time"
+
+// AgeBucket counts the entries of a cache last updated within an age.
+type AgeBucket struct {
+	// Label describes the age, for example "< 1h".
+	Label string
+	// MaxAge is the upper bound of the bucket, zero for the last one.
+	MaxAge  time.Duration
+	Entries int
+	Size    int64
+}
+
+// Stats summarizes the content and the use of a cache.
+type Stats struct {
+	Entries int
+	Size    int64
+	Hits    int64
+	Misses  int64
+	// HitsRecorded is false for caches that do not count hits and misses.
+	HitsRecorded bool
+	Ages         []AgeBucket
+}
+
+// HitRatio returns the share of lookups answered from the cache.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// hitCounter is implemented by caches that count their hits and misses.
+type hitCounter interface {
+	HitStats() (hits int64, misses int64)
+}
+
+var ageBuckets = []AgeBucket{
+	{Label: "< 1h", MaxAge: time.Hour},
+	{Label: "< 1d", MaxAge: 24 * time.Hour},
+	{Label: "< 7d", MaxAge: 7 * 24 * time.Hour},
+	{Label: "< 30d", MaxAge: 30 * 24 * time.Hour},
+	{Label: ">= 30d"},
+}
+
+// GetStats lists the entries of c and sorts them by their age at now.
+func GetStats(c ICache, now time.Time) (Stats, error) {
+	entries, err := c.List()
+	if err != nil {
+		return Stats{}, err
+	}
+
+	stats := Stats{Ages: make([]AgeBucket, len(ageBuckets))}
+	copy(stats.Ages, ageBuckets)
+	for _, entry := range entries {
+		stats.Entries++
+		stats.Size += entry.Size
+		age := now.Sub(entry.UpdatedAt)
+		for i := range stats.Ages {
+			if stats.Ages[i].MaxAge == 0 || age < stats.Ages[i].MaxAge {
+				stats.Ages[i].Entries++
+				stats.Ages[i].Size += entry.Size
+				break
+			}
+		}
+	}
+	if counter, ok := c.(hitCounter); ok {
+		stats.Hits, stats.Misses = counter.HitStats()
+		stats.HitsRecorded = true
+	}
+	return stats, nil
+}
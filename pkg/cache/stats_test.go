@@ -0,0 +1,135 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/adrg/xdg"
+	"github.com/stretchr/testify/require"
+)
+
+// withCacheDir points the file cache to a temp dir.
+func withCacheDir(t *testing.T) string {
+	t.Helper()
+	t.Cleanup(xdg.Reload)
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	xdg.Reload()
+	return filepath.Join(dir, "k8sgpt")
+}
+
+func age(t *testing.T, dir, key string, d time.Duration) {
+	t.Helper()
+	at := time.Now().Add(-d)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, key), at, at))
+}
+
+func TestFileBasedCacheTTL(t *testing.T) {
+	dir := withCacheDir(t)
+	c := &FileBasedCache{}
+	require.NoError(t, c.Configure(CacheProvider{Policy: Policy{TTL: time.Hour}}))
+
+	require.NoError(t, c.Store("fresh", "a"))
+	require.NoError(t, c.Store("stale", "b"))
+	age(t, dir, "stale", 2*time.Hour)
+
+	require.True(t, c.Exists("fresh"))
+	require.False(t, c.Exists("stale"))
+	_, err := os.Stat(filepath.Join(dir, "stale"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, c.Store("stale", "b"))
+	age(t, dir, "stale", 2*time.Hour)
+	_, err = c.Load("stale")
+	require.Error(t, err)
+}
+
+func TestFileBasedCacheEviction(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		kept   []string
+	}{
+		{
+			name:   "max entries",
+			policy: Policy{MaxEntries: 2},
+			kept:   []string{"a", "c"},
+		},
+		{
+			name:   "max size",
+			policy: Policy{MaxSize: 8},
+			kept:   []string{"a", "c"},
+		},
+		{
+			name: "no limit",
+			kept: []string{"a", "b", "c"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := withCacheDir(t)
+			c := &FileBasedCache{}
+			require.NoError(t, c.Configure(CacheProvider{Policy: tt.policy}))
+
+			require.NoError(t, c.Store("a", "1234"))
+			require.NoError(t, c.Store("b", "1234"))
+			age(t, dir, "a", 3*time.Minute)
+			age(t, dir, "b", 2*time.Minute)
+			// Loading a makes b the least recently used entry.
+			_, err := c.Load("a")
+			require.NoError(t, err)
+			require.NoError(t, c.Store("c", "1234"))
+
+			items, err := c.List()
+			require.NoError(t, err)
+			var kept []string
+			for _, item := range items {
+				kept = append(kept, item.Name)
+			}
+			require.ElementsMatch(t, tt.kept, kept)
+
+			// Evicted entries leave the index, and no temporary index
+			// files are left behind.
+			var used []string
+			for key := range c.loadIndex().LastUsed {
+				used = append(used, key)
+			}
+			require.ElementsMatch(t, tt.kept, used)
+			files, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			require.Len(t, files, len(tt.kept)+1)
+		})
+	}
+}
+
+func TestGetStats(t *testing.T) {
+	dir := withCacheDir(t)
+	c := &FileBasedCache{}
+	require.NoError(t, c.Store("new", "12"))
+	require.NoError(t, c.Store("day", "1234"))
+	require.NoError(t, c.Store("old", "123456"))
+	age(t, dir, "day", 2*time.Hour)
+	age(t, dir, "old", 40*24*time.Hour)
+
+	require.True(t, c.Exists("new"))
+	_, err := c.Load("new")
+	require.NoError(t, err)
+	require.False(t, c.Exists("missing"))
+
+	stats, err := GetStats(c, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.Entries)
+	require.Equal(t, int64(12), stats.Size)
+	require.True(t, stats.HitsRecorded)
+	require.Equal(t, int64(1), stats.Hits)
+	require.Equal(t, int64(1), stats.Misses)
+	require.InDelta(t, 0.5, stats.HitRatio(), 0.001)
+
+	var entries []int
+	for _, bucket := range stats.Ages {
+		entries = append(entries, bucket.Entries)
+	}
+	require.Equal(t, []int{1, 1, 0, 0, 1}, entries)
+}
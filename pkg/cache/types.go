@@ -8,9 +8,25 @@ type CacheProvider struct {
 	Azure            AzureCacheConfiguration     `mapstructure:"azure" yaml:"azure,omitempty"`
 	S3               S3CacheConfiguration        `mapstructure:"s3" yaml:"s3,omitempty"`
 	Interplex        InterplexCacheConfiguration `mapstructure:"interplex" yaml:"interplex,omitempty"`
+	Policy           Policy                      `mapstructure:"policy" yaml:"policy,omitempty"`
+}
+
+// Policy bounds the entries of a cache. Zero values mean no limit. Only the
+// file cache enforces it, remote caches rely on the lifecycle rules of
+// their bucket or container.
+type Policy struct {
+	TTL        time.Duration `mapstructure:"ttl" yaml:"ttl,omitempty"`
+	MaxEntries int           `mapstructure:"maxEntries" yaml:"maxEntries,omitempty"`
+	MaxSize    int64         `mapstructure:"maxSize" yaml:"maxSize,omitempty"`
+}
+
+// IsZero reports whether the policy sets no limit.
+func (p Policy) IsZero() bool {
+	return p == Policy{}
 }
 
 type CacheObjectDetails struct {
 	Name      string
 	UpdatedAt time.Time
+	Size      int64
 }
@@ -0,0 +1,310 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Lister caches LIST calls for the resource kinds that several analyzers
+// read, so that each kind is fetched from the API server at most once per
+// namespace and label selector during an analysis. Label selectors are sent
+// to the server, unless a list without one is cached already.
+//
+// The returned objects are shared between analyzers and must not be
+// modified. A Lister is safe for concurrent use.
+type Lister struct {
+	client *kubernetes.Client
+	// informers serve the resources they hold from their caches, for the
+	// namespace they watch, see NewInformerLister.
+	informers         map[schema.GroupVersionResource]cache.GenericLister
+	informerNamespace string
+
+	mutex   sync.Mutex
+	entries map[listerKey]*listerEntry
+}
+
+type listerKey struct {
+	resource      string
+	namespace     string
+	labelSelector string
+}
+
+// listerEntry is a listing that is fetched once; done is closed when items
+// and err are set.
+type listerEntry struct {
+	done  chan struct{}
+	items interface{}
+	err   error
+}
+
+// NewLister returns an empty Lister for client.
+func NewLister(client *kubernetes.Client) *Lister {
+	return &Lister{
+		client:  client,
+		entries: map[listerKey]*listerEntry{},
+	}
+}
+
+// NewInformerLister returns an empty Lister for client that reads the
+// resources of informers from their synced caches instead of the API
+// server. The informers watch namespace, or all namespaces when it is empty.
+func NewInformerLister(client *kubernetes.Client, namespace string, informers map[schema.GroupVersionResource]cache.GenericLister) *Lister {
+	l := NewLister(client)
+	l.informers = informers
+	l.informerNamespace = namespace
+	return l
+}
+
+// informerItems returns the objects of resource in namespace from the cache
+// of its informer. ok is false when no informer covers the request.
+func informerItems[T any](l *Lister, gvr schema.GroupVersionResource, namespace string) (items []T, ok bool, err error) {
+	informer, watched := l.informers[gvr]
+	if !watched || (l.informerNamespace != "" && namespace != l.informerNamespace) {
+		return nil, false, nil
+	}
+	var objects []runtime.Object
+	if namespace == "" {
+		objects, err = informer.List(labels.Everything())
+	} else {
+		objects, err = informer.ByNamespace(namespace).List(labels.Everything())
+	}
+	if err != nil {
+		return nil, true, err
+	}
+	items = make([]T, 0, len(objects))
+	for _, object := range objects {
+		switch item := any(object).(type) {
+		case *T:
+			items = append(items, *item)
+		default:
+			// Resources read through the dynamic client are typed in the
+			// cache of a typed informer.
+			var target T
+			if _, unstructuredTarget := any(&target).(*unstructured.Unstructured); !unstructuredTarget {
+				return nil, true, fmt.Errorf("unexpected %T in the informer cache of %s", object, gvr.String())
+			}
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+			if err != nil {
+				return nil, true, err
+			}
+			any(&target).(*unstructured.Unstructured).Object = content
+			items = append(items, target)
+		}
+	}
+	return items, true, nil
+}
+
+// Pods lists the pods of namespace, or of all namespaces when namespace is
+// empty, that match labelSelector.
+func (l *Lister) Pods(ctx context.Context, namespace, labelSelector string) ([]v1.Pod, error) {
+	return listCached(ctx, l, "pods", namespace, labelSelector, func(ctx context.Context, namespace, labelSelector string) ([]v1.Pod, error) {
+		if items, ok, err := informerItems[v1.Pod](l, v1.SchemeGroupVersion.WithResource("pods"), namespace); ok {
+			return items, err
+		}
+		list, err := l.client.GetClient().CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// PersistentVolumeClaims lists the claims of namespace that match
+// labelSelector.
+func (l *Lister) PersistentVolumeClaims(ctx context.Context, namespace, labelSelector string) ([]v1.PersistentVolumeClaim, error) {
+	return listCached(ctx, l, "persistentvolumeclaims", namespace, labelSelector, func(ctx context.Context, namespace, labelSelector string) ([]v1.PersistentVolumeClaim, error) {
+		if items, ok, err := informerItems[v1.PersistentVolumeClaim](l, v1.SchemeGroupVersion.WithResource("persistentvolumeclaims"), namespace); ok {
+			return items, err
+		}
+		list, err := l.client.GetClient().CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Services lists the services of namespace that match labelSelector.
+func (l *Lister) Services(ctx context.Context, namespace, labelSelector string) ([]v1.Service, error) {
+	return listCached(ctx, l, "services", namespace, labelSelector, func(ctx context.Context, namespace, labelSelector string) ([]v1.Service, error) {
+		if items, ok, err := informerItems[v1.Service](l, v1.SchemeGroupVersion.WithResource("services"), namespace); ok {
+			return items, err
+		}
+		list, err := l.client.GetClient().CoreV1().Services(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Service returns a single service from the cached list of its namespace,
+// or a NotFound error like a GET call would.
+func (l *Lister) Service(ctx context.Context, namespace, name string) (*v1.Service, error) {
+	services, err := l.Services(ctx, namespace, "")
+	if err != nil {
+		return nil, err
+	}
+	for i := range services {
+		if services[i].Namespace == namespace && services[i].Name == name {
+			return &services[i], nil
+		}
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, name)
+}
+
+// Objects lists the objects of any resource in namespace that match
+// labelSelector through the dynamic client.
+func (l *Lister) Objects(ctx context.Context, gvr schema.GroupVersionResource, namespace, labelSelector string) ([]unstructured.Unstructured, error) {
+	return listCached(ctx, l, gvr.String(), namespace, labelSelector, func(ctx context.Context, namespace, labelSelector string) ([]unstructured.Unstructured, error) {
+		if items, ok, err := informerItems[unstructured.Unstructured](l, gvr, namespace); ok {
+			return items, err
+		}
+		list, err := l.client.GetDynamicClient().Resource(gvr).Namespace(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return nil, err
+		}
+		return list.Items, nil
+	})
+}
+
+// Annotations returns the annotations of a single object from the cached
+// list of its resource and namespace. Pods are read from the list the pod
+// analyzers share. found is false when the object does not exist.
+func (l *Lister) Annotations(ctx context.Context, gvr schema.GroupVersionResource, namespace, name string) (annotations map[string]string, found bool, err error) {
+	if gvr.Group == "" && gvr.Resource == "pods" {
+		pods, err := l.Pods(ctx, namespace, "")
+		if err != nil {
+			return nil, false, err
+		}
+		for i := range pods {
+			if pods[i].Namespace == namespace && pods[i].Name == name {
+				return pods[i].Annotations, true, nil
+			}
+		}
+		return nil, false, nil
+	}
+
+	objects, err := l.Objects(ctx, gvr, namespace, "")
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range objects {
+		if objects[i].GetNamespace() == namespace && objects[i].GetName() == name {
+			return objects[i].GetAnnotations(), true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// listCached returns the items of resource in namespace that match
+// labelSelector. A request is served from a list that covers it, e.g. the
+// all-namespaces list or the list without a selector, when that one has
+// already been fetched successfully; otherwise it is fetched on its own.
+func listCached[T any, PT interface {
+	*T
+	metav1.Object
+}](ctx context.Context, l *Lister, resource, namespace, labelSelector string, fetch func(ctx context.Context, namespace, labelSelector string) ([]T, error)) ([]T, error) {
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	own := listerKey{resource: resource, namespace: namespace, labelSelector: labelSelector}
+	var covering []listerKey
+	for _, key := range []listerKey{
+		{resource: resource},
+		{resource: resource, namespace: namespace},
+		{resource: resource, labelSelector: labelSelector},
+	} {
+		if key != own && !slices.Contains(covering, key) {
+			covering = append(covering, key)
+		}
+	}
+
+	l.mutex.Lock()
+	var candidates []*listerEntry
+	for _, key := range covering {
+		if entry, ok := l.entries[key]; ok {
+			candidates = append(candidates, entry)
+		}
+	}
+	l.mutex.Unlock()
+
+	// An error of a wider list, e.g. when listing all namespaces is
+	// forbidden, does not answer for a narrower one.
+	var entry *listerEntry
+	for _, candidate := range candidates {
+		<-candidate.done
+		if candidate.err == nil {
+			entry = candidate
+			break
+		}
+	}
+	if entry == nil {
+		l.mutex.Lock()
+		var ok bool
+		entry, ok = l.entries[own]
+		if !ok {
+			entry = &listerEntry{done: make(chan struct{})}
+			l.entries[own] = entry
+		}
+		l.mutex.Unlock()
+
+		if ok {
+			<-entry.done
+		} else {
+			entry.items, entry.err = fetch(ctx, namespace, labelSelector)
+			close(entry.done)
+		}
+	}
+	if entry.err != nil {
+		return nil, entry.err
+	}
+
+	var items []T
+	for _, item := range entry.items.([]T) {
+		obj := PT(&item)
+		if namespace != "" && obj.GetNamespace() != namespace {
+			continue
+		}
+		if selector.Matches(labels.Set(obj.GetLabels())) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+// GetLister returns the Lister shared by the analyzers of this analysis, or
+// an uncached one when the analysis did not set any.
+func (a Analyzer) GetLister() *Lister {
+	if a.Lister != nil {
+		return a.Lister
+	}
+	return NewLister(a.Client)
+}
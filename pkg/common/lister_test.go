@@ -0,0 +1,176 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func countListActions(clientset *fake.Clientset, resource string) int {
+	count := 0
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "list" && action.GetResource().Resource == resource {
+			count++
+		}
+	}
+	return count
+}
+
+func TestListerPods(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "kube-system"}},
+	)
+	lister := NewLister(&kubernetes.Client{Client: clientset})
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pods, err := lister.Pods(ctx, "", "")
+			require.NoError(t, err)
+			require.Len(t, pods, 3)
+		}()
+	}
+	wg.Wait()
+
+	pods, err := lister.Pods(ctx, "default", "app=web")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.Equal(t, "web", pods[0].Name)
+
+	pods, err = lister.Pods(ctx, "kube-system", "")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+
+	// Every request was served by the first all-namespaces listing.
+	require.Equal(t, 1, countListActions(clientset, "pods"))
+
+	_, err = lister.Pods(ctx, "", "app in (")
+	require.Error(t, err)
+}
+
+func TestListerPodsLabelSelector(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}}},
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}}},
+	)
+	lister := NewLister(&kubernetes.Client{Client: clientset})
+	ctx := context.Background()
+
+	// Without a cached list the selector is sent to the server.
+	pods, err := lister.Pods(ctx, "default", "app=web")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	actions := clientset.Actions()
+	require.Len(t, actions, 1)
+	require.Equal(t, "app=web", actions[0].(k8stesting.ListAction).GetListRestrictions().Labels.String())
+
+	// Another selector is a listing of its own.
+	pods, err = lister.Pods(ctx, "default", "app=db")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	require.Equal(t, "db", pods[0].Name)
+	require.Equal(t, 2, countListActions(clientset, "pods"))
+}
+
+func TestListerPodsErrors(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetNamespace() == "" {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", errors.New("cluster-wide listing is not allowed"))
+		}
+		return false, nil, nil
+	})
+	lister := NewLister(&kubernetes.Client{Client: clientset})
+	ctx := context.Background()
+
+	_, err := lister.Pods(ctx, "", "")
+	require.True(t, apierrors.IsForbidden(err))
+
+	// The failed all-namespaces listing does not answer for a namespace.
+	pods, err := lister.Pods(ctx, "default", "")
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+}
+
+func TestListerService(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "default"}},
+	)
+	lister := NewLister(&kubernetes.Client{Client: clientset})
+	ctx := context.Background()
+
+	service, err := lister.Service(ctx, "default", "api")
+	require.NoError(t, err)
+	require.Equal(t, "api", service.Name)
+
+	_, err = lister.Service(ctx, "default", "missing")
+	require.True(t, apierrors.IsNotFound(err))
+
+	require.Equal(t, 1, countListActions(clientset, "services"))
+}
+
+func TestInformerListerPods(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	require.NoError(t, indexer.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}}}))
+	require.NoError(t, indexer.Add(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}}))
+	pods := v1.SchemeGroupVersion.WithResource("pods")
+	lister := NewInformerLister(&kubernetes.Client{Client: clientset}, "default", map[schema.GroupVersionResource]cache.GenericLister{
+		pods: cache.NewGenericLister(indexer, pods.GroupResource()),
+	})
+	ctx := context.Background()
+
+	items, err := lister.Pods(ctx, "default", "app=web")
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Equal(t, "web", items[0].Name)
+
+	objects, err := lister.Objects(ctx, pods, "default", "")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+
+	// The cached pods were served without a LIST call; resources without an
+	// informer are still listed from the API server.
+	require.Equal(t, 0, countListActions(clientset, "pods"))
+	_, err = lister.Services(ctx, "default", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, countListActions(clientset, "services"))
+}
+
+func TestAnalyzerGetLister(t *testing.T) {
+	lister := NewLister(&kubernetes.Client{})
+	require.Same(t, lister, Analyzer{Lister: lister}.GetLister())
+	require.NotNil(t, Analyzer{}.GetLister())
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity describes how urgent a failure is. An empty Severity means the
+// analyzer did not classify the failure.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+var severityRank = map[Severity]int{
+	SeverityInfo:     1,
+	SeverityLow:      2,
+	SeverityMedium:   3,
+	SeverityHigh:     4,
+	SeverityCritical: 5,
+}
+
+// Severities lists the known severities from the most to the least severe.
+var Severities = []Severity{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// Rank returns the ordering weight of the severity; unknown or empty
+// severities rank lowest.
+func (s Severity) Rank() int {
+	return severityRank[s]
+}
+
+// AtLeast reports whether s is as severe as min.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.Rank() >= min.Rank()
+}
+
+// ParseSeverity converts a user supplied string into a Severity.
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := severityRank[severity]; !ok {
+		names := make([]string, 0, len(Severities))
+		for _, sev := range Severities {
+			names = append(names, string(sev))
+		}
+		return "", fmt.Errorf("unknown severity %q, must be one of %s", s, strings.Join(names, ", "))
+	}
+	return severity, nil
+}
+
+// MaxSeverity returns the highest severity among the result's failures.
+func (r Result) MaxSeverity() Severity {
+	var max Severity
+	for _, failure := range r.Error {
+		if failure.Severity.Rank() > max.Rank() {
+			max = failure.Severity
+		}
+	}
+	return max
+}
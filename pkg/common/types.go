@@ -20,6 +20,7 @@ import (
 	openapi_v2 "github.com/google/gnostic/openapiv2"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	keda "github.com/kedacore/keda/v2/apis/keda/v1alpha1"
 	kyverno "github.com/kyverno/policy-reporter-kyverno-plugin/pkg/crd/api/policyreport/v1alpha2"
 	regv1 "k8s.io/api/admissionregistration/v1"
@@ -45,6 +46,13 @@ type Analyzer struct {
 	PreAnalysis   map[string]PreAnalysis
 	Results       []Result
 	OpenapiSchema *openapi_v2.Document
+	// Lister is shared by all analyzers of an analysis run.
+	Lister *Lister
+	// Owners resolves and caches owner chains for all analyzers of a run.
+	Owners *util.OwnerResolver
+	// TargetVersion is the Kubernetes version the Deprecation analyzer
+	// checks upgrade readiness for; empty means the release after the server.
+	TargetVersion string
 }
 
 type PreAnalysis struct {
@@ -80,6 +88,45 @@ type Result struct {
 	Error        []Failure `json:"error"`
 	Details      string    `json:"details"`
 	ParentObject string    `json:"parentObject"`
+	Severity     Severity  `json:"severity,omitempty"`
+	// Unexplained is set when the result was left out of the AI explanation,
+	// e.g. because the token budget of the run was exhausted.
+	Unexplained bool `json:"unexplained,omitempty"`
+	// Count and Affected are set when identical failures of several objects
+	// with the same parent were collapsed into this result.
+	Count    int      `json:"count,omitempty"`
+	Affected []string `json:"affected,omitempty"`
+	// Explanation is the typed form of Details when structured explanations
+	// were requested and the reply matched the schema.
+	Explanation *ai.Explanation `json:"explanation,omitempty"`
+	// Provider is the AI provider that explained the result, which differs
+	// from the selected one when a fallback provider answered.
+	Provider string `json:"provider,omitempty"`
+	// OwnerChain lists the owners of the object from its direct owner to
+	// ParentObject, its root owner.
+	OwnerChain []string `json:"ownerChain,omitempty"`
+	// Transcript is the conversation in which the AI backend investigated
+	// the result with cluster tools, see analyze --agent.
+	Transcript []ai.ChatMessage `json:"transcript,omitempty"`
+}
+
+// SetOwnerChain records the owners of the object the result is about; the
+// last one becomes the ParentObject.
+func (r *Result) SetOwnerChain(chain []string) {
+	if len(chain) == 0 {
+		return
+	}
+	r.OwnerChain = chain
+	r.ParentObject = chain[len(chain)-1]
+}
+
+// GetOwners returns the OwnerResolver shared by the analyzers of this
+// analysis, or an uncached one when the analysis did not set any.
+func (a Analyzer) GetOwners() *util.OwnerResolver {
+	if a.Owners != nil {
+		return a.Owners
+	}
+	return util.NewOwnerResolver(a.Client)
 }
 
 type AnalysisStats struct {
@@ -91,6 +138,7 @@ type Failure struct {
 	Text          string
 	KubernetesDoc string
 	Sensitive     []Sensitive
+	Severity      Severity `json:",omitempty"`
 }
 
 type Sensitive struct {
@@ -0,0 +1,189 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+)
+
+const (
+	// ConfigKey is the config key that holds the ignore rules.
+	ConfigKey = "ignore_rules"
+	// Annotation lists the analyzers whose findings are ignored for the
+	// annotated object, e.g. "Pod,Log", or "*" for all of them.
+	Annotation = "k8sgpt.ai/ignore"
+)
+
+// Rule silences the failures it matches. Empty fields match anything, but
+// a rule needs at least one of Kind, Namespace, Name or Text.
+type Rule struct {
+	ID string `mapstructure:"id"`
+	// Kind is an analyzer name or result kind, e.g. ConfigMap or Security.
+	Kind      string `mapstructure:"kind"`
+	Namespace string `mapstructure:"namespace"`
+	// Name is a glob matched against the object name, or against
+	// "namespace/name" when it contains a slash.
+	Name string `mapstructure:"name"`
+	// Text must be contained in the failure text.
+	Text string `mapstructure:"text"`
+	// Until is a date (2006-01-02) or RFC 3339 time after which the rule no
+	// longer applies. A date still applies through that day, in UTC.
+	Until  string `mapstructure:"until"`
+	Reason string `mapstructure:"reason"`
+}
+
+// Validate checks that the rule is usable and sets its ID.
+func (r *Rule) Validate() error {
+	if r.Kind == "" && r.Namespace == "" && r.Name == "" && r.Text == "" {
+		return errors.New("an ignore rule needs at least one of kind, namespace, name or text")
+	}
+	if _, err := path.Match(r.Name, ""); err != nil {
+		return fmt.Errorf("invalid name pattern %q: %w", r.Name, err)
+	}
+	if _, err := r.Expiry(); err != nil {
+		return err
+	}
+	r.ID = r.key()
+	return nil
+}
+
+// key derives a short, stable ID from the matching fields of the rule, so
+// that adding the same rule twice can be detected.
+func (r Rule) key() string {
+	hash := sha256.Sum256([]byte(strings.Join([]string{r.Kind, r.Namespace, r.Name, r.Text}, "\x00")))
+	return hex.EncodeToString(hash[:])[:8]
+}
+
+// Expiry returns when the rule expires, or the zero time if it never does.
+func (r Rule) Expiry() (time.Time, error) {
+	if r.Until == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, r.Until); err == nil {
+		return t.AddDate(0, 0, 1), nil
+	}
+	t, err := time.Parse(time.RFC3339, r.Until)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid expiry %q, use YYYY-MM-DD or RFC 3339", r.Until)
+	}
+	return t, nil
+}
+
+// Expired reports whether the rule no longer applies at now. Rules with an
+// invalid expiry never apply.
+func (r Rule) Expired(now time.Time) bool {
+	expiry, err := r.Expiry()
+	return err != nil || (!expiry.IsZero() && !now.Before(expiry))
+}
+
+// Matches reports whether the rule silences failure of result.
+func (r Rule) Matches(result common.Result, failure common.Failure) bool {
+	if r.Kind != "" && !MatchesKind(r.Kind, result.Kind) {
+		return false
+	}
+	namespace, name := splitName(result.Name)
+	if r.Namespace != "" && r.Namespace != namespace {
+		return false
+	}
+	if r.Name != "" {
+		target := name
+		if strings.Contains(r.Name, "/") {
+			target = result.Name
+		}
+		if ok, _ := path.Match(r.Name, target); !ok {
+			return false
+		}
+	}
+	return r.Text == "" || strings.Contains(failure.Text, r.Text)
+}
+
+// MatchesKind compares an analyzer name or kind with the kind of a result.
+// Results of analyzers such as Security use "Security/Pod" as their kind, so
+// both parts are accepted.
+func MatchesKind(kind, resultKind string) bool {
+	if kind == "*" || strings.EqualFold(kind, resultKind) {
+		return true
+	}
+	analyzer, object, found := strings.Cut(resultKind, "/")
+	return found && (strings.EqualFold(kind, analyzer) || strings.EqualFold(kind, object))
+}
+
+// Apply removes the failures matched by any of the active rules and drops
+// the results that are left without failures.
+func Apply(rules []Rule, results []common.Result, now time.Time) []common.Result {
+	var active []Rule
+	for _, rule := range rules {
+		if !rule.Expired(now) {
+			active = append(active, rule)
+		}
+	}
+	if len(active) == 0 {
+		return results
+	}
+
+	kept := results[:0:0]
+	for _, result := range results {
+		var failures []common.Failure
+		for _, failure := range result.Error {
+			if !matchesAny(active, result, failure) {
+				failures = append(failures, failure)
+			}
+		}
+		if len(failures) == 0 && len(result.Error) > 0 {
+			continue
+		}
+		result.Error = failures
+		if result.Severity != "" {
+			result.Severity = result.MaxSeverity()
+		}
+		kept = append(kept, result)
+	}
+	return kept
+}
+
+func matchesAny(rules []Rule, result common.Result, failure common.Failure) bool {
+	for _, rule := range rules {
+		if rule.Matches(result, failure) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnnotationIgnores reports whether the value of the Annotation on an object
+// silences the findings of the named analyzer for that object.
+func AnnotationIgnores(value, analyzerName string) bool {
+	for _, kind := range strings.Split(value, ",") {
+		kind = strings.TrimSpace(kind)
+		if kind == "*" || strings.EqualFold(kind, analyzerName) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitName(name string) (namespace string, object string) {
+	if namespace, object, found := strings.Cut(name, "/"); found {
+		return namespace, object
+	}
+	return "", name
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ignore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleMatches(t *testing.T) {
+	unused := common.Failure{Text: "ConfigMap coredns is not used by any pods in the namespace"}
+	tests := []struct {
+		name   string
+		rule   Rule
+		result common.Result
+		want   bool
+	}{
+		{
+			name:   "kind and namespace",
+			rule:   Rule{Kind: "ConfigMap", Namespace: "kube-system"},
+			result: common.Result{Kind: "ConfigMap", Name: "kube-system/coredns"},
+			want:   true,
+		},
+		{
+			name:   "other namespace",
+			rule:   Rule{Kind: "ConfigMap", Namespace: "kube-system"},
+			result: common.Result{Kind: "ConfigMap", Name: "default/coredns"},
+		},
+		{
+			name:   "namespaced glob",
+			rule:   Rule{Kind: "pod", Name: "foo/*"},
+			result: common.Result{Kind: "Pod", Name: "foo/web-1"},
+			want:   true,
+		},
+		{
+			name:   "object glob",
+			rule:   Rule{Name: "core*"},
+			result: common.Result{Kind: "ConfigMap", Name: "kube-system/coredns"},
+			want:   true,
+		},
+		{
+			name:   "analyzer prefix",
+			rule:   Rule{Kind: "Security"},
+			result: common.Result{Kind: "Security/Pod", Name: "default/web"},
+			want:   true,
+		},
+		{
+			name:   "text mismatch",
+			rule:   Rule{Kind: "ConfigMap", Text: "is empty"},
+			result: common.Result{Kind: "ConfigMap", Name: "kube-system/coredns"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, tt.rule.Matches(tt.result, unused))
+		})
+	}
+}
+
+func TestRuleValidate(t *testing.T) {
+	rule := Rule{Kind: "Pod", Until: "2026-12-01"}
+	require.NoError(t, rule.Validate())
+	require.Len(t, rule.ID, 8)
+
+	require.Error(t, (&Rule{}).Validate())
+	require.Error(t, (&Rule{Name: "[a"}).Validate())
+	require.Error(t, (&Rule{Kind: "Pod", Until: "next week"}).Validate())
+}
+
+func TestRuleExpired(t *testing.T) {
+	// A date applies through the end of that day.
+	rule := Rule{Kind: "Pod", Until: "2026-12-01"}
+	require.False(t, rule.Expired(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)))
+	require.False(t, rule.Expired(time.Date(2026, 12, 1, 23, 59, 59, 0, time.UTC)))
+	require.True(t, rule.Expired(time.Date(2026, 12, 2, 0, 0, 0, 0, time.UTC)))
+
+	// A time applies until that instant.
+	rule = Rule{Kind: "Pod", Until: "2026-12-01T12:00:00Z"}
+	require.False(t, rule.Expired(time.Date(2026, 12, 1, 11, 59, 59, 0, time.UTC)))
+	require.True(t, rule.Expired(time.Date(2026, 12, 1, 12, 0, 0, 0, time.UTC)))
+
+	require.False(t, Rule{Kind: "Pod"}.Expired(time.Now()))
+	require.True(t, Rule{Kind: "Pod", Until: "next week"}.Expired(time.Now()))
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	results := []common.Result{
+		{Kind: "Pod", Name: "foo/web", Severity: common.SeverityCritical, Error: []common.Failure{
+			{Text: "crash loop", Severity: common.SeverityCritical},
+			{Text: "readiness probe failed", Severity: common.SeverityMedium},
+		}},
+		{Kind: "ConfigMap", Name: "kube-system/coredns", Error: []common.Failure{{Text: "not used"}}},
+		{Kind: "Node", Name: "node-1", Error: []common.Failure{{Text: "not ready"}}},
+	}
+	rules := []Rule{
+		{Kind: "Pod", Name: "foo/*", Text: "crash loop", Until: "2026-12-01"},
+		{Kind: "ConfigMap", Namespace: "kube-system"},
+		{Kind: "Node", Until: "2026-01-01"},
+	}
+
+	kept := Apply(rules, results, now)
+	require.Len(t, kept, 2)
+	require.Equal(t, []common.Failure{{Text: "readiness probe failed", Severity: common.SeverityMedium}}, kept[0].Error)
+	require.Equal(t, common.SeverityMedium, kept[0].Severity)
+	require.Equal(t, "node-1", kept[1].Name)
+
+	// Apply must not modify the results it was given.
+	require.Len(t, results[0].Error, 2)
+}
+
+func TestAnnotationIgnores(t *testing.T) {
+	require.True(t, AnnotationIgnores("Pod, Log", "Log"))
+	require.True(t, AnnotationIgnores("*", "Service"))
+	require.False(t, AnnotationIgnores("Pod", "Log"))
+}
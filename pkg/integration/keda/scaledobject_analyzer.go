@@ -140,8 +140,7 @@ func (s *ScaledObjectAnalyzer) Analyze(a common.Analyzer) ([]common.Result, erro
 			Error: value.FailureDetails,
 		}
 
-		parent, _ := util.GetParent(a.Client, value.ScaledObject.ObjectMeta)
-		currentAnalysis.ParentObject = parent
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.ScaledObject.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
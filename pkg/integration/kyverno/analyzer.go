@@ -19,7 +19,6 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
-	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 
 	"github.com/kyverno/policy-reporter-kyverno-plugin/pkg/crd/api/policyreport/v1alpha2"
 )
@@ -76,8 +75,7 @@ func (KyvernoAnalyzer) analyzePolicyReports(a common.Analyzer) ([]common.Result,
 			Error: value.FailureDetails,
 		}
 
-		parent, _ := util.GetParent(a.Client, value.KyvernoPolicyReport.ObjectMeta)
-		currentAnalysis.ParentObject = parent
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.KyvernoPolicyReport.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -130,8 +128,7 @@ func (t KyvernoAnalyzer) analyzeClusterPolicyReports(a common.Analyzer) ([]commo
 			Error: value.FailureDetails,
 		}
 
-		parent, _ := util.GetParent(a.Client, value.KyvernoClusterPolicyReport.ObjectMeta)
-		currentAnalysis.ParentObject = parent
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.KyvernoClusterPolicyReport.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
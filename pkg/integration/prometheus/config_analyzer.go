@@ -95,8 +95,7 @@ func (c *ConfigAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Name:  key,
 			Error: value.FailureDetails,
 		}
-		parent, _ := util.GetParent(a.Client, value.Pod.ObjectMeta)
-		currentAnalysis.ParentObject = parent
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Pod.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
@@ -4,7 +4,6 @@ import (
 	"fmt"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
-	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	discoverykube "github.com/prometheus/prometheus/discovery/kubernetes"
 	"gopkg.in/yaml.v2"
 )
@@ -76,8 +75,7 @@ func (r *RelabelAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			Name:  key,
 			Error: value.FailureDetails,
 		}
-		parent, _ := util.GetParent(a.Client, value.Pod.ObjectMeta)
-		currentAnalysis.ParentObject = parent
+		currentAnalysis.SetOwnerChain(a.GetOwners().Chain(a.Context, value.Pod.ObjectMeta))
 		a.Results = append(a.Results, currentAnalysis)
 	}
 
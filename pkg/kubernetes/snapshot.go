@@ -0,0 +1,366 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+	ctrlfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// NewSnapshotClient builds a Client backed by in-memory fake clients that
+// serve the objects found in a snapshot instead of a live API server. The
+// snapshot is either a directory or a (optionally gzipped) tarball of YAML or
+// JSON manifests, such as a `kubectl get -o yaml` dump or a must-gather.
+func NewSnapshotClient(path string) (*Client, error) {
+	objects, err := loadSnapshot(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// coreScheme holds the types served by the typed clientset, scheme adds
+	// the types the controller-runtime client is expected to know about.
+	coreScheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(coreScheme); err != nil {
+		return nil, err
+	}
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, err
+	}
+	if err := installGatewayAPI(scheme); err != nil {
+		return nil, err
+	}
+
+	var typedObjects, ctrlObjects []runtime.Object
+	var dynamicObjects []runtime.Object
+	knownResources := map[schema.GroupVersionResource]bool{}
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		knownResources[gvr] = true
+		dynamicObjects = append(dynamicObjects, obj)
+
+		if !scheme.Recognizes(gvk) {
+			continue
+		}
+		typed, err := scheme.New(gvk)
+		if err != nil {
+			return nil, err
+		}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+			return nil, fmt.Errorf("converting %s %s/%s: %w", gvk.Kind, obj.GetNamespace(), obj.GetName(), err)
+		}
+		if coreScheme.Recognizes(gvk) {
+			typedObjects = append(typedObjects, typed)
+		}
+		ctrlObjects = append(ctrlObjects, typed)
+	}
+
+	clientSet := fake.NewSimpleClientset(typedObjects...)
+	clientSet.Resources = discoveryResources(objects)
+	// The fake clientset ignores field selectors, which the analyzers rely
+	// on to find the events of a single object.
+	clientSet.PrependReactor("list", "events", eventFieldSelectorReactor(clientSet.Tracker()))
+
+	for gvk := range coreScheme.AllKnownTypes() {
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		knownResources[gvr] = true
+	}
+
+	serverVersion, err := clientSet.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		Client:        clientSet,
+		CtrlClient:    ctrlfake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(ctrlObjects...).Build(),
+		Config:        &rest.Config{Host: "snapshot://" + path},
+		ServerVersion: serverVersion,
+		DynamicClient: &snapshotDynamicClient{
+			Interface: dynamicfake.NewSimpleDynamicClient(coreScheme, dynamicObjects...),
+			known:     knownResources,
+		},
+	}, nil
+}
+
+// discoveryResources describes the resources of the snapshot objects for
+// discovery. A resource is namespaced when one of its objects is.
+func discoveryResources(objects []*unstructured.Unstructured) []*metav1.APIResourceList {
+	var lists []*metav1.APIResourceList
+	byGroupVersion := map[schema.GroupVersion]*metav1.APIResourceList{}
+	for _, obj := range objects {
+		gvk := obj.GroupVersionKind()
+		gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+		list, ok := byGroupVersion[gvk.GroupVersion()]
+		if !ok {
+			list = &metav1.APIResourceList{GroupVersion: gvk.GroupVersion().String()}
+			byGroupVersion[gvk.GroupVersion()] = list
+			lists = append(lists, list)
+		}
+		i := slices.IndexFunc(list.APIResources, func(resource metav1.APIResource) bool {
+			return resource.Name == gvr.Resource
+		})
+		if i < 0 {
+			list.APIResources = append(list.APIResources, metav1.APIResource{
+				Name:  gvr.Resource,
+				Kind:  gvk.Kind,
+				Verbs: metav1.Verbs{"get", "list"},
+			})
+			i = len(list.APIResources) - 1
+		}
+		if obj.GetNamespace() != "" {
+			list.APIResources[i].Namespaced = true
+		}
+	}
+	return lists
+}
+
+// loadSnapshot reads every manifest of a snapshot directory or tarball and
+// flattens List documents into their items.
+func loadSnapshot(path string) ([]*unstructured.Unstructured, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+	add := func(name string, data []byte) error {
+		objs, err := decodeManifests(data)
+		if err != nil {
+			return fmt.Errorf("reading snapshot file %s: %w", name, err)
+		}
+		objects = append(objects, objs...)
+		return nil
+	}
+
+	if info.IsDir() {
+		err = filepath.WalkDir(path, func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !isManifestFile(name) {
+				return nil
+			}
+			data, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			return add(name, data)
+		})
+	} else {
+		err = readTarball(path, add)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return objects, nil
+}
+
+func readTarball(path string, add func(name string, data []byte) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var r io.Reader = reader
+	// Detect gzip by its magic number rather than the file extension.
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading snapshot tarball %s: %w", path, err)
+		}
+		if header.Typeflag != tar.TypeReg || !isManifestFile(header.Name) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+		if err := add(header.Name, data); err != nil {
+			return err
+		}
+	}
+}
+
+func isManifestFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// decodeManifests decodes a stream of YAML or JSON documents. Documents
+// without an apiVersion and kind are skipped.
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objects, nil
+			}
+			return nil, err
+		}
+		if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+			continue
+		}
+		if !obj.IsList() {
+			objects = append(objects, obj)
+			continue
+		}
+		// Items of typed lists such as a PodList usually omit their own
+		// apiVersion and kind, so derive them from the list.
+		itemKind := strings.TrimSuffix(obj.GetKind(), "List")
+		err := obj.EachListItem(func(item runtime.Object) error {
+			u, ok := item.(*unstructured.Unstructured)
+			if !ok {
+				return fmt.Errorf("unexpected list item type %T", item)
+			}
+			if u.GetKind() == "" && itemKind != "" && itemKind != obj.GetKind() {
+				u.SetAPIVersion(obj.GetAPIVersion())
+				u.SetKind(itemKind)
+			}
+			if u.GetAPIVersion() != "" && u.GetKind() != "" {
+				objects = append(objects, u)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// eventFieldSelectorReactor applies the involvedObject field selectors that
+// the fake clientset does not support on its own.
+func eventFieldSelectorReactor(tracker k8stesting.ObjectTracker) k8stesting.ReactionFunc {
+	return func(action k8stesting.Action) (bool, runtime.Object, error) {
+		listAction, ok := action.(k8stesting.ListActionImpl)
+		if !ok || listAction.GetListRestrictions().Fields == nil || listAction.GetListRestrictions().Fields.Empty() {
+			return false, nil, nil
+		}
+		obj, err := tracker.List(listAction.GetResource(), listAction.GetKind(), listAction.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		events, ok := obj.(*v1.EventList)
+		if !ok {
+			return false, nil, nil
+		}
+		selector := listAction.GetListRestrictions().Fields
+		filtered := &v1.EventList{ListMeta: events.ListMeta}
+		for _, event := range events.Items {
+			if selector.Matches(eventFields(event)) {
+				filtered.Items = append(filtered.Items, event)
+			}
+		}
+		return true, filtered, nil
+	}
+}
+
+func eventFields(event v1.Event) fields.Set {
+	return fields.Set{
+		"metadata.name":                  event.Name,
+		"metadata.namespace":             event.Namespace,
+		"involvedObject.kind":            event.InvolvedObject.Kind,
+		"involvedObject.namespace":       event.InvolvedObject.Namespace,
+		"involvedObject.name":            event.InvolvedObject.Name,
+		"involvedObject.uid":             string(event.InvolvedObject.UID),
+		"involvedObject.apiVersion":      event.InvolvedObject.APIVersion,
+		"involvedObject.resourceVersion": event.InvolvedObject.ResourceVersion,
+		"involvedObject.fieldPath":       event.InvolvedObject.FieldPath,
+		"reason":                         event.Reason,
+		"reportingComponent":             event.ReportingController,
+		"source":                         event.Source.Component,
+		"type":                           event.Type,
+	}
+}
+
+// snapshotDynamicClient answers LIST calls for resources missing from the
+// snapshot with NotFound, like an API server without the matching CRD,
+// instead of letting the fake dynamic client panic.
+type snapshotDynamicClient struct {
+	dynamic.Interface
+	known map[schema.GroupVersionResource]bool
+}
+
+func (c *snapshotDynamicClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	if c.known[resource] {
+		return c.Interface.Resource(resource)
+	}
+	return &missingResource{
+		NamespaceableResourceInterface: c.Interface.Resource(resource),
+		resource:                       resource,
+	}
+}
+
+type missingResource struct {
+	dynamic.NamespaceableResourceInterface
+	resource schema.GroupVersionResource
+}
+
+func (r *missingResource) Namespace(string) dynamic.ResourceInterface {
+	return r
+}
+
+func (r *missingResource) List(context.Context, metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return nil, apierrors.NewGenericServerResponse(http.StatusNotFound, "list", r.resource.GroupResource(), "", "", 0, false)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const snapshotPods = `apiVersion: v1
+kind: PodList
+items:
+- metadata:
+    name: web-1
+    namespace: default
+    labels:
+      app: web
+- metadata:
+    name: db-1
+    namespace: default
+`
+
+const snapshotResources = `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: web-1.1
+    namespace: default
+  involvedObject:
+    kind: Pod
+    name: web-1
+  reason: BackOff
+- apiVersion: v1
+  kind: Event
+  metadata:
+    name: db-1.1
+    namespace: default
+  involvedObject:
+    kind: Pod
+    name: db-1
+  reason: Unhealthy
+---
+apiVersion: gateway.networking.k8s.io/v1
+kind: GatewayClass
+metadata:
+  name: example
+spec:
+  controllerName: example.com/gateway
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: sprocket
+  namespace: default
+`
+
+func TestNewSnapshotClientFromDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "namespaces", "default"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "namespaces", "default", "pods.yaml"), []byte(snapshotPods), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "resources.yaml"), []byte(snapshotResources), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a manifest"), 0o644))
+
+	client, err := NewSnapshotClient(dir)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	pods, err := client.GetClient().CoreV1().Pods("default").List(ctx, metav1.ListOptions{LabelSelector: "app=web"})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 1)
+	require.Equal(t, "web-1", pods.Items[0].Name)
+
+	events, err := client.GetClient().CoreV1().Events("default").List(ctx, metav1.ListOptions{FieldSelector: "involvedObject.name=db-1"})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	require.Equal(t, "Unhealthy", events.Items[0].Reason)
+
+	gatewayClass := &gtwapi.GatewayClass{}
+	require.NoError(t, client.GetCtrlClient().Get(ctx, ctrl.ObjectKey{Name: "example"}, gatewayClass))
+
+	widgets, err := client.GetDynamicClient().Resource(schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}).
+		Namespace("default").List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, widgets.Items, 1)
+
+	_, err = client.GetDynamicClient().Resource(schema.GroupVersionResource{Group: "operators.coreos.com", Version: "v1alpha1", Resource: "subscriptions"}).
+		Namespace("").List(ctx, metav1.ListOptions{})
+	require.True(t, apierrors.IsNotFound(err))
+
+	// Discovery serves the resources of the snapshot objects.
+	resources, err := client.GetClient().Discovery().ServerResourcesForGroupVersion("gateway.networking.k8s.io/v1")
+	require.NoError(t, err)
+	require.Equal(t, []metav1.APIResource{{Name: "gatewayclasses", Kind: "GatewayClass", Verbs: metav1.Verbs{"get", "list"}}}, resources.APIResources)
+	resources, err = client.GetClient().Discovery().ServerResourcesForGroupVersion("v1")
+	require.NoError(t, err)
+	require.Len(t, resources.APIResources, 2)
+	require.True(t, resources.APIResources[0].Namespaced)
+	_, err = client.GetClient().Discovery().ServerResourcesForGroupVersion("batch/v1")
+	require.Error(t, err)
+}
+
+func TestNewSnapshotClientFromTarball(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.tar.gz")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "pods.yaml", Mode: 0o644, Size: int64(len(snapshotPods)), Typeflag: tar.TypeReg}))
+	_, err = tw.Write([]byte(snapshotPods))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+	require.NoError(t, f.Close())
+
+	client, err := NewSnapshotClient(path)
+	require.NoError(t, err)
+
+	pods, err := client.GetClient().CoreV1().Pods("").List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, pods.Items, 2)
+}
+
+func TestNewSnapshotClientInvalidManifest(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.yaml"), []byte("kind: [unterminated"), 0o644))
+
+	_, err := NewSnapshotClient(dir)
+	require.ErrorContains(t, err, "broken.yaml")
+}
@@ -0,0 +1,288 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/spf13/viper"
+)
+
+const (
+	// ConfigKey is the config key that holds the prompt templates.
+	ConfigKey = "prompts"
+	// DefaultKind is the kind of the template used for results without a
+	// template of their own.
+	DefaultKind = "default"
+	// Extension is the file extension of templates in the prompt directory.
+	Extension = ".tmpl"
+	// BuiltIn is the Source of the compiled-in templates.
+	BuiltIn = "built-in"
+)
+
+// reserved are the keys of ai.PromptMap that are not result kinds.
+var reserved = map[string]bool{
+	"raw":                    true,
+	DefaultKind:              true,
+	ai.StructuredPrompt:      true,
+	ai.StructuredRetryPrompt: true,
+}
+
+// Config holds the user-defined templates. Templates in Directory are named
+// <Kind>.tmpl, or <integration>/<Kind>.tmpl for the results of an
+// integration; Templates override the ones of the directory.
+type Config struct {
+	Directory string     `mapstructure:"directory" yaml:"directory,omitempty"`
+	Templates []Template `mapstructure:"templates" yaml:"templates,omitempty"`
+}
+
+// Template is a prompt template for the results of a kind. User templates
+// are text/template templates executed with Data.
+type Template struct {
+	Kind string `mapstructure:"kind" yaml:"kind"`
+	// Integration restricts the template to the results of an integration,
+	// e.g. prometheus.
+	Integration string `mapstructure:"integration" yaml:"integration,omitempty"`
+	Template    string `mapstructure:"template" yaml:"template"`
+	// Source is where the template was loaded from: "config", a file path,
+	// or BuiltIn.
+	Source string `mapstructure:"-" yaml:"-"`
+
+	parsed *template.Template
+}
+
+// Data holds the variables available to templates, e.g. {{.Language}}.
+type Data struct {
+	Language string
+	// Failures are the failure texts of the result joined by spaces, and
+	// FailureList the individual texts.
+	Failures    string
+	FailureList []string
+	Kind        string
+	Name        string
+	Parent      string
+	// Integration is the integration that reported the result, if any.
+	Integration    string
+	ClusterVersion string
+	// Context holds the events, spec, logs and owners of the object selected
+	// with --explain-context. Built-in templates append it to the prompt.
+	Context string
+}
+
+// sampleData is used to check that templates only use known variables.
+var sampleData = Data{
+	Language:       "english",
+	Failures:       "failure",
+	FailureList:    []string{"failure"},
+	Kind:           "Pod",
+	Name:           "default/pod",
+	Parent:         "Deployment/app",
+	ClusterVersion: "v1.30.0",
+	Context:        "Recent events:\nWarning BackOff: Back-off restarting failed container",
+}
+
+// IsBuiltIn reports whether t is one of the compiled-in prompts, which take
+// the language and the failures as positional %s verbs.
+func (t *Template) IsBuiltIn() bool {
+	return t.Source == BuiltIn
+}
+
+// Render returns the prompt for data.
+func (t *Template) Render(data Data) (string, error) {
+	if t.IsBuiltIn() {
+		return AppendContext(fmt.Sprintf(strings.TrimSpace(t.Template), data.Language, data.Failures), data.Context), nil
+	}
+	var b strings.Builder
+	if err := t.parsed.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// AppendContext appends the context of the object to a prompt.
+func AppendContext(prompt, context string) string {
+	if context == "" {
+		return prompt
+	}
+	return prompt + "\n\nUse this context about the object:\n" + context
+}
+
+func (t *Template) parse() error {
+	if t.Kind == "" {
+		return errors.New("a prompt template needs a kind")
+	}
+	parsed, err := template.New(t.Kind).Option("missingkey=error").Parse(t.Template)
+	if err != nil {
+		return fmt.Errorf("prompt template for %s: %w", t.Kind, err)
+	}
+	t.parsed = parsed
+	if _, err := t.Render(sampleData); err != nil {
+		return fmt.Errorf("prompt template for %s: %w", t.Kind, err)
+	}
+	return nil
+}
+
+type key struct {
+	kind        string
+	integration string
+}
+
+// Registry holds the user-defined templates in front of the built-in ones.
+// A nil Registry only has the built-in templates.
+type Registry struct {
+	templates map[key]*Template
+}
+
+// LoadConfig loads the templates configured under ConfigKey.
+func LoadConfig() (*Registry, error) {
+	var config Config
+	if err := viper.UnmarshalKey(ConfigKey, &config); err != nil {
+		return nil, fmt.Errorf("loading prompt templates: %w", err)
+	}
+	return Load(config)
+}
+
+// Load parses the templates of config.
+func Load(config Config) (*Registry, error) {
+	r := &Registry{templates: map[key]*Template{}}
+	if config.Directory != "" {
+		if err := r.loadDirectory(config.Directory); err != nil {
+			return nil, err
+		}
+	}
+	for _, t := range config.Templates {
+		t.Source = "config"
+		if err := r.add(t); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func (r *Registry) loadDirectory(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading prompt directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			if err := r.loadFile(filepath.Join(dir, entry.Name()), ""); err != nil {
+				return err
+			}
+			continue
+		}
+		integration := entry.Name()
+		files, err := os.ReadDir(filepath.Join(dir, integration))
+		if err != nil {
+			return fmt.Errorf("reading prompt directory: %w", err)
+		}
+		for _, file := range files {
+			if !file.IsDir() {
+				if err := r.loadFile(filepath.Join(dir, integration, file.Name()), integration); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (r *Registry) loadFile(path, integration string) error {
+	if filepath.Ext(path) != Extension {
+		return nil
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return r.add(Template{
+		Kind:        strings.TrimSuffix(filepath.Base(path), Extension),
+		Integration: integration,
+		Template:    string(text),
+		Source:      path,
+	})
+}
+
+func (r *Registry) add(t Template) error {
+	if err := t.parse(); err != nil {
+		return err
+	}
+	r.templates[key{t.Kind, t.Integration}] = &t
+	return nil
+}
+
+// Lookup returns the template for the results of kind reported by
+// integration, which is empty for core analyzers: a user template for the
+// kind and integration, then one for the kind, then a built-in one.
+func (r *Registry) Lookup(kind, integration string) (*Template, bool) {
+	if r != nil && kind != DefaultKind {
+		for _, k := range []key{{kind, integration}, {kind, ""}} {
+			if t, ok := r.templates[k]; ok {
+				return t, true
+			}
+		}
+	}
+	if prompt, ok := ai.PromptMap[kind]; ok && !reserved[kind] {
+		return builtIn(kind, prompt), true
+	}
+	return nil, false
+}
+
+// Default returns the template for results without a template of their
+// own: the user-defined default template, or the built-in one.
+func (r *Registry) Default() *Template {
+	if r != nil {
+		if t, ok := r.templates[key{DefaultKind, ""}]; ok {
+			return t
+		}
+	}
+	return builtIn(DefaultKind, ai.PromptMap[DefaultKind])
+}
+
+// List returns the user-defined templates and the built-in templates of the
+// kinds without one, sorted by kind and integration.
+func (r *Registry) List() []*Template {
+	var templates []*Template
+	overridden := map[string]bool{}
+	if r != nil {
+		for k, t := range r.templates {
+			templates = append(templates, t)
+			overridden[k.kind] = true
+		}
+	}
+	for kind, prompt := range ai.PromptMap {
+		if (reserved[kind] && kind != DefaultKind) || overridden[kind] {
+			continue
+		}
+		templates = append(templates, builtIn(kind, prompt))
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		if templates[i].Kind != templates[j].Kind {
+			return templates[i].Kind < templates[j].Kind
+		}
+		return templates[i].Integration < templates[j].Integration
+	})
+	return templates
+}
+
+func builtIn(kind, prompt string) *Template {
+	return &Template{Kind: kind, Template: prompt, Source: BuiltIn}
+}
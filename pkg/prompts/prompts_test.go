@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prompts
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, path, text string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(text), 0o600))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, filepath.Join(dir, "Pod.tmpl"), "pod from file: {{.Failures}}")
+	writeTemplate(t, filepath.Join(dir, "default.tmpl"), "default: {{.Failures}}")
+	writeTemplate(t, filepath.Join(dir, "prometheus", "PrometheusConfigValidate.tmpl"), "{{.Integration}}: {{.Failures}}")
+	writeTemplate(t, filepath.Join(dir, "README.md"), "not a template")
+
+	registry, err := Load(Config{
+		Directory: dir,
+		Templates: []Template{{Kind: "Pod", Template: "pod from config: {{.Failures}}"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		kind        string
+		integration string
+		wantSource  string
+		wantFound   bool
+	}{
+		{name: "config overrides directory", kind: "Pod", wantSource: "config", wantFound: true},
+		{name: "integration template", kind: "PrometheusConfigValidate", integration: "prometheus", wantSource: filepath.Join(dir, "prometheus", "PrometheusConfigValidate.tmpl"), wantFound: true},
+		{name: "built-in for other integrations", kind: "PrometheusConfigValidate", wantSource: BuiltIn, wantFound: true},
+		{name: "built-in kind", kind: "PolicyReport", integration: "kyverno", wantSource: BuiltIn, wantFound: true},
+		{name: "no template", kind: "Service"},
+		{name: "default is not a kind", kind: DefaultKind},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			template, found := registry.Lookup(tt.kind, tt.integration)
+			require.Equal(t, tt.wantFound, found)
+			if found {
+				require.Equal(t, tt.wantSource, template.Source)
+			}
+		})
+	}
+	require.Equal(t, filepath.Join(dir, "default.tmpl"), registry.Default().Source)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		template Template
+		wantErr  string
+	}{
+		{name: "no kind", template: Template{Template: "{{.Failures}}"}, wantErr: "needs a kind"},
+		{name: "syntax error", template: Template{Kind: "Pod", Template: "{{.Failures"}, wantErr: "prompt template for Pod"},
+		{name: "unknown variable", template: Template{Kind: "Pod", Template: "{{.Namespace}}"}, wantErr: "can't evaluate field Namespace"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(Config{Templates: []Template{tt.template}})
+			require.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+
+	_, err := Load(Config{Directory: filepath.Join(t.TempDir(), "missing")})
+	require.ErrorContains(t, err, "reading prompt directory")
+}
+
+func TestRender(t *testing.T) {
+	registry, err := Load(Config{Templates: []Template{{
+		Kind: "Pod",
+		Template: `Explain in {{.Language}} why {{.Kind}} {{.Name}} of {{.Parent}} fails on Kubernetes {{.ClusterVersion}}:
+{{range .FailureList}}- {{.}}
+{{end}}`,
+	}}})
+	require.NoError(t, err)
+	data := Data{
+		Language:       "english",
+		Failures:       "image not found crash loop",
+		FailureList:    []string{"image not found", "crash loop"},
+		Kind:           "Pod",
+		Name:           "default/web-0",
+		Parent:         "Deployment/web",
+		ClusterVersion: "v1.30.2",
+	}
+
+	template, _ := registry.Lookup("Pod", "")
+	prompt, err := template.Render(data)
+	require.NoError(t, err)
+	require.Equal(t, "Explain in english why Pod default/web-0 of Deployment/web fails on Kubernetes v1.30.2:\n- image not found\n- crash loop", prompt)
+
+	// The built-in templates keep their positional verbs.
+	var nilRegistry *Registry
+	prompt, err = nilRegistry.Default().Render(data)
+	require.NoError(t, err)
+	require.Contains(t, prompt, "--- english ---")
+	require.Contains(t, prompt, "--- image not found crash loop ---")
+	require.NotContains(t, prompt, "%!")
+}
+
+func TestList(t *testing.T) {
+	registry, err := Load(Config{Templates: []Template{
+		{Kind: "Pod", Template: "{{.Failures}}"},
+		{Kind: "PolicyReport", Integration: "kyverno", Template: "{{.Failures}}"},
+	}})
+	require.NoError(t, err)
+
+	var kinds []string
+	for _, template := range registry.List() {
+		kinds = append(kinds, template.Kind+"/"+template.Source)
+	}
+	require.Equal(t, []string{
+		"ClusterPolicyReport/built-in",
+		"Pod/config",
+		"PolicyReport/config",
+		"PrometheusConfigRelabelReport/built-in",
+		"PrometheusConfigValidate/built-in",
+		"default/built-in",
+	}, kinds)
+	require.NotContains(t, kinds, ai.StructuredPrompt+"/built-in")
+}
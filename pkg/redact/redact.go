@@ -0,0 +1,267 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package redact replaces identifying values in failure texts with stable
+// pseudonyms before they are sent to an AI backend, and restores them in
+// the response.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// ConfigKey is the config key that holds the redaction settings.
+const ConfigKey = "redaction"
+
+// Pattern is a user-defined regular expression whose matches are redacted.
+// When the expression has a capture group, only the first group is replaced.
+type Pattern struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+// Config configures a Redactor.
+//
+//	redaction:
+//	  key: 3f1c...
+//	  patterns:
+//	  - name: account
+//	    regex: acct-[0-9]+
+type Config struct {
+	// Key is the secret the pseudonyms are derived from. Keeping it makes
+	// pseudonyms stable across runs.
+	Key      string    `mapstructure:"key"`
+	Patterns []Pattern `mapstructure:"patterns"`
+}
+
+// processKey is the key generated when none is configured. It is kept out
+// of the configuration so that it is only written by SaveKey.
+var processKey struct {
+	sync.Mutex
+	key string
+}
+
+// LoadConfig reads the redaction settings. When no key is configured, a
+// random one is generated for the current process only, so pseudonyms are
+// not stable across runs until a key is saved with SaveKey.
+func LoadConfig() (Config, error) {
+	var config Config
+	if err := viper.UnmarshalKey(ConfigKey, &config); err != nil {
+		return config, err
+	}
+	if config.Key != "" {
+		return config, nil
+	}
+
+	processKey.Lock()
+	defer processKey.Unlock()
+	if processKey.key == "" {
+		key, err := GenerateKey()
+		if err != nil {
+			return config, err
+		}
+		processKey.key = key
+	}
+	config.Key = processKey.key
+	return config, nil
+}
+
+// GenerateKey returns a new random redaction key.
+func GenerateKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// SaveKey stores key as the redaction key in the configuration file.
+func SaveKey(key string) error {
+	viper.Set(ConfigKey+".key", key)
+	if err := viper.WriteConfig(); err != nil {
+		return fmt.Errorf("saving the redaction key: %w", err)
+	}
+	return nil
+}
+
+type rule struct {
+	category string
+	regex    *regexp.Regexp
+}
+
+// The built-in rules, applied in this order before user-defined patterns.
+var builtinRules = []rule{
+	{"token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)},
+	{"token", regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]+=*)`)},
+	{"token", regexp.MustCompile(`(?i)\b(?:token|password|passwd|secret|api[_-]?key)\s*[:=]\s*([^\s,;"']+)`)},
+	{"email", regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+	{"ip", regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1?[0-9]?[0-9])\b`)},
+	// Full and compressed IPv6 addresses; shorter colon-separated groups
+	// such as times are left alone.
+	{"ip", regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b(?:[0-9a-f]{1,4}:){1,6}:(?:[0-9a-f]{1,4}(?::[0-9a-f]{1,4})*\b)?|::(?:[0-9a-f]{1,4}:)*[0-9a-f]{1,4}\b`)},
+}
+
+// hostRule matches DNS names; it runs after the user-defined patterns.
+var hostRule = rule{"host", regexp.MustCompile(`(?i)\b(?:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]*[a-z0-9]\b`)}
+
+// publicDomains are well-known suffixes, e.g. of label keys and public
+// registries, that do not identify a cluster and are left as they are.
+var publicDomains = []string{"kubernetes.io", "k8s.io", "docker.io", "gcr.io", "ghcr.io", "quay.io"}
+
+// token matches the words that are compared against the registered names.
+var token = regexp.MustCompile(`[A-Za-z0-9](?:[A-Za-z0-9_.-]*[A-Za-z0-9])?`)
+
+// Redactor replaces identifying values with pseudonyms derived from a keyed
+// hash, so the same value always gets the same pseudonym. It is safe for
+// concurrent use.
+type Redactor struct {
+	key   []byte
+	rules []rule
+
+	mutex    sync.RWMutex
+	names    map[string]bool
+	original map[string]string
+}
+
+// New returns a Redactor for config.
+func New(config Config) (*Redactor, error) {
+	if config.Key == "" {
+		return nil, fmt.Errorf("a redaction key is required")
+	}
+	r := &Redactor{
+		key:      []byte(config.Key),
+		rules:    append([]rule{}, builtinRules...),
+		names:    map[string]bool{},
+		original: map[string]string{},
+	}
+	for _, pattern := range config.Patterns {
+		regex, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("redaction pattern %s: %w", pattern.Name, err)
+		}
+		category := pattern.Name
+		if category == "" {
+			category = "custom"
+		}
+		r.rules = append(r.rules, rule{category: category, regex: regex})
+	}
+	r.rules = append(r.rules, hostRule)
+	return r, nil
+}
+
+// AddNames registers object names and namespaces that are redacted wherever
+// they appear as a whole word. Values of the form "namespace/name" are
+// split into their parts.
+func (r *Redactor) AddNames(values ...string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, value := range values {
+		for _, part := range strings.Split(value, "/") {
+			if token.FindString(part) == part && part != "" {
+				r.names[part] = true
+			}
+		}
+	}
+}
+
+// Pseudonym returns the stable replacement for value.
+func (r *Redactor) Pseudonym(category, value string) string {
+	mac := hmac.New(sha256.New, r.key)
+	mac.Write([]byte(category + "\x00" + value))
+	pseudonym := fmt.Sprintf("%s-%s", category, hex.EncodeToString(mac.Sum(nil))[:10])
+
+	r.mutex.Lock()
+	r.original[pseudonym] = value
+	r.mutex.Unlock()
+	return pseudonym
+}
+
+// Redact replaces every sensitive value in text with its pseudonym.
+func (r *Redactor) Redact(text string) string {
+	for _, rule := range r.rules {
+		text = r.replace(text, rule)
+	}
+
+	return token.ReplaceAllStringFunc(text, func(word string) string {
+		r.mutex.RLock()
+		known := r.names[word]
+		r.mutex.RUnlock()
+		if !known {
+			return word
+		}
+		return r.Pseudonym("name", word)
+	})
+}
+
+func (r *Redactor) replace(text string, rule rule) string {
+	var output strings.Builder
+	last := 0
+	for _, match := range rule.regex.FindAllStringSubmatchIndex(text, -1) {
+		start, end := match[0], match[1]
+		// Only replace the first capture group when there is one.
+		if len(match) >= 4 && match[2] >= 0 {
+			start, end = match[2], match[3]
+		}
+		value := text[start:end]
+		if rule.category == "host" && isPublicDomain(value) {
+			continue
+		}
+		output.WriteString(text[last:start])
+		output.WriteString(r.Pseudonym(rule.category, value))
+		last = end
+	}
+	output.WriteString(text[last:])
+	return output.String()
+}
+
+func isPublicDomain(host string) bool {
+	host = strings.ToLower(host)
+	for _, domain := range publicDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// Restore replaces the pseudonyms handed out by this Redactor in text with
+// the original values.
+func (r *Redactor) Restore(text string) string {
+	r.mutex.RLock()
+	pseudonyms := make([]string, 0, len(r.original))
+	for pseudonym := range r.original {
+		pseudonyms = append(pseudonyms, pseudonym)
+	}
+	// Longer pseudonyms first, in case a category is a prefix of another.
+	sort.Slice(pseudonyms, func(i, j int) bool { return len(pseudonyms[i]) > len(pseudonyms[j]) })
+	pairs := make([]string, 0, 2*len(pseudonyms))
+	for _, pseudonym := range pseudonyms {
+		pairs = append(pairs, pseudonym, r.original[pseudonym])
+	}
+	r.mutex.RUnlock()
+
+	if len(pairs) == 0 {
+		return text
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package redact
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRedactor(t *testing.T, patterns ...Pattern) *Redactor {
+	r, err := New(Config{Key: "test-key", Patterns: patterns})
+	require.NoError(t, err)
+	return r
+}
+
+func TestRedact(t *testing.T) {
+	r := newTestRedactor(t, Pattern{Name: "account", Regex: `acct-[0-9]+`})
+	r.AddNames("payments/api-7d9f", "payments")
+
+	tests := []struct {
+		name     string
+		text     string
+		redacted []string
+		kept     []string
+	}{
+		{
+			name:     "names",
+			text:     "Back-off restarting failed container api in pod api-7d9f in namespace payments",
+			redacted: []string{"api-7d9f", "payments"},
+			kept:     []string{"Back-off restarting failed container api in pod"},
+		},
+		{
+			name:     "ip addresses",
+			text:     "dial tcp 10.96.0.12:443 and fd00:10:96::a: connection refused at 10:30:45",
+			redacted: []string{"10.96.0.12", "fd00:10:96::a"},
+			kept:     []string{":443", "connection refused at 10:30:45"},
+		},
+		{
+			name:     "hosts",
+			text:     "lookup db.payments.svc.cluster.local failed; label app.kubernetes.io/name is missing",
+			redacted: []string{"db.payments.svc.cluster.local"},
+			kept:     []string{"app.kubernetes.io/name"},
+		},
+		{
+			name:     "emails and tokens",
+			text:     "owner ops@example.com sent Bearer abcdef123456 with password=hunter22 and eyJhbGciOi.eyJzdWIiOi.c2ln",
+			redacted: []string{"ops@example.com", "abcdef123456", "hunter22", "eyJhbGciOi.eyJzdWIiOi.c2ln"},
+			kept:     []string{"Bearer ", "password="},
+		},
+		{
+			name:     "custom patterns",
+			text:     "billing account acct-1234 is suspended",
+			redacted: []string{"acct-1234"},
+			kept:     []string{"billing account ", " is suspended"},
+		},
+		{
+			name: "unrelated words",
+			text: "Service has no endpoints, expected label app=apiserver",
+			kept: []string{"Service has no endpoints, expected label app=apiserver"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			redacted := r.Redact(tt.text)
+			for _, value := range tt.redacted {
+				require.NotContains(t, redacted, value)
+			}
+			for _, value := range tt.kept {
+				require.Contains(t, redacted, value)
+			}
+			require.Equal(t, tt.text, r.Restore(redacted))
+		})
+	}
+}
+
+func TestPseudonymsAreStable(t *testing.T) {
+	first := newTestRedactor(t)
+	second := newTestRedactor(t)
+	first.AddNames("payments")
+	second.AddNames("payments")
+
+	require.Equal(t, first.Redact("namespace payments"), second.Redact("namespace payments"))
+	require.Equal(t, "namespace name-", first.Redact("namespace payments")[:15])
+
+	other, err := New(Config{Key: "other-key"})
+	require.NoError(t, err)
+	other.AddNames("payments")
+	require.NotEqual(t, first.Redact("namespace payments"), other.Redact("namespace payments"))
+}
+
+func TestRestoreOnlyKnownPseudonyms(t *testing.T) {
+	r := newTestRedactor(t)
+	r.AddNames("web")
+	redacted := r.Redact("pod web")
+	pseudonym := strings.TrimPrefix(redacted, "pod ")
+
+	response := "Error: " + pseudonym + " crashed. Solution: check name-0000000000."
+	require.Equal(t, "Error: web crashed. Solution: check name-0000000000.", r.Restore(response))
+}
+
+func TestRedactConcurrently(t *testing.T) {
+	r := newTestRedactor(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.AddNames("web")
+			require.Equal(t, "pod web on 10.0.0.1", r.Restore(r.Redact("pod web on 10.0.0.1")))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{})
+	require.ErrorContains(t, err, "redaction key is required")
+
+	_, err = New(Config{Key: "k", Patterns: []Pattern{{Name: "broken", Regex: "("}}})
+	require.ErrorContains(t, err, "redaction pattern broken")
+}
+
+func TestLoadConfig(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	require.Len(t, config.Key, 64)
+	// The generated key is kept for the rest of the run.
+	again, err := LoadConfig()
+	require.NoError(t, err)
+	require.Equal(t, config.Key, again.Key)
+	// It is not part of the configuration, which may be written later.
+	require.Empty(t, viper.GetString(ConfigKey+".key"))
+}
+
+func TestSaveKey(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	key, err := GenerateKey()
+	require.NoError(t, err)
+	require.Len(t, key, 64)
+
+	// Without a configuration file the key cannot be saved.
+	require.ErrorContains(t, SaveKey(key), "saving the redaction key")
+
+	path := filepath.Join(t.TempDir(), "k8sgpt.yaml")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+	viper.SetConfigFile(path)
+	require.NoError(t, SaveKey(key))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), key)
+	config, err := LoadConfig()
+	require.NoError(t, err)
+	require.Equal(t, key, config.Key)
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/agent"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// runAnalyzerTool runs one analyzer without explaining its results
+var runAnalyzerTool = mcp.NewTool("run-analyzer",
+	mcp.WithDescription("Run a K8sGPT analyzer and return the problems it finds"),
+	mcp.WithString("analyzer",
+		mcp.Required(),
+		mcp.Description("Name of the analyzer (e.g., Pod, Service, Ingress, PersistentVolumeClaim)"),
+	),
+	mcp.WithString("namespace",
+		mcp.Description("Namespace to analyze (empty for all namespaces)"),
+	),
+)
+
+// AgentTools returns the read-only tools of the MCP server for analyze
+// --agent: get-resource, list-events, get-logs and run-analyzer. They read
+// from client, the cluster or snapshot under analysis.
+func AgentTools(client *kubernetes.Client) []agent.Tool {
+	s := &K8sGptMCPServer{aiProvider: &ai.AIProvider{}, client: client}
+	tools := []server.ServerTool{
+		{Tool: getResourceTool, Handler: s.handleGetResourceReadOnly},
+		{Tool: listEventsTool, Handler: s.handleListEvents},
+		{Tool: getLogsTool, Handler: s.handleGetLogs},
+		{Tool: runAnalyzerTool, Handler: s.handleRunAnalyzer},
+	}
+	agentTools := make([]agent.Tool, 0, len(tools))
+	for _, tool := range tools {
+		agentTools = append(agentTools, agentTool(tool))
+	}
+	return agentTools
+}
+
+// agentTool calls the handler of tool with the arguments of the model.
+func agentTool(tool server.ServerTool) agent.Tool {
+	parameters := map[string]any{
+		"type":       "object",
+		"properties": tool.Tool.InputSchema.Properties,
+	}
+	if len(tool.Tool.InputSchema.Required) > 0 {
+		parameters["required"] = tool.Tool.InputSchema.Required
+	}
+	return agent.Tool{
+		Tool: ai.Tool{
+			Name:        tool.Tool.Name,
+			Description: tool.Tool.Description,
+			Parameters:  parameters,
+		},
+		Call: func(ctx context.Context, arguments string) (string, error) {
+			if strings.TrimSpace(arguments) == "" {
+				arguments = "{}"
+			}
+			request := mcp.CallToolRequest{}
+			request.Params.Name = tool.Tool.Name
+			request.Params.Arguments = json.RawMessage(arguments)
+			result, err := tool.Handler(ctx, request)
+			if err != nil {
+				return "", err
+			}
+			var text []string
+			for _, content := range result.Content {
+				if content, ok := content.(mcp.TextContent); ok {
+					text = append(text, content.Text)
+				}
+			}
+			if result.IsError {
+				return "", errors.New(strings.Join(text, "\n"))
+			}
+			return strings.Join(text, "\n"), nil
+		},
+	}
+}
+
+// handleGetResourceReadOnly gets a resource other than a secret, whose
+// data must not reach the AI backend
+func (s *K8sGptMCPServer) handleGetResourceReadOnly(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var req struct {
+		ResourceType string `json:"resourceType"`
+	}
+	if err := request.BindArguments(&req); err != nil {
+		return mcp.NewToolResultErrorf("Failed to parse request arguments: %v", err), nil
+	}
+	if resourceType, err := normalizeResourceType(req.ResourceType); err == nil && resourceType == "secret" {
+		return mcp.NewToolResultErrorf("secrets cannot be read during an investigation"), nil
+	}
+	return s.handleGetResource(ctx, request)
+}
+
+// handleRunAnalyzer runs an analyzer against the client of the server
+func (s *K8sGptMCPServer) handleRunAnalyzer(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var req struct {
+		Analyzer  string `json:"analyzer"`
+		Namespace string `json:"namespace,omitempty"`
+	}
+	if err := request.BindArguments(&req); err != nil {
+		return mcp.NewToolResultErrorf("Failed to parse request arguments: %v", err), nil
+	}
+	if req.Analyzer == "" {
+		return mcp.NewToolResultErrorf("analyzer is required"), nil
+	}
+	_, analyzers, err := analyzer.GetAnalyzerMap()
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to load analyzers: %v", err), nil
+	}
+	selected, ok := analyzers[req.Analyzer]
+	if !ok {
+		return mcp.NewToolResultErrorf("unknown analyzer %s", req.Analyzer), nil
+	}
+	client, err := s.kubernetesClient()
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to create Kubernetes client: %v", err), nil
+	}
+	results, err := selected.Analyze(common.Analyzer{
+		Client:    client,
+		Context:   ctx,
+		Namespace: req.Namespace,
+	})
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to run analyzer %s: %v", req.Analyzer, err), nil
+	}
+	if results == nil {
+		results = []common.Result{}
+	}
+	resultJSON, err := marshalJSON(results)
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to serialize result: %v", err), nil
+	}
+	return mcp.NewToolResultText(resultJSON), nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestAgentTools(t *testing.T) {
+	var names []string
+	for _, tool := range AgentTools(&kubernetes.Client{}) {
+		names = append(names, tool.Name)
+		require.Equal(t, "object", tool.Parameters["type"])
+	}
+	require.Equal(t, []string{"get-resource", "list-events", "get-logs", "run-analyzer"}, names)
+}
+
+func TestAgentTool(t *testing.T) {
+	tool := agentTool(server.ServerTool{
+		Tool: getLogsTool,
+		Handler: func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			var req struct {
+				PodName string `json:"podName"`
+			}
+			if err := request.BindArguments(&req); err != nil {
+				return nil, err
+			}
+			if req.PodName == "" {
+				return mcp.NewToolResultErrorf("podName is required"), nil
+			}
+			return mcp.NewToolResultText("logs of " + req.PodName), nil
+		},
+	})
+	require.Equal(t, "get-logs", tool.Name)
+	require.Equal(t, []string{"podName", "namespace"}, tool.Parameters["required"])
+
+	output, err := tool.Call(context.Background(), `{"podName":"web"}`)
+	require.NoError(t, err)
+	require.Equal(t, "logs of web", output)
+
+	_, err = tool.Call(context.Background(), "")
+	require.EqualError(t, err, "podName is required")
+}
+
+func TestAgentToolsRefuseSecrets(t *testing.T) {
+	for _, tool := range AgentTools(&kubernetes.Client{}) {
+		if tool.Name != "get-resource" {
+			continue
+		}
+		_, err := tool.Call(context.Background(), `{"resourceType":"secrets","name":"db","namespace":"default"}`)
+		require.EqualError(t, err, "secrets cannot be read during an investigation")
+	}
+}
+
+func TestAgentToolsUseClient(t *testing.T) {
+	client := &kubernetes.Client{Client: fake.NewSimpleClientset(
+		&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "web.1", Namespace: "default"}, Reason: "BackOff"},
+	)}
+	for _, tool := range AgentTools(client) {
+		if tool.Name != "list-events" {
+			continue
+		}
+		output, err := tool.Call(context.Background(), `{"namespace":"default"}`)
+		require.NoError(t, err)
+		require.Contains(t, output, "BackOff")
+	}
+}
+
+func TestAgentToolsRunAnalyzerUsesClient(t *testing.T) {
+	// The default context is not the cluster under analysis.
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	client := &kubernetes.Client{Client: fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodPending,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Reason: "Unschedulable", Message: "0/1 nodes are available"}},
+		},
+	})}
+	for _, tool := range AgentTools(client) {
+		if tool.Name != "run-analyzer" {
+			continue
+		}
+		output, err := tool.Call(context.Background(), `{"analyzer":"Pod","namespace":"default"}`)
+		require.NoError(t, err)
+		require.Contains(t, output, "default/web")
+		require.Contains(t, output, "0/1 nodes are available")
+
+		_, err = tool.Call(context.Background(), `{"analyzer":"Unknown"}`)
+		require.EqualError(t, err, "unknown analyzer Unknown")
+	}
+}
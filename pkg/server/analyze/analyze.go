@@ -3,11 +3,18 @@ package analyze
 import (
 	"context"
 	json "encoding/json"
+	"fmt"
 
 	schemav1 "buf.build/gen/go/k8sgpt-ai/k8sgpt/protocolbuffers/go/schema/v1"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 )
 
+// Analyze runs an analysis for a gRPC request. Results are returned most
+// severe first. The pinned response schema has no severity field, so the
+// severity of each failure is prefixed to its text as in the text output,
+// e.g. "[high] ...". The request has no minimum severity and structured
+// explanations are not offered until the schema gains fields for them.
 func (h *Handler) Analyze(ctx context.Context, i *schemav1.AnalyzeRequest) (
 	*schemav1.AnalyzeResponse,
 	error,
@@ -33,6 +40,7 @@ func (h *Handler) Analyze(ctx context.Context, i *schemav1.AnalyzeRequest) (
 		false,      // Interactive mode disabled in server mode
 		[]string{}, //TODO: add custom http headers in server mode
 		false,      // with stats disable
+		"",         // Snapshots are not served
 	)
 	if err != nil {
 		return &schemav1.AnalyzeResponse{}, err
@@ -52,6 +60,7 @@ func (h *Handler) Analyze(ctx context.Context, i *schemav1.AnalyzeRequest) (
 		}
 	}
 
+	labelSeverities(config.Results)
 	out, err := config.PrintOutput(i.Output)
 	if err != nil {
 		return &schemav1.AnalyzeResponse{}, err
@@ -65,3 +74,15 @@ func (h *Handler) Analyze(ctx context.Context, i *schemav1.AnalyzeRequest) (
 
 	return &obj, nil
 }
+
+// labelSeverities prefixes the severity of every failure to its text.
+func labelSeverities(results []common.Result) {
+	for i := range results {
+		for j := range results[i].Error {
+			failure := &results[i].Error[j]
+			if failure.Severity != "" {
+				failure.Text = fmt.Sprintf("[%s] %s", failure.Severity, failure.Text)
+			}
+		}
+	}
+}
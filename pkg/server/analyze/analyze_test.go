@@ -0,0 +1,21 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelSeverities(t *testing.T) {
+	results := []common.Result{{
+		Kind: "Pod",
+		Error: []common.Failure{
+			{Text: "Back-off restarting failed container", Severity: common.SeverityHigh},
+			{Text: "no severity"},
+		},
+	}}
+	labelSeverities(results)
+	require.Equal(t, "[high] Back-off restarting failed container", results[0].Error[0].Text)
+	require.Equal(t, "no severity", results[0].Error[1].Text)
+}
@@ -22,6 +22,7 @@ import (
 	schemav1 "buf.build/gen/go/k8sgpt-ai/k8sgpt/protocolbuffers/go/schema/v1"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/server/config"
 	"github.com/mark3labs/mcp-go/mcp"
@@ -40,6 +41,9 @@ type K8sGptMCPServer struct {
 	logger      *zap.Logger
 	httpServer  *server.StreamableHTTPServer
 	stdioServer *server.StdioServer
+	// client is the cluster the tools read from; when it is nil each call
+	// connects to the default context.
+	client *kubernetes.Client
 }
 
 func NewMCPServer(port string, aiProvider *ai.AIProvider, useHTTP bool, logger *zap.Logger) (*K8sGptMCPServer, error) {
@@ -129,6 +133,67 @@ func (s *K8sGptMCPServer) Start() error {
 	}
 }
 
+// The read-only tools are shared with analyze --agent, see AgentTools.
+var (
+	// getResourceTool gets a specific resource
+	getResourceTool = mcp.NewTool("get-resource",
+		mcp.WithDescription("Get detailed information about a specific Kubernetes resource"),
+		mcp.WithString("resourceType",
+			mcp.Required(),
+			mcp.Description("Type of resource (e.g., pod, deployment, service)"),
+		),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("Name of the resource"),
+		),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace of the resource (required for namespaced resources)"),
+		),
+	)
+
+	// listEventsTool lists events
+	listEventsTool = mcp.NewTool("list-events",
+		mcp.WithDescription("List Kubernetes events for debugging and troubleshooting"),
+		mcp.WithString("namespace",
+			mcp.Description("Namespace to list events from (empty for all namespaces)"),
+		),
+		mcp.WithString("involvedObjectName",
+			mcp.Description("Filter events by involved object name (e.g., pod name)"),
+		),
+		mcp.WithString("involvedObjectKind",
+			mcp.Description("Filter events by involved object kind (e.g., Pod, Deployment)"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of events to return (default: 100)"),
+		),
+	)
+
+	// getLogsTool gets the logs of a container
+	getLogsTool = mcp.NewTool("get-logs",
+		mcp.WithDescription("Get logs from a pod container"),
+		mcp.WithString("podName",
+			mcp.Required(),
+			mcp.Description("Name of the pod"),
+		),
+		mcp.WithString("namespace",
+			mcp.Required(),
+			mcp.Description("Namespace of the pod"),
+		),
+		mcp.WithString("container",
+			mcp.Description("Container name (if pod has multiple containers)"),
+		),
+		mcp.WithBoolean("previous",
+			mcp.Description("Get logs from previous terminated container"),
+		),
+		mcp.WithNumber("tailLines",
+			mcp.Description("Number of lines from the end of logs (default: 100)"),
+		),
+		mcp.WithNumber("sinceSeconds",
+			mcp.Description("Return logs newer than this many seconds"),
+		),
+	)
+)
+
 func (s *K8sGptMCPServer) registerToolsAndResources() error {
 	// Register analyze tool with proper JSON schema
 	analyzeTool := mcp.NewTool("analyze",
@@ -142,6 +207,12 @@ func (s *K8sGptMCPServer) registerToolsAndResources() error {
 		mcp.WithBoolean("explain",
 			mcp.Description("Provide detailed explanations for issues"),
 		),
+		mcp.WithBoolean("structured",
+			mcp.Description("With explain, return explanations as JSON with a cause, confidence, steps, kubectl commands and documentation links"),
+		),
+		mcp.WithString("minSeverity",
+			mcp.Description("Only report failures at or above this severity (critical, high, medium, low, info)"),
+		),
 		mcp.WithArray("filters",
 			mcp.Description("Provide filters to narrow down the analysis (e.g. ['Pods', 'Deployments'])"),
 			// without below line MCP server fails with Google Agent Development Kit (ADK), interestingly works fine with mcpinspector
@@ -239,20 +310,6 @@ func (s *K8sGptMCPServer) registerToolsAndResources() error {
 	s.server.AddTool(listResourcesTool, s.handleListResources)
 
 	// Register get resource tool
-	getResourceTool := mcp.NewTool("get-resource",
-		mcp.WithDescription("Get detailed information about a specific Kubernetes resource"),
-		mcp.WithString("resourceType",
-			mcp.Required(),
-			mcp.Description("Type of resource (e.g., pod, deployment, service)"),
-		),
-		mcp.WithString("name",
-			mcp.Required(),
-			mcp.Description("Name of the resource"),
-		),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace of the resource (required for namespaced resources)"),
-		),
-	)
 	s.server.AddTool(getResourceTool, s.handleGetResource)
 
 	// Register list namespaces tool
@@ -262,47 +319,9 @@ func (s *K8sGptMCPServer) registerToolsAndResources() error {
 	s.server.AddTool(listNamespacesTool, s.handleListNamespaces)
 
 	// Register list events tool
-	listEventsTool := mcp.NewTool("list-events",
-		mcp.WithDescription("List Kubernetes events for debugging and troubleshooting"),
-		mcp.WithString("namespace",
-			mcp.Description("Namespace to list events from (empty for all namespaces)"),
-		),
-		mcp.WithString("involvedObjectName",
-			mcp.Description("Filter events by involved object name (e.g., pod name)"),
-		),
-		mcp.WithString("involvedObjectKind",
-			mcp.Description("Filter events by involved object kind (e.g., Pod, Deployment)"),
-		),
-		mcp.WithNumber("limit",
-			mcp.Description("Maximum number of events to return (default: 100)"),
-		),
-	)
 	s.server.AddTool(listEventsTool, s.handleListEvents)
 
 	// Register get logs tool
-	getLogsTool := mcp.NewTool("get-logs",
-		mcp.WithDescription("Get logs from a pod container"),
-		mcp.WithString("podName",
-			mcp.Required(),
-			mcp.Description("Name of the pod"),
-		),
-		mcp.WithString("namespace",
-			mcp.Required(),
-			mcp.Description("Namespace of the pod"),
-		),
-		mcp.WithString("container",
-			mcp.Description("Container name (if pod has multiple containers)"),
-		),
-		mcp.WithBoolean("previous",
-			mcp.Description("Get logs from previous terminated container"),
-		),
-		mcp.WithNumber("tailLines",
-			mcp.Description("Number of lines from the end of logs (default: 100)"),
-		),
-		mcp.WithNumber("sinceSeconds",
-			mcp.Description("Return logs newer than this many seconds"),
-		),
-	)
 	s.server.AddTool(getLogsTool, s.handleGetLogs)
 
 	// Register filter management tools
@@ -355,6 +374,8 @@ type AnalyzeRequest struct {
 	CustomHeaders   []string `json:"customHeaders,omitempty"`
 	WithStats       bool     `json:"withStats,omitempty"`
 	Anonymize       bool     `json:"anonymize,omitempty"`
+	Structured      bool     `json:"structured,omitempty"`
+	MinSeverity     string   `json:"minSeverity,omitempty"`
 }
 
 // AnalyzeResponse represents the output of the analyze tool
@@ -439,15 +460,26 @@ func (s *K8sGptMCPServer) handleAnalyze(ctx context.Context, request mcp.CallToo
 		req.InteractiveMode,
 		req.CustomHeaders,
 		req.WithStats,
+		"",
 	)
 	if err != nil {
 		return mcp.NewToolResultErrorf("Failed to create analysis: %v", err), nil
 	}
 	defer analysis.Close()
 
+	if req.MinSeverity != "" {
+		severity, err := common.ParseSeverity(req.MinSeverity)
+		if err != nil {
+			return mcp.NewToolResultErrorf("Failed to parse minSeverity: %v", err), nil
+		}
+		analysis.MinSeverity = severity
+	}
+
+	analysis.Structured = req.Structured
+
 	// Run the analysis
 	analysis.RunAnalysis()
-	if req.Explain {
+	if req.Explain && !req.Structured {
 
 		var output string
 		err := analysis.GetAIResults(output, req.Anonymize)
@@ -463,6 +495,12 @@ func (s *K8sGptMCPServer) handleAnalyze(ctx context.Context, request mcp.CallToo
 		plainText := stripANSI(string(outputBytes))
 		return mcp.NewToolResultText(plainText), nil
 	} else {
+		if req.Explain {
+			// Structured explanations are returned in the JSON output.
+			if err := analysis.GetAIResults("json", req.Anonymize); err != nil {
+				return mcp.NewToolResultErrorf("Failed to get results from AI: %v", err), nil
+			}
+		}
 		// Get the output
 		output, err := analysis.PrintOutput("json")
 		if err != nil {
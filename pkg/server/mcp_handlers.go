@@ -213,6 +213,15 @@ func marshalJSON(data interface{}) (string, error) {
 	return string(jsonData), nil
 }
 
+// kubernetesClient returns the client of the server, or one for the default
+// context when it has none
+func (s *K8sGptMCPServer) kubernetesClient() (*kubernetes.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+	return kubernetes.NewClient("", "")
+}
+
 // handleListResources lists Kubernetes resources of a specific type
 func (s *K8sGptMCPServer) handleListResources(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	var req struct {
@@ -246,7 +255,7 @@ func (s *K8sGptMCPServer) handleListResources(ctx context.Context, request mcp.C
 		req.Limit = MaxListLimit
 	}
 
-	client, err := kubernetes.NewClient("", "")
+	client, err := s.kubernetesClient()
 	if err != nil {
 		return mcp.NewToolResultErrorf("Failed to create Kubernetes client: %v", err), nil
 	}
@@ -296,7 +305,7 @@ func (s *K8sGptMCPServer) handleGetResource(ctx context.Context, request mcp.Cal
 		return mcp.NewToolResultErrorf("%v", err), nil
 	}
 
-	client, err := kubernetes.NewClient("", "")
+	client, err := s.kubernetesClient()
 	if err != nil {
 		return mcp.NewToolResultErrorf("Failed to create Kubernetes client: %v", err), nil
 	}
@@ -318,7 +327,7 @@ func (s *K8sGptMCPServer) handleGetResource(ctx context.Context, request mcp.Cal
 
 // handleListNamespaces lists all namespaces in the cluster
 func (s *K8sGptMCPServer) handleListNamespaces(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	client, err := kubernetes.NewClient("", "")
+	client, err := s.kubernetesClient()
 	if err != nil {
 		return mcp.NewToolResultErrorf("Failed to create Kubernetes client: %v", err), nil
 	}
@@ -354,7 +363,7 @@ func (s *K8sGptMCPServer) handleListEvents(ctx context.Context, request mcp.Call
 		req.Limit = MaxListLimit
 	}
 
-	client, err := kubernetes.NewClient("", "")
+	client, err := s.kubernetesClient()
 	if err != nil {
 		return mcp.NewToolResultErrorf("Failed to create Kubernetes client: %v", err), nil
 	}
@@ -413,7 +422,7 @@ func (s *K8sGptMCPServer) handleGetLogs(ctx context.Context, request mcp.CallToo
 		req.TailLines = 100
 	}
 
-	client, err := kubernetes.NewClient("", "")
+	client, err := s.kubernetesClient()
 	if err != nil {
 		return mcp.NewToolResultErrorf("Failed to create Kubernetes client: %v", err), nil
 	}
@@ -447,7 +456,10 @@ func (s *K8sGptMCPServer) handleGetLogs(ctx context.Context, request mcp.CallToo
 
 // handleListFilters lists available and active filters
 func (s *K8sGptMCPServer) handleListFilters(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	coreFilters, additionalFilters, integrationFilters := analyzer.ListFilters()
+	coreFilters, additionalFilters, integrationFilters, err := analyzer.ListFilters()
+	if err != nil {
+		return mcp.NewToolResultErrorf("Failed to list filters: %v", err), nil
+	}
 	active := viper.GetStringSlice("active_filters")
 
 	result := map[string]interface{}{
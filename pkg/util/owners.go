@@ -0,0 +1,222 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k "k8s.io/client-go/kubernetes"
+)
+
+// maxOwnerDepth guards against ownership cycles.
+const maxOwnerDepth = 10
+
+// ownerKinds shortens the kinds used in owner chains where k8sgpt always
+// used shorter names.
+var ownerKinds = map[string]string{
+	"MutatingWebhookConfiguration":   "MutatingWebhook",
+	"ValidatingWebhookConfiguration": "ValidatingWebhook",
+}
+
+// OwnerResolver walks the OwnerReferences of objects up to their root owner.
+// Owners are fetched through the dynamic client, so any kind including
+// custom resources is followed, and every owner is fetched at most once. It
+// is safe for concurrent use.
+type OwnerResolver struct {
+	client *kubernetes.Client
+
+	mutex  sync.Mutex
+	owners map[string]*ownerEntry
+}
+
+// ownerEntry caches a single fetched owner.
+type ownerEntry struct {
+	once      sync.Once
+	found     bool
+	name      string
+	namespace string
+	refs      []metav1.OwnerReference
+}
+
+// NewOwnerResolver returns an OwnerResolver with an empty cache.
+func NewOwnerResolver(client *kubernetes.Client) *OwnerResolver {
+	return &OwnerResolver{client: client, owners: map[string]*ownerEntry{}}
+}
+
+// Chain returns the owners of an object, from its direct owner to its root
+// owner, formatted as Kind/name. The controller reference is followed when
+// there is one. Owners that cannot be fetched end the chain.
+func (r *OwnerResolver) Chain(ctx context.Context, object metav1.ObjectMeta) []string {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var chain []string
+	visited := map[string]bool{}
+	namespace, refs := object.Namespace, object.OwnerReferences
+	for len(chain) < maxOwnerDepth {
+		ref, ok := controllerRef(refs)
+		if !ok {
+			break
+		}
+		key := fmt.Sprintf("%s/%s/%s/%s", ref.APIVersion, ref.Kind, namespace, ref.Name)
+		if visited[key] {
+			break
+		}
+		visited[key] = true
+
+		entry := r.lookup(ctx, key, namespace, ref)
+		if !entry.found {
+			break
+		}
+		kind := ref.Kind
+		if short, ok := ownerKinds[kind]; ok {
+			kind = short
+		}
+		chain = append(chain, kind+"/"+entry.name)
+		namespace, refs = entry.namespace, entry.refs
+	}
+	return chain
+}
+
+func (r *OwnerResolver) lookup(ctx context.Context, key, namespace string, ref metav1.OwnerReference) *ownerEntry {
+	r.mutex.Lock()
+	entry, ok := r.owners[key]
+	if !ok {
+		entry = &ownerEntry{}
+		r.owners[key] = entry
+	}
+	r.mutex.Unlock()
+
+	entry.once.Do(func() {
+		owner, err := r.get(ctx, namespace, ref)
+		if err != nil {
+			return
+		}
+		entry.found = true
+		entry.name = owner.GetName()
+		entry.namespace = owner.GetNamespace()
+		entry.refs = owner.GetOwnerReferences()
+	})
+	return entry
+}
+
+// controllerRef returns the managing controller of an object, or its first
+// owner when none of them is marked as controller.
+func controllerRef(refs []metav1.OwnerReference) (metav1.OwnerReference, bool) {
+	if len(refs) == 0 {
+		return metav1.OwnerReference{}, false
+	}
+	for _, ref := range refs {
+		if ref.Controller != nil && *ref.Controller {
+			return ref, true
+		}
+	}
+	return refs[0], true
+}
+
+// get fetches an owner through the typed clients for the built-in kinds
+// and generically through the dynamic client for any other kind, including
+// custom resources that reuse a built-in kind name in their own group.
+func (r *OwnerResolver) get(ctx context.Context, namespace string, ref metav1.OwnerReference) (metav1.Object, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("no client to fetch %s/%s", ref.Kind, ref.Name)
+	}
+	if ref.Name == "" {
+		return nil, fmt.Errorf("owner reference of kind %s has no name", ref.Kind)
+	}
+	if get, ok := typedOwnerGetter(ref); ok && r.client.Client != nil {
+		return get(ctx, r.client.GetClient(), namespace, ref.Name)
+	}
+	if r.client.DynamicClient == nil {
+		return nil, fmt.Errorf("cannot fetch owner of kind %s without a dynamic client", ref.Kind)
+	}
+
+	gvr, namespaced := r.resource(ref)
+	resource := r.client.DynamicClient.Resource(gvr)
+	if namespaced && namespace != "" {
+		return resource.Namespace(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	}
+	return resource.Get(ctx, ref.Name, metav1.GetOptions{})
+}
+
+// resource maps the kind of an owner reference to its resource using the
+// RESTMapper of the client, falling back to the conventional plural name.
+func (r *OwnerResolver) resource(ref metav1.OwnerReference) (schema.GroupVersionResource, bool) {
+	gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+	if r.client.CtrlClient != nil {
+		if mapping, err := r.client.CtrlClient.RESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err == nil {
+			return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace
+		}
+	}
+	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
+	return gvr, true
+}
+
+type ownerGetter func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error)
+
+// typedOwnerGetter returns the typed getter for the group and kind of ref.
+// References without an API version are assumed to name a built-in kind.
+func typedOwnerGetter(ref metav1.OwnerReference) (ownerGetter, bool) {
+	if ref.APIVersion == "" {
+		for groupKind, get := range typedOwnerGetters {
+			if groupKind.Kind == ref.Kind {
+				return get, true
+			}
+		}
+		return nil, false
+	}
+	gv, err := schema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		return nil, false
+	}
+	get, ok := typedOwnerGetters[gv.WithKind(ref.Kind).GroupKind()]
+	return get, ok
+}
+
+// typedOwnerGetters fetch the built-in kinds that commonly own objects.
+var typedOwnerGetters = map[schema.GroupKind]ownerGetter{
+	{Group: "apps", Kind: "ReplicaSet"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "apps", Kind: "Deployment"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "apps", Kind: "StatefulSet"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "apps", Kind: "DaemonSet"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.AppsV1().DaemonSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "batch", Kind: "Job"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "batch", Kind: "CronJob"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.BatchV1().CronJobs(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "networking.k8s.io", Kind: "Ingress"}: func(ctx context.Context, client k.Interface, namespace, name string) (metav1.Object, error) {
+		return client.NetworkingV1().Ingresses(namespace).Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}: func(ctx context.Context, client k.Interface, _, name string) (metav1.Object, error) {
+		return client.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+	},
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}: func(ctx context.Context, client k.Interface, _, name string) (metav1.Object, error) {
+		return client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(ctx, name, metav1.GetOptions{})
+	},
+}
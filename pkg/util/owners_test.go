@@ -0,0 +1,133 @@
+/*
+Copyright 2024 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package util
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func ownedBy(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: kind, Name: name, Controller: &controller}}
+}
+
+func TestOwnerResolverChain(t *testing.T) {
+	rollout := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "Rollout",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+	}}
+	// A custom resource that reuses the name of a built-in kind.
+	serverless := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "serving.example.com/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "fn", "namespace": "default"},
+	}}
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			{Group: "argoproj.io", Version: "v1alpha1", Resource: "rollouts"}:      "RolloutList",
+			{Group: "serving.example.com", Version: "v1", Resource: "deployments"}: "DeploymentList",
+		},
+		rollout, serverless)
+
+	clientset := fake.NewSimpleClientset(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-7d9f", Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "argoproj.io/v1alpha1", Kind: "Rollout", Name: "web"}},
+		}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name: "backup-28012345", Namespace: "default",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "CronJob", Name: "backup"}},
+		}},
+		&batchv1.CronJob{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "loop-a", Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "loop-b"),
+		}},
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "loop-b", Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "loop-a"),
+		}},
+	)
+	resolver := NewOwnerResolver(&kubernetes.Client{Client: clientset, DynamicClient: dynamicClient})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		owners   []metav1.OwnerReference
+		expected []string
+	}{
+		{
+			name:     "custom resource owner",
+			owners:   ownedBy("ReplicaSet", "web-7d9f"),
+			expected: []string{"ReplicaSet/web-7d9f", "Rollout/web"},
+		},
+		{
+			name:     "custom resource with a built-in kind name",
+			owners:   []metav1.OwnerReference{{APIVersion: "serving.example.com/v1", Kind: "Deployment", Name: "fn"}},
+			expected: []string{"Deployment/fn"},
+		},
+		{
+			name:     "job owned by cronjob",
+			owners:   []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "backup-28012345"}},
+			expected: []string{"Job/backup-28012345", "CronJob/backup"},
+		},
+		{
+			name:     "missing owner",
+			owners:   ownedBy("Deployment", "gone"),
+			expected: nil,
+		},
+		{
+			name:     "ownership cycle",
+			owners:   ownedBy("ReplicaSet", "loop-a"),
+			expected: []string{"ReplicaSet/loop-a", "ReplicaSet/loop-b"},
+		},
+		{
+			name:     "no owner",
+			expected: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meta := metav1.ObjectMeta{Name: "pod", Namespace: "default", OwnerReferences: tt.owners}
+			require.Equal(t, tt.expected, resolver.Chain(ctx, meta))
+		})
+	}
+}
+
+func TestOwnerResolverCachesLookups(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+			Name: "web-7d9f", Namespace: "default", OwnerReferences: ownedBy("Deployment", "web"),
+		}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"}},
+	)
+	resolver := NewOwnerResolver(&kubernetes.Client{Client: clientset})
+
+	for _, pod := range []string{"web-7d9f-a", "web-7d9f-b", "web-7d9f-c"} {
+		meta := metav1.ObjectMeta{Name: pod, Namespace: "default", OwnerReferences: ownedBy("ReplicaSet", "web-7d9f")}
+		require.Equal(t, []string{"ReplicaSet/web-7d9f", "Deployment/web"}, resolver.Chain(context.Background(), meta))
+	}
+	require.Len(t, clientset.Actions(), 2)
+}
@@ -37,83 +37,15 @@ import (
 
 var anonymizePattern = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789!@#$%^&*()-_=+[]{}|;':\",./<>?")
 
+// GetParent returns the root owner of an object as Kind/name. Analyzers
+// should prefer the resolver of their analysis, which caches lookups, see
+// OwnerResolver.
 func GetParent(client *kubernetes.Client, meta metav1.ObjectMeta) (string, bool) {
-	if meta.OwnerReferences != nil {
-		for _, owner := range meta.OwnerReferences {
-			switch owner.Kind {
-			case "ReplicaSet":
-				rs, err := client.GetClient().AppsV1().ReplicaSets(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if rs.OwnerReferences != nil {
-					return GetParent(client, rs.ObjectMeta)
-				}
-				return "ReplicaSet/" + rs.Name, true
-
-			case "Deployment":
-				dep, err := client.GetClient().AppsV1().Deployments(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if dep.OwnerReferences != nil {
-					return GetParent(client, dep.ObjectMeta)
-				}
-				return "Deployment/" + dep.Name, true
-
-			case "StatefulSet":
-				sts, err := client.GetClient().AppsV1().StatefulSets(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if sts.OwnerReferences != nil {
-					return GetParent(client, sts.ObjectMeta)
-				}
-				return "StatefulSet/" + sts.Name, true
-
-			case "DaemonSet":
-				ds, err := client.GetClient().AppsV1().DaemonSets(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if ds.OwnerReferences != nil {
-					return GetParent(client, ds.ObjectMeta)
-				}
-				return "DaemonSet/" + ds.Name, true
-
-			case "Ingress":
-				ds, err := client.GetClient().NetworkingV1().Ingresses(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if ds.OwnerReferences != nil {
-					return GetParent(client, ds.ObjectMeta)
-				}
-				return "Ingress/" + ds.Name, true
-
-			case "MutatingWebhookConfiguration":
-				mw, err := client.GetClient().AdmissionregistrationV1().MutatingWebhookConfigurations().Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if mw.OwnerReferences != nil {
-					return GetParent(client, mw.ObjectMeta)
-				}
-				return "MutatingWebhook/" + mw.Name, true
-
-			case "ValidatingWebhookConfiguration":
-				vw, err := client.GetClient().AdmissionregistrationV1().ValidatingWebhookConfigurations().Get(context.Background(), owner.Name, metav1.GetOptions{})
-				if err != nil {
-					return "", false
-				}
-				if vw.OwnerReferences != nil {
-					return GetParent(client, vw.ObjectMeta)
-				}
-				return "ValidatingWebhook/" + vw.Name, true
-			}
-		}
+	chain := NewOwnerResolver(client).Chain(context.Background(), meta)
+	if len(chain) == 0 {
+		return "", false
 	}
-	return "", false
+	return chain[len(chain)-1], true
 }
 
 func RemoveDuplicates(slice []string) ([]string, []string) {